@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/dagger/dagger/cmd/codegen/generator"
-	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
-	typescriptgenerator "github.com/dagger/dagger/cmd/codegen/generator/typescript"
+	_ "github.com/dagger/dagger/cmd/codegen/generator/go"
+	_ "github.com/dagger/dagger/cmd/codegen/generator/ruby"
+	_ "github.com/dagger/dagger/cmd/codegen/generator/typescript"
 	"github.com/dagger/dagger/cmd/codegen/introspection"
 )
 
 func Generate(ctx context.Context, cfg generator.Config) (err error) {
 	logsW := os.Stdout
+	cfg.Logs = logsW
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
 
 	if cfg.ModuleName != "" {
 		fmt.Fprintf(logsW, "generating %s module: %s\n", cfg.Lang, cfg.ModuleName)
@@ -23,37 +31,43 @@ func Generate(ctx context.Context, cfg generator.Config) (err error) {
 		fmt.Fprintf(logsW, "generating %s SDK client\n", cfg.Lang)
 	}
 
-	var introspectionSchema *introspection.Schema
-	var introspectionSchemaVersion string
-	if cfg.IntrospectionJSON != "" {
-		var resp introspection.Response
-		if err := json.Unmarshal([]byte(cfg.IntrospectionJSON), &resp); err != nil {
-			return fmt.Errorf("unmarshal introspection json: %w", err)
-		}
-		introspectionSchema = resp.Schema
-		introspectionSchemaVersion = resp.SchemaVersion
-	} else {
-		introspectionSchema, introspectionSchemaVersion, err = generator.Introspect(ctx, cfg.Dag)
-		if err != nil {
-			return err
-		}
+	introspectionSchema, introspectionSchemaVersion, err := generator.LoadSchema(ctx, cfg)
+	if err != nil {
+		return err
 	}
 
+	return generateSchema(ctx, logsW, introspectionSchema, introspectionSchemaVersion, cfg)
+}
+
+// generateSchema generates the code for the given schema and writes it to the
+// output directory, running the post commands and regenerating as many times
+// as needed.
+func generateSchema(ctx context.Context, logsW io.Writer, schema *introspection.Schema, schemaVersion string, cfg generator.Config) error {
+	var overlays []fs.FS
 	for ctx.Err() == nil {
-		generated, err := generate(ctx, introspectionSchema, introspectionSchemaVersion, cfg)
+		generated, err := generator.Generate(ctx, schema, schemaVersion, cfg)
 		if err != nil {
 			return err
 		}
+		if err := generator.ValidateOverlay(generated.Overlay); err != nil {
+			return fmt.Errorf("invalid generated code: %w", err)
+		}
+		overlays = append(overlays, generated.Overlay)
 
-		if err := generator.Overlay(ctx, logsW, generated.Overlay, cfg.OutputDir); err != nil {
+		if err := generator.Overlay(ctx, logsW, generated.Overlay, cfg.OutputDir, generator.OverlayOptions{
+			UserRegionMarkers: cfg.UserRegionMarkers,
+			Backup:            cfg.Backup,
+			BackupSuffix:      cfg.BackupSuffix,
+		}); err != nil {
 			return fmt.Errorf("failed to overlay generated code: %w", err)
 		}
 
+		cmdDir, err := generator.PostCommandsDir(cfg)
+		if err != nil {
+			return err
+		}
 		for _, cmd := range generated.PostCommands {
-			cmd.Dir = cfg.OutputDir
-			if cfg.ModuleName != "" {
-				cmd.Dir = filepath.Join(cfg.OutputDir, cfg.ModuleSourcePath)
-			}
+			cmd.Dir = cmdDir
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 			fmt.Fprintln(logsW, "running post-command:", strings.Join(cmd.Args, " "))
@@ -65,6 +79,9 @@ func Generate(ctx context.Context, cfg generator.Config) (err error) {
 		}
 
 		if !generated.NeedRegenerate {
+			if err := writeManifest(logsW, schemaVersion, cfg, overlays); err != nil {
+				return err
+			}
 			fmt.Fprintln(logsW, "done!")
 			break
 		}
@@ -75,31 +92,41 @@ func Generate(ctx context.Context, cfg generator.Config) (err error) {
 	return ctx.Err()
 }
 
-func generate(ctx context.Context, introspectionSchema *introspection.Schema, introspectionSchemaVersion string, cfg generator.Config) (*generator.GeneratedState, error) {
-	generator.SetSchemaParents(introspectionSchema)
+// writeManifest writes the manifest of the files generated by all the passes,
+// if enabled.
+func writeManifest(logsW io.Writer, schemaVersion string, cfg generator.Config, overlays []fs.FS) error {
+	if cfg.ManifestPath == "" {
+		return nil
+	}
 
-	var gen generator.Generator
-	switch cfg.Lang {
-	case generator.SDKLangGo:
-		gen = &gogenerator.GoGenerator{
-			Config: cfg,
-		}
-	case generator.SDKLangTypeScript:
-		gen = &typescriptgenerator.TypeScriptGenerator{
-			Config: cfg,
-		}
+	manifest, err := generator.NewManifest(cfg.OutputDir, overlays...)
+	if err != nil {
+		return err
+	}
 
-	default:
-		sdks := []string{
-			string(generator.SDKLangGo),
-			string(generator.SDKLangTypeScript),
+	path := filepath.Join(cfg.OutputDir, cfg.ManifestPath)
+	if len(cfg.ChangedTypes) > 0 {
+		// the files of the unchanged types are still the ones of the previous
+		// generation
+		previous, err := generator.ReadManifest(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if previous != nil {
+			for file, sum := range previous.Files {
+				if _, ok := manifest.Files[file]; !ok {
+					manifest.Files[file] = sum
+				}
+			}
 		}
-		return nil, fmt.Errorf("use target SDK language: %s: %w", sdks, generator.ErrUnknownSDKLang)
 	}
-
-	if cfg.ClientOnly {
-		return gen.GenerateClient(ctx, introspectionSchema, introspectionSchemaVersion)
+	if cfg.ManifestProvenance {
+		manifest.Provenance = generator.NewProvenance(schemaVersion, filepath.Join(cfg.OutputDir, cfg.ModuleSourcePath))
 	}
 
-	return gen.GenerateModule(ctx, introspectionSchema, introspectionSchemaVersion)
+	fmt.Fprintln(logsW, "writing manifest", cfg.ManifestPath)
+	if err := generator.WriteManifest(path, manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
 }
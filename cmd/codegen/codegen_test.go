@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+)
+
+func TestSupportedLangs(t *testing.T) {
+	langs := generator.SupportedLangs()
+	require.Contains(t, langs, generator.SDKLangGo)
+	require.Contains(t, langs, generator.SDKLangTypeScript)
+	require.Contains(t, langs, generator.SDKLangRuby)
+
+	for _, lang := range langs {
+		gen, err := generator.New(generator.Config{Lang: lang})
+		require.NoError(t, err)
+		require.NotNil(t, gen)
+	}
+
+	_, err := generator.New(generator.Config{Lang: "cobol"})
+	require.ErrorIs(t, err, generator.ErrUnknownSDKLang)
+}
+
+func TestGenerateMulti(t *testing.T) {
+	introspectionJSON, err := os.ReadFile(filepath.Join("generator", "go", "testdata", "basic.json"))
+	require.NoError(t, err)
+
+	// the Go client is generated in a package of the Go module of the working
+	// directory
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("go.mod", []byte("module example.com/poly\n\ngo 1.24\n"), 0o600))
+	require.NoError(t, os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0o600))
+
+	cfgs := []generator.Config{
+		{Lang: generator.SDKLangGo, ClientOnly: true, OutputDir: "go", IntrospectionJSON: string(introspectionJSON)},
+		{Lang: generator.SDKLangTypeScript, ClientOnly: true, OutputDir: filepath.Join("sdk", "ts"), IntrospectionJSON: string(introspectionJSON)},
+	}
+	generated, err := generator.GenerateMulti(context.Background(), cfgs)
+	require.NoError(t, err)
+
+	goClient, err := fs.ReadFile(generated.Overlay, "go/dagger.gen.go")
+	require.NoError(t, err)
+	require.Contains(t, string(goClient), "package dagger\n")
+	require.Contains(t, string(goClient), "func (r *Container) From(address string) *Container {")
+	tsClient, err := fs.ReadFile(generated.Overlay, "sdk/ts/client.gen.ts")
+	require.NoError(t, err)
+	require.Contains(t, string(tsClient), "export class Container extends BaseClient {")
+
+	// nothing is generated outside of the output directories
+	entries, err := fs.ReadDir(generated.Overlay, ".")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{"go", "sdk"}, names)
+
+	require.Len(t, generated.PostCommands, 1)
+	require.Equal(t, []string{"go", "mod", "tidy"}, generated.PostCommands[0].Args)
+	require.Equal(t, "go", generated.PostCommands[0].Dir)
+	require.False(t, generated.NeedRegenerate)
+
+	t.Run("collision", func(t *testing.T) {
+		_, err := generator.GenerateMulti(context.Background(), []generator.Config{cfgs[0], cfgs[0]})
+		require.ErrorContains(t, err, "go/dag/dag.gen.go is generated by both config 0 (go) and config 1 (go)")
+	})
+
+	t.Run("non-local output dir", func(t *testing.T) {
+		cfg := cfgs[1]
+		cfg.OutputDir = filepath.Join("..", "ts")
+		_, err := generator.GenerateMulti(context.Background(), []generator.Config{cfgs[0], cfg})
+		require.ErrorContains(t, err, `config 1 (typescript): output dir "../ts" must be a local path`)
+	})
+}
+
+func TestGenerateFS(t *testing.T) {
+	introspectionJSON, err := os.ReadFile(filepath.Join("generator", "go", "testdata", "basic.json"))
+	require.NoError(t, err)
+
+	// the output directory only exists in the sources
+	t.Chdir(t.TempDir())
+	src := fstest.MapFS{
+		"ci/dagger.json": {Data: []byte(`{"name": "my-module", "sdk": "go"}`)},
+	}
+
+	t.Run("non-local output dir", func(t *testing.T) {
+		_, err := generator.GenerateFS(context.Background(), generator.Config{
+			Lang:              generator.SDKLangGo,
+			OutputDir:         filepath.Join("..", "ci"),
+			ClientOnly:        true,
+			IntrospectionJSON: string(introspectionJSON),
+		}, src)
+		require.ErrorContains(t, err, `output dir "../ci" must be a local path`)
+	})
+
+	// the second pass of a new Go module loads its package
+	requirePackageLoading(t)
+	generated, err := generator.GenerateFS(context.Background(), generator.Config{
+		Lang:              generator.SDKLangGo,
+		OutputDir:         "ci",
+		ModuleName:        "my-module",
+		ModuleSourcePath:  ".",
+		IsInit:            true,
+		IntrospectionJSON: string(introspectionJSON),
+	}, src)
+	require.NoError(t, err)
+
+	// the module is scaffolded by the first pass, and its client generated
+	// for the loaded package by the second one
+	mainSrc, err := fs.ReadFile(generated, "ci/main.go")
+	require.NoError(t, err)
+	require.Contains(t, string(mainSrc), "type MyModule struct{}")
+	client, err := fs.ReadFile(generated, "ci/internal/dagger/dagger.gen.go")
+	require.NoError(t, err)
+	require.Contains(t, string(client), "func (r *Container) From(address string) *Container {")
+	_, err = fs.Stat(generated, "ci/go.mod")
+	require.NoError(t, err)
+
+	// the unchanged sources aren't returned, and nothing is written on the
+	// host
+	_, err = fs.Stat(generated, "ci/dagger.json")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat("ci")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// requirePackageLoading skips the test if the packages loaded by the Go
+// generator can't be type-checked with the Go toolchain, whose export data
+// may be of a newer version than golang.org/x/tools supports.
+func requirePackageLoading(t *testing.T) {
+	t.Helper()
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, "context")
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	if len(pkgs[0].Errors) > 0 {
+		t.Skipf("can't load packages with this Go toolchain: %v", pkgs[0].Errors)
+	}
+}
+
+func TestFileConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codegen.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("clientOnly: true\ndocCommentWrap: 80\nclientRetryBackoff: 100ms\n"), 0o600))
+
+	// the flags set on the command line override the file, whose omitted keys
+	// keep the defaults of the flags
+	flags := defaultConfig
+	flags.DocCommentWrap = 100
+	cfg, err := fileConfig(path, flags)
+	require.NoError(t, err)
+	require.Equal(t, generator.SDKLangGo, cfg.Lang)
+	require.Equal(t, ".", cfg.OutputDir)
+	require.True(t, cfg.ClientOnly)
+	require.Equal(t, 100, cfg.DocCommentWrap)
+	require.Equal(t, 100*time.Millisecond, cfg.ClientRetryBackoff)
+	require.False(t, cfg.UserRegionMarkers.Enabled())
+}
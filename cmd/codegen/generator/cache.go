@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// CacheableDirective is the directive marking the fields whose value doesn't
+// change for the same query, which the client can fetch once with
+// Config.GenerateFieldCache, e.g. `digest: String! @cacheable`.
+const CacheableDirective = "cacheable"
+
+// IsCacheableField returns true if the field of the named type is marked with
+// CacheableDirective, or if `Type.field` matches one of the patterns of
+// Config.CacheableFields (e.g. `*.id` or `Container.platform`). Only the
+// fields whose value is fetched by a request, the scalars, enums and lists of
+// them but the void ones, can be cached.
+func IsCacheableField(typeName string, f *introspection.Field, patterns []string) bool {
+	if !isFetchedField(f.TypeRef) || f.TypeRef.IsVoid() {
+		return false
+	}
+	if f.Directives.Directive(CacheableDirective) != nil {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, typeName+"."+f.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCacheableFields returns an error if a pattern of
+// Config.CacheableFields is malformed.
+func validateCacheableFields(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("cacheable field pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// isFetchedField returns true if the reference is a scalar, an enum or a list
+// of them, fetched by a request instead of extending the query.
+func isFetchedField(ref *introspection.TypeRef) bool {
+	for ref.Kind == introspection.TypeKindNonNull || ref.Kind == introspection.TypeKindList {
+		ref = ref.OfType
+	}
+	return ref.Kind == introspection.TypeKindScalar || ref.Kind == introspection.TypeKindEnum
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// DependentTypes returns the names of the given types and of the types
+// depending on them, transitively: the types with a field, an argument or an
+// input field of a dependent type, implementing a dependent interface or with
+// a dependent possible type.
+// It's an error if a type isn't in the schema.
+func DependentTypes(schema *introspection.Schema, names []string) (map[string]bool, error) {
+	dependents := make(map[string]bool, len(names))
+	for _, name := range names {
+		if schema.Types.Get(name) == nil {
+			return nil, fmt.Errorf("unknown type %q", name)
+		}
+		dependents[name] = true
+	}
+
+	// iterate until no new dependent is found, the schemas are small enough
+	for found := true; found; {
+		found = false
+		for _, t := range schema.Types {
+			if !dependents[t.Name] && dependsOn(t, dependents) {
+				dependents[t.Name] = true
+				found = true
+			}
+		}
+	}
+	return dependents, nil
+}
+
+// dependsOn returns true if the type directly references one of the types.
+func dependsOn(t *introspection.Type, types map[string]bool) bool {
+	for _, f := range t.Fields {
+		if types[typeRefName(f.TypeRef)] {
+			return true
+		}
+		for _, arg := range f.Args {
+			if types[typeRefName(arg.TypeRef)] {
+				return true
+			}
+		}
+	}
+	for _, f := range t.InputFields {
+		if types[typeRefName(f.TypeRef)] {
+			return true
+		}
+	}
+	for _, iface := range t.Interfaces {
+		if types[iface.Name] {
+			return true
+		}
+	}
+	for _, possible := range t.PossibleTypes {
+		if types[possible.Name] {
+			return true
+		}
+	}
+	return false
+}
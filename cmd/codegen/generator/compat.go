@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// IntersectSchemas removes from the schema everything that isn't in all the
+// other schemas, so that the code generated for it works with all of them,
+// e.g. with two versions of the engine:
+//
+//   - the types missing from a schema, or of another kind;
+//   - the fields missing from a schema or of another type, or whose required
+//     arguments differ;
+//   - the optional arguments missing from a schema or of another type;
+//   - the enum values missing from a schema;
+//   - the optional input fields missing from a schema or of another type.
+//
+// Removing a type removes what depends on it in turn, e.g. the fields
+// returning it, and an input type whose required fields differ is removed as
+// a whole. Removing the query type, or all its fields, is an error.
+func IntersectSchemas(schema *introspection.Schema, others []*introspection.Schema) error {
+	if len(others) == 0 {
+		return nil
+	}
+
+	// removing something may make other things removable, e.g. a type with
+	// all its fields removed, so remove until nothing changes
+	removed := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		types := make(introspection.Types, 0, len(schema.Types))
+		for _, t := range schema.Types {
+			keep, typeChanged := intersectType(t, others, removed)
+			changed = changed || typeChanged || !keep
+			if !keep {
+				if t.Name == schema.QueryType.Name {
+					if len(t.Fields) == 0 {
+						return fmt.Errorf("no field of the query type %q is in all the schemas", t.Name)
+					}
+					return fmt.Errorf("query type %q isn't in all the schemas", t.Name)
+				}
+				removed[t.Name] = true
+				continue
+			}
+			types = append(types, t)
+		}
+		schema.Types = types
+	}
+	return nil
+}
+
+// intersectType removes from the type what isn't in all the other schemas or
+// depends on the removed types, and returns whether the type can be kept and
+// whether it changed. The built-in scalars are in all the schemas.
+func intersectType(t *introspection.Type, others []*introspection.Schema, removed map[string]bool) (keep bool, changed bool) {
+	if t.Kind == introspection.TypeKindScalar && builtinScalars[t.Name] {
+		return true, false
+	}
+	otherTypes := make([]*introspection.Type, len(others))
+	for i, other := range others {
+		otherType := other.Types.Get(t.Name)
+		if otherType == nil || otherType.Kind != t.Kind {
+			return false, true
+		}
+		otherTypes[i] = otherType
+	}
+
+	switch t.Kind {
+	case introspection.TypeKindObject, introspection.TypeKindInterface:
+		fields := make([]*introspection.Field, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if intersectField(f, otherTypes, removed) {
+				fields = append(fields, f)
+			}
+		}
+		changed = len(fields) != len(t.Fields)
+		t.Fields = fields
+
+		interfaces := keptTypes(t.Interfaces, removed)
+		possibleTypes := keptTypes(t.PossibleTypes, removed)
+		changed = changed || len(interfaces) != len(t.Interfaces) || len(possibleTypes) != len(t.PossibleTypes)
+		t.Interfaces, t.PossibleTypes = interfaces, possibleTypes
+		return len(t.Fields) > 0, changed
+
+	case introspection.TypeKindUnion:
+		possibleTypes := keptTypes(t.PossibleTypes, removed)
+		changed = len(possibleTypes) != len(t.PossibleTypes)
+		t.PossibleTypes = possibleTypes
+		return len(t.PossibleTypes) > 0, changed
+
+	case introspection.TypeKindInputObject:
+		fields, ok := intersectInputValues(t.InputFields, otherTypes, removed, func(t *introspection.Type) introspection.InputValues {
+			return t.InputFields
+		})
+		if !ok {
+			return false, true
+		}
+		changed = len(fields) != len(t.InputFields)
+		t.InputFields = fields
+		return len(t.InputFields) > 0, changed
+
+	case introspection.TypeKindEnum:
+		values := make([]introspection.EnumValue, 0, len(t.EnumValues))
+		for _, v := range t.EnumValues {
+			if inAllEnums(otherTypes, v.Name) {
+				values = append(values, v)
+			}
+		}
+		changed = len(values) != len(t.EnumValues)
+		t.EnumValues = values
+		return len(t.EnumValues) > 0, changed
+	}
+	return true, false
+}
+
+// intersectField removes the optional arguments of the field that aren't in
+// all the other types, and returns whether the field can be kept.
+func intersectField(f *introspection.Field, otherTypes []*introspection.Type, removed map[string]bool) bool {
+	if removed[typeRefName(f.TypeRef)] {
+		return false
+	}
+	otherFields := make([]*introspection.Field, len(otherTypes))
+	for i, otherType := range otherTypes {
+		otherFields[i] = findField(otherType, f.Name)
+		if otherFields[i] == nil || !reflect.DeepEqual(otherFields[i].TypeRef, f.TypeRef) {
+			return false
+		}
+	}
+
+	args, ok := intersectInputValues(f.Args, otherFields, removed, func(f *introspection.Field) introspection.InputValues {
+		return f.Args
+	})
+	if !ok {
+		return false
+	}
+	f.Args = args
+	return true
+}
+
+// intersectInputValues returns the arguments or input fields that are in all
+// the others, with the same type, or false if a required one isn't, or if one
+// of the others is required but missing.
+func intersectInputValues[T any](values introspection.InputValues, others []T, removed map[string]bool, otherValues func(T) introspection.InputValues) (introspection.InputValues, bool) {
+	kept := make(introspection.InputValues, 0, len(values))
+	for _, v := range values {
+		same := !removed[typeRefName(v.TypeRef)]
+		for _, other := range others {
+			otherValue := findInputValue(otherValues(other), v.Name)
+			if otherValue == nil || !reflect.DeepEqual(otherValue.TypeRef, v.TypeRef) {
+				same = false
+				break
+			}
+		}
+		switch {
+		case same:
+			kept = append(kept, v)
+		case !v.IsOptional():
+			return nil, false
+		}
+	}
+
+	for _, other := range others {
+		for _, otherValue := range otherValues(other) {
+			if !otherValue.IsOptional() && findInputValue(values, otherValue.Name) == nil {
+				return nil, false
+			}
+		}
+	}
+	return kept, true
+}
+
+// keptTypes returns the types that aren't removed.
+func keptTypes(types []*introspection.Type, removed map[string]bool) []*introspection.Type {
+	kept := make([]*introspection.Type, 0, len(types))
+	for _, t := range types {
+		if !removed[t.Name] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// inAllEnums returns true if all the enum types have the named value.
+func inAllEnums(types []*introspection.Type, name string) bool {
+	for _, t := range types {
+		if !hasEnumValue(t, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func findField(t *introspection.Type, name string) *introspection.Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func findInputValue(values introspection.InputValues, name string) *introspection.InputValue {
+	for i := range values {
+		if values[i].Name == name {
+			return &values[i]
+		}
+	}
+	return nil
+}
+
+func hasEnumValue(t *introspection.Type, name string) bool {
+	for _, v := range t.EnumValues {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
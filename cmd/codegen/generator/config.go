@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a Config from a JSON or YAML file, depending on its
+// extension, see ReadConfig, and validates it.
+func LoadConfig(path string) (Config, error) {
+	cfg, err := ReadConfig(path, Config{})
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ReadConfig reads a JSON or YAML file, depending on its extension, over
+// base, whose fields are kept if the file doesn't set them, e.g. the defaults
+// of the codegen CLI. The keys are the names of the Config fields, matched
+// case insensitively (e.g. `outputDir`), and unknown keys are an error. The
+// durations are either a string such as "100ms" or a number of nanoseconds.
+// The Dag client and the NameTransform function can't be set from a file.
+func ReadConfig(path string, base Config) (Config, error) {
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	// decode through a map so both formats share the same keys
+	var v map[string]any
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(dt))
+		dec.UseNumber()
+		err = dec.Decode(&v)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(dt, &v)
+	default:
+		return Config{}, fmt.Errorf("unsupported config format %q, expected .json, .yaml or .yml", ext)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := parseDurations(v); err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if dt, err = json.Marshal(v); err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	cfg := base
+	dec := json.NewDecoder(bytes.NewReader(dt))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseDurations replaces the duration strings of the time.Duration fields
+// of a config file by their number of nanoseconds, as they are decoded.
+func parseDurations(v map[string]any) error {
+	durationType := reflect.TypeFor[time.Duration]()
+	for _, field := range reflect.VisibleFields(reflect.TypeFor[Config]()) {
+		if field.Type != durationType {
+			continue
+		}
+		for key, value := range v {
+			s, ok := value.(string)
+			if !ok || !strings.EqualFold(key, field.Name) {
+				continue
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			v[key] = int64(d)
+		}
+	}
+	return nil
+}
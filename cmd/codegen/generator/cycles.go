@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// IsRecursiveInputField returns true if the input field of the named input
+// type closes a cycle of input types: it's outside of a list and leads back
+// to the type through other such fields. An SDK holding input fields by value
+// must hold these ones by reference, or the types would contain themselves.
+func IsRecursiveInputField(schema *introspection.Schema, typeName string, f introspection.InputValue) bool {
+	name, ok := inputValueTypeName(f.TypeRef)
+	return ok && reachesInputType(schema, name, typeName, map[string]bool{})
+}
+
+// reachesInputType returns true if the input type to is from or is reached
+// from it through input fields held by value.
+func reachesInputType(schema *introspection.Schema, from, to string, visited map[string]bool) bool {
+	if from == to {
+		return true
+	}
+	if visited[from] {
+		return false
+	}
+	visited[from] = true
+
+	t := schema.Types.Get(from)
+	if t == nil {
+		return false
+	}
+	for _, f := range t.InputFields {
+		if name, ok := inputValueTypeName(f.TypeRef); ok && reachesInputType(schema, name, to, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputValueTypeName returns the name of the input type of a reference, if
+// it's an input type outside of a list.
+func inputValueTypeName(ref *introspection.TypeRef) (string, bool) {
+	if ref.Kind == introspection.TypeKindNonNull {
+		ref = ref.OfType
+	}
+	if ref.Kind != introspection.TypeKindInputObject {
+		return "", false
+	}
+	return ref.Name, true
+}
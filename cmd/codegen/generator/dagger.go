@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/psanford/memfs"
+
+	"dagger.io/dagger"
+)
+
+// GenerateInDagger generates code against the engine of cfg.Dag for the
+// sources of src, and returns src with the generated files overlaid.
+//
+// The generation itself doesn't run in a container: src is exported to a
+// scratch directory on the host, removed once done, where the generators
+// read the existing sources and the post commands run with the toolchains
+// of the host, e.g. `go mod tidy`, see GenerateFS. Only the files the
+// generation adds or changes are written back on top of src.
+//
+// cfg.OutputDir is a path in src rather than on the host.
+func GenerateInDagger(ctx context.Context, cfg Config, src *dagger.Directory) (*dagger.Directory, error) {
+	if cfg.Dag == nil {
+		return nil, errors.New("generating in dagger requires a dagger client")
+	}
+
+	scratch, err := os.MkdirTemp("", "codegen-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	if _, err := src.Export(ctx, scratch); err != nil {
+		return nil, fmt.Errorf("export sources: %w", err)
+	}
+	generated, err := generateInScratch(ctx, cfg, scratch)
+	if err != nil {
+		return nil, err
+	}
+	return OverlayToDirectory(generated, src)
+}
+
+// GenerateFS generates code for the sources of src, and returns the files
+// the generation adds to or changes in src.
+//
+// cfg.OutputDir is a path in src rather than on the host. Since generators
+// such as the Go one load the existing sources with the toolchain of their
+// language, src is copied to a private scratch directory, removed once done,
+// where all the passes are overlaid and their post commands run, e.g. `go mod
+// tidy` between the passes of a new Go module.
+func GenerateFS(ctx context.Context, cfg Config, src fs.FS) (fs.FS, error) {
+	scratch, err := os.MkdirTemp("", "codegen-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := os.CopyFS(scratch, src); err != nil {
+		return nil, fmt.Errorf("copy sources: %w", err)
+	}
+	return generateInScratch(ctx, cfg, scratch)
+}
+
+// generateInScratch runs all the passes of the generation for the sources
+// copied to scratch, and returns the files they add or change.
+func generateInScratch(ctx context.Context, cfg Config, scratch string) (fs.FS, error) {
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if !filepath.IsLocal(outputDir) {
+		return nil, fmt.Errorf("output dir %q must be a local path", cfg.OutputDir)
+	}
+	cfg.OutputDir = filepath.Join(scratch, outputDir)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	original, err := sumFiles(scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, schemaVersion, err := LoadSchema(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for ctx.Err() == nil {
+		generated, err := Generate(ctx, schema, schemaVersion, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateOverlay(generated.Overlay); err != nil {
+			return nil, fmt.Errorf("invalid generated code: %w", err)
+		}
+		if err := Overlay(ctx, io.Discard, generated.Overlay, cfg.OutputDir, OverlayOptions{
+			UserRegionMarkers: cfg.UserRegionMarkers,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to overlay generated code: %w", err)
+		}
+
+		cmdDir, err := PostCommandsDir(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, cmd := range generated.PostCommands {
+			cmd.Dir = cmdDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return nil, fmt.Errorf("post-command %s: %w\n%s", strings.Join(cmd.Args, " "), err, out)
+			}
+		}
+
+		if !generated.NeedRegenerate {
+			return changedFiles(scratch, original)
+		}
+	}
+	return nil, ctx.Err()
+}
+
+// PostCommandsDir returns the directory the post commands of a generation
+// run in: the source directory of the module, or cfg.OutputDir for a client.
+func PostCommandsDir(cfg Config) (string, error) {
+	if cfg.ModuleName == "" {
+		return cfg.OutputDir, nil
+	}
+	layout, err := ResolveModuleLayout(cfg)
+	if err != nil {
+		return "", fmt.Errorf("resolve module layout: %w", err)
+	}
+	return filepath.Join(cfg.OutputDir, layout.SourceDir), nil
+}
+
+// sumFiles returns the sha256 of the content of each file under dir, by
+// slash-separated path relative to it.
+func sumFiles(dir string) (map[string][sha256.Size]byte, error) {
+	sums := map[string][sha256.Size]byte{}
+	err := walkOverlay(os.DirFS(dir), func(path string, d fs.DirEntry) error {
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return err
+		}
+		sums[path] = sha256.Sum256(content)
+		return nil
+	})
+	return sums, err
+}
+
+// changedFiles returns the files under dir that aren't in original, or whose
+// content differs from it.
+func changedFiles(dir string, original map[string][sha256.Size]byte) (fs.FS, error) {
+	changed := memfs.New()
+	err := walkOverlay(os.DirFS(dir), func(path string, d fs.DirEntry) error {
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return err
+		}
+		if sum, ok := original[path]; ok && sum == sha256.Sum256(content) {
+			return nil
+		}
+		if parent := filepath.Dir(path); parent != "." {
+			if err := changed.MkdirAll(parent, 0o755); err != nil {
+				return err
+			}
+		}
+		return changed.WriteFile(path, content, 0o600)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// OverlayToDirectory writes the files of the overlay on top of dir.
+func OverlayToDirectory(overlay fs.FS, dir *dagger.Directory) (*dagger.Directory, error) {
+	err := walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			if path != "." {
+				dir = dir.WithNewDirectory(path)
+			}
+			return nil
+		}
+
+		content, err := fs.ReadFile(overlay, path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		dir = dir.WithNewFile(path, string(content))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dir, nil
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"dagger.io/dagger"
+)
+
+//go:embed modsourcedeps.graphql
+var moduleSourceDependenciesQuery string
+
+// DefaultResolveConcurrency is the number of dependencies resolved at the same
+// time by ResolveDependencies when no limit is given.
+const DefaultResolveConcurrency = 8
+
+// DependencyResolver returns the direct dependencies of a module dependency,
+// e.g. by querying the engine.
+type DependencyResolver func(ctx context.Context, dep ModuleSourceDependencies) ([]ModuleSourceDependencies, error)
+
+// LoadModuleDependencies returns the dependencies of the module source of
+// cfg.ModuleSourceID, queried from the engine of cfg.Dag, along with their
+// transitive dependencies if cfg.ResolveTransitiveDependencies is set, see
+// ResolveDependencies.
+func LoadModuleDependencies(ctx context.Context, cfg Config) ([]ModuleSourceDependencies, error) {
+	deps, err := queryDependencies(ctx, cfg.Dag, "ModuleSourceDependencies", map[string]any{
+		"source": dagger.ModuleSourceID(cfg.ModuleSourceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load module source dependencies: %w", err)
+	}
+	if !cfg.ResolveTransitiveDependencies {
+		return deps, nil
+	}
+	return ResolveDependencies(ctx, deps, cfg.DependencyConcurrency, EngineDependencyResolver(cfg.Dag))
+}
+
+// EngineDependencyResolver returns a DependencyResolver querying the engine of
+// dag for the dependencies of the module source of each dependency, loaded
+// from its ref and pin.
+func EngineDependencyResolver(dag *dagger.Client) DependencyResolver {
+	return func(ctx context.Context, dep ModuleSourceDependencies) ([]ModuleSourceDependencies, error) {
+		return queryDependencies(ctx, dag, "DependencyDependencies", map[string]any{
+			"refString": dep.Source,
+			"refPin":    dep.Pin,
+		})
+	}
+}
+
+// queryDependencies sends the operation of modsourcedeps.graphql, returning
+// the dependencies of the module source it selects.
+func queryDependencies(ctx context.Context, dag *dagger.Client, opName string, vars map[string]any) ([]ModuleSourceDependencies, error) {
+	var res struct {
+		Source struct {
+			Dependencies []ModuleSourceDependencies
+		}
+	}
+	err := dag.Do(ctx, &dagger.Request{
+		Query:     moduleSourceDependenciesQuery,
+		OpName:    opName,
+		Variables: vars,
+	}, &dagger.Response{
+		Data: &res,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Source.Dependencies, nil
+}
+
+// ResolveDependencies returns the dependencies with their transitive
+// dependencies, resolving at most concurrency of them at the same time, or
+// DefaultResolveConcurrency if it isn't positive, so that many dependencies
+// are resolved fast without overloading the engine.
+//
+// A dependency is resolved once even if several others depend on it. The
+// dependencies are returned in the order they're found: the given ones first,
+// then theirs, and so on. The first error cancels the resolution of the
+// others, as does canceling the context.
+func ResolveDependencies(ctx context.Context, deps []ModuleSourceDependencies, concurrency int, resolve DependencyResolver) ([]ModuleSourceDependencies, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultResolveConcurrency
+	}
+
+	var resolved []ModuleSourceDependencies
+	seen := map[ModuleSourceDependencies]bool{}
+	pending := deps
+	for len(pending) > 0 {
+		var level []ModuleSourceDependencies
+		for _, dep := range pending {
+			if !seen[dep] {
+				seen[dep] = true
+				level = append(level, dep)
+			}
+		}
+		resolved = append(resolved, level...)
+
+		// resolve the dependencies found at the same depth together, keeping
+		// their results in order for the output to be deterministic
+		results := make([][]ModuleSourceDependencies, len(level))
+		eg, egCtx := errgroup.WithContext(ctx)
+		eg.SetLimit(concurrency)
+		for i, dep := range level {
+			eg.Go(func() error {
+				if err := egCtx.Err(); err != nil {
+					return err
+				}
+				depDeps, err := resolve(egCtx, dep)
+				if err != nil {
+					return fmt.Errorf("resolve dependency %s: %w", dep.Name, err)
+				}
+				results[i] = depDeps
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return nil, err
+		}
+
+		pending = nil
+		for _, depDeps := range results {
+			pending = append(pending, depDeps...)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// ExcludeDeprecated removes from the schema the deprecated fields, optional
+// arguments, optional input fields and enum values, for
+// Config.ExcludeDeprecated, and returns the names of what it removed, e.g.
+// `Container.withFile` or `Container.withExec(skipEntrypoint)`.
+//
+// An object or interface with only deprecated fields is removed along with
+// them, unless a field, an argument or an input field that is kept still
+// depends on it: it's then kept as is rather than generated without fields.
+// The values of an enum are kept when they are all deprecated, for the same
+// reason.
+func ExcludeDeprecated(schema *introspection.Schema) []string {
+	var deprecated []*introspection.Type
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || t.Name == schema.QueryType.Name || len(t.Fields) == 0 {
+			continue
+		}
+		all := true
+		for _, f := range t.Fields {
+			all = all && f.IsDeprecated
+		}
+		if all {
+			deprecated = append(deprecated, t)
+		}
+	}
+
+	// keep the deprecated types used by the kept types, which in turn keep
+	// the types their deprecated fields depend on
+	kept := map[string]bool{}
+	for _, t := range schema.Types {
+		kept[t.Name] = true
+	}
+	for _, t := range deprecated {
+		kept[t.Name] = false
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, t := range schema.Types {
+			if !kept[t.Name] {
+				continue
+			}
+			// the deprecated fields of a kept deprecated type are kept too
+			keepAll := slices.Contains(deprecated, t)
+			for _, name := range dependencies(t, keepAll) {
+				if !kept[name] {
+					kept[name] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	var excluded []string
+	types := make(introspection.Types, 0, len(schema.Types))
+	for _, t := range schema.Types {
+		if !kept[t.Name] {
+			excluded = append(excluded, t.Name)
+			continue
+		}
+		types = append(types, t)
+		if slices.Contains(deprecated, t) {
+			continue
+		}
+
+		fields := make([]*introspection.Field, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if f.IsDeprecated {
+				excluded = append(excluded, t.Name+"."+f.Name)
+				continue
+			}
+			args := make(introspection.InputValues, 0, len(f.Args))
+			for _, arg := range f.Args {
+				if arg.IsDeprecated && arg.IsOptional() {
+					excluded = append(excluded, t.Name+"."+f.Name+"("+arg.Name+")")
+					continue
+				}
+				args = append(args, arg)
+			}
+			f.Args = args
+			fields = append(fields, f)
+		}
+		t.Fields = fields
+
+		inputFields := make([]introspection.InputValue, 0, len(t.InputFields))
+		for _, f := range t.InputFields {
+			if f.IsDeprecated && f.IsOptional() {
+				excluded = append(excluded, t.Name+"."+f.Name)
+				continue
+			}
+			inputFields = append(inputFields, f)
+		}
+		t.InputFields = inputFields
+
+		values := make([]introspection.EnumValue, 0, len(t.EnumValues))
+		for _, v := range t.EnumValues {
+			if !v.IsDeprecated {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			for _, v := range t.EnumValues {
+				if v.IsDeprecated {
+					excluded = append(excluded, t.Name+"."+v.Name)
+				}
+			}
+			t.EnumValues = values
+		}
+
+		possibleTypes := make([]*introspection.Type, 0, len(t.PossibleTypes))
+		for _, possible := range t.PossibleTypes {
+			if kept[possible.Name] {
+				possibleTypes = append(possibleTypes, possible)
+			}
+		}
+		t.PossibleTypes = possibleTypes
+	}
+
+	schema.Types = types
+	return excluded
+}
+
+// dependencies returns the names of the types the fields, arguments, input
+// fields and interfaces of the type depend on, ignoring the deprecated ones
+// unless all is set.
+func dependencies(t *introspection.Type, all bool) []string {
+	var names []string
+	for _, f := range t.Fields {
+		if f.IsDeprecated && !all {
+			continue
+		}
+		names = append(names, typeRefName(f.TypeRef))
+		for _, arg := range f.Args {
+			if !arg.IsDeprecated || !arg.IsOptional() || all {
+				names = append(names, typeRefName(arg.TypeRef))
+			}
+		}
+	}
+	for _, f := range t.InputFields {
+		if !f.IsDeprecated || !f.IsOptional() || all {
+			names = append(names, typeRefName(f.TypeRef))
+		}
+	}
+	for _, iface := range t.Interfaces {
+		names = append(names, iface.Name)
+	}
+	return names
+}
@@ -0,0 +1,203 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// OverlayChange is how a path changed from an overlay to another.
+type OverlayChange string
+
+const (
+	OverlayAdded    OverlayChange = "added"
+	OverlayRemoved  OverlayChange = "removed"
+	OverlayModified OverlayChange = "modified"
+)
+
+// OverlayDiff is a path changed from an overlay to another.
+type OverlayDiff struct {
+	Path   string
+	Change OverlayChange
+
+	// Diff is the unified diff of the content of the file, empty for a
+	// directory, a binary file or a file changed from or to a directory.
+	Diff string
+}
+
+// DiffOverlays returns the paths added, removed or modified from overlay a to
+// overlay b, sorted by path, e.g. to preview the changes of a generation
+// before writing it. A directory is only modified if it changed to a file.
+func DiffOverlays(a, b fs.FS) ([]OverlayDiff, error) {
+	entriesA, err := overlayEntries(a)
+	if err != nil {
+		return nil, err
+	}
+	entriesB, err := overlayEntries(b)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entriesA)+len(entriesB))
+	for path := range entriesA {
+		paths = append(paths, path)
+	}
+	for path := range entriesB {
+		if _, ok := entriesA[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diffs []OverlayDiff
+	for _, path := range paths {
+		dirA, inA := entriesA[path]
+		dirB, inB := entriesB[path]
+
+		diff := OverlayDiff{Path: path}
+		var contentA, contentB []byte
+		switch {
+		case !inB:
+			diff.Change = OverlayRemoved
+			if dirA {
+				diffs = append(diffs, diff)
+				continue
+			}
+		case !inA:
+			diff.Change = OverlayAdded
+			if dirB {
+				diffs = append(diffs, diff)
+				continue
+			}
+		case dirA && dirB:
+			continue
+		case dirA != dirB:
+			diff.Change = OverlayModified
+			diffs = append(diffs, diff)
+			continue
+		default:
+			diff.Change = OverlayModified
+		}
+
+		if inA {
+			if contentA, err = fs.ReadFile(a, path); err != nil {
+				return nil, fmt.Errorf("read %s: %w", path, err)
+			}
+		}
+		if inB {
+			if contentB, err = fs.ReadFile(b, path); err != nil {
+				return nil, fmt.Errorf("read %s: %w", path, err)
+			}
+		}
+		if inA && inB && bytes.Equal(contentA, contentB) {
+			continue
+		}
+
+		if isText(contentA) && isText(contentB) {
+			diff.Diff, err = unifiedDiff(path, contentA, contentB, inA, inB)
+			if err != nil {
+				return nil, fmt.Errorf("diff %s: %w", path, err)
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// OverlaysEqual returns whether overlays a and b have the same paths, with the
+// same content for the files, and the paths that differ, sorted, e.g. to test
+// that generating twice produces the same overlay. Unlike DiffOverlays, it
+// doesn't diff the content of the files.
+func OverlaysEqual(a, b fs.FS) (bool, []string, error) {
+	entriesA, err := overlayEntries(a)
+	if err != nil {
+		return false, nil, err
+	}
+	entriesB, err := overlayEntries(b)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var paths []string
+	for path, dirA := range entriesA {
+		dirB, inB := entriesB[path]
+		switch {
+		case !inB || dirA != dirB:
+			paths = append(paths, path)
+		case !dirA:
+			contentA, err := fs.ReadFile(a, path)
+			if err != nil {
+				return false, nil, fmt.Errorf("read %s: %w", path, err)
+			}
+			contentB, err := fs.ReadFile(b, path)
+			if err != nil {
+				return false, nil, fmt.Errorf("read %s: %w", path, err)
+			}
+			if !bytes.Equal(contentA, contentB) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	for path := range entriesB {
+		if _, ok := entriesA[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return len(paths) == 0, paths, nil
+}
+
+// overlayEntries returns the paths of the overlay, except the root, and
+// whether they are directories.
+func overlayEntries(overlay fs.FS) (map[string]bool, error) {
+	entries := map[string]bool{}
+	err := walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		if path != "." {
+			entries[path] = d.IsDir()
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// isText returns true if the content doesn't look binary: it's valid UTF-8
+// without NUL bytes.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) == -1
+}
+
+// unifiedDiff returns the unified diff of the contents of a path, from
+// /dev/null if the path doesn't exist on a side.
+func unifiedDiff(path string, a, b []byte, inA, inB bool) (string, error) {
+	fromFile, toFile := "a/"+path, "b/"+path
+	if !inA {
+		fromFile = "/dev/null"
+	}
+	if !inB {
+		toFile = "/dev/null"
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// splitLines splits the content into lines ending with a newline, as expected
+// by difflib, adding one to the last line if missing.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Dump writes a human-readable summary of the state to w, for debugging: the
+// paths of the overlay with the size of the files, the post commands as shell
+// commands and whether the code needs to be generated again. The contents of
+// the files aren't read.
+func (s *GeneratedState) Dump(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("overlay:\n")
+	if s.Overlay != nil {
+		err := walkOverlay(s.Overlay, func(path string, d fs.DirEntry) error {
+			if path == "." {
+				return nil
+			}
+			if d.IsDir() {
+				fmt.Fprintf(&b, "  %s/\n", path)
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("stat %s: %w", path, err)
+			}
+			fmt.Fprintf(&b, "  %s (%d bytes)\n", path, info.Size())
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	b.WriteString("post commands:\n")
+	for _, cmd := range s.PostCommands {
+		fmt.Fprintf(&b, "  %s\n", shellCommand(cmd))
+	}
+
+	fmt.Fprintf(&b, "need regenerate: %t\n", s.NeedRegenerate)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shellCommand returns the command as it would be typed in a POSIX shell,
+// run from its directory if set.
+func shellCommand(cmd *exec.Cmd) string {
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = shellQuote(arg)
+	}
+	line := strings.Join(args, " ")
+	if cmd.Dir != "" {
+		line = "cd " + shellQuote(cmd.Dir) + " && " + line
+	}
+	return line
+}
+
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes the argument for a POSIX shell, unless it's made of safe
+// characters only.
+func shellQuote(arg string) string {
+	if shellSafeRe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -26,10 +26,33 @@ type FormatTypeFuncs interface {
 	FormatKindScalarBoolean(representation string) string
 	FormatKindScalarDefault(representation string, refName string, input bool) string
 	FormatKindObject(representation string, refName string, input bool) string
+	FormatKindInterface(representation string, refName string, input bool) string
 	FormatKindInputObject(representation string, refName string, input bool) string
 	FormatKindEnum(representation string, refName string) string
 }
 
+// IsStringArg returns true if the argument is a non-null string-like value
+// (a string, an enum or a custom scalar such as an ID), for which an empty
+// value is rejected by the engine.
+func IsStringArg(arg introspection.InputValue) bool {
+	if arg.TypeRef == nil || arg.TypeRef.Kind != introspection.TypeKindNonNull {
+		return false
+	}
+	ref := arg.TypeRef.OfType
+	switch ref.Kind {
+	case introspection.TypeKindEnum:
+		return true
+	case introspection.TypeKindScalar:
+		switch introspection.Scalar(ref.Name) {
+		case introspection.ScalarInt, introspection.ScalarFloat, introspection.ScalarBoolean, introspection.ScalarVoid:
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 // CommonFunctions formatting function with global shared template functions.
 type CommonFunctions struct {
 	schemaVersion   string
@@ -218,6 +241,8 @@ func (c *CommonFunctions) formatType(r *introspection.TypeRef, scope string, inp
 			}
 		case introspection.TypeKindObject:
 			return ff.FormatKindObject(representation, ref.Name, input), nil
+		case introspection.TypeKindInterface:
+			return ff.FormatKindInterface(representation, ref.Name, input), nil
 		case introspection.TypeKindInputObject:
 			return ff.FormatKindInputObject(representation, ref.Name, input), nil
 		case introspection.TypeKindEnum:
@@ -0,0 +1,218 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/psanford/memfs"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// LoadSchema loads the schema to generate code for, from the pre-parsed
+// schema, the schema snapshot or the pre-computed introspection JSON if set,
+// or by introspecting the engine otherwise.
+func LoadSchema(ctx context.Context, cfg Config) (*introspection.Schema, string, error) {
+	var schema *introspection.Schema
+	var schemaVersion string
+	switch {
+	case cfg.Schema != nil:
+		schema = cfg.Schema
+		schemaVersion = cfg.SchemaVersion
+	case cfg.SchemaSnapshotDir != "":
+		var err error
+		schema, schemaVersion, err = ReadSchemaSnapshot(cfg.SchemaSnapshotDir)
+		if err != nil {
+			return nil, "", err
+		}
+	case cfg.IntrospectionJSON != "":
+		var resp introspection.Response
+		if err := json.Unmarshal([]byte(cfg.IntrospectionJSON), &resp); err != nil {
+			return nil, "", fmt.Errorf("unmarshal introspection json: %w", err)
+		}
+		schema = resp.Schema
+		schemaVersion = resp.SchemaVersion
+	default:
+		var err error
+		schema, schemaVersion, err = Introspect(ctx, cfg.Dag, cfg.ModuleSourceID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// types may be declared multiple times when they are extended by
+	// different sources, so merge them into a single definition
+	schema, err := introspection.MergeSchemas(schema)
+	if err != nil {
+		return nil, "", fmt.Errorf("merge introspection schema: %w", err)
+	}
+
+	if cfg.ExcludeDeprecated {
+		for _, name := range ExcludeDeprecated(schema) {
+			cfg.Logf("excluding deprecated %s", name)
+		}
+	}
+
+	if err := HideTypes(schema, cfg.HiddenTypePrefixes); err != nil {
+		return nil, "", fmt.Errorf("hide types: %w", err)
+	}
+
+	if len(cfg.CompatSchemas) > 0 {
+		compatSchemas := make([]*introspection.Schema, len(cfg.CompatSchemas))
+		for i, compatSchema := range cfg.CompatSchemas {
+			compatSchemas[i], err = introspection.MergeSchemas(compatSchema)
+			if err != nil {
+				return nil, "", fmt.Errorf("merge compat schema %d: %w", i, err)
+			}
+		}
+		if err := IntersectSchemas(schema, compatSchemas); err != nil {
+			return nil, "", fmt.Errorf("intersect compat schemas: %w", err)
+		}
+	}
+	return schema, schemaVersion, nil
+}
+
+// Generate runs a single pass of the generator of cfg.Lang over the schema,
+// calling its GeneratorHooks if it implements them.
+func Generate(ctx context.Context, schema *introspection.Schema, schemaVersion string, cfg Config) (*GeneratedState, error) {
+	SetSchemaParents(schema)
+
+	if cfg.StrictSchema {
+		if err := LintSchema(schema); err != nil {
+			return nil, fmt.Errorf("strict schema: %w", err)
+		}
+	}
+
+	gen, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, _ := gen.(GeneratorHooks)
+	if hooks != nil {
+		if err := hooks.Before(ctx, schema); err != nil {
+			return nil, fmt.Errorf("before generation: %w", err)
+		}
+	}
+
+	var generated *GeneratedState
+	if cfg.ClientOnly {
+		generated, err = gen.GenerateClient(ctx, schema, schemaVersion)
+	} else {
+		generated, err = gen.GenerateModule(ctx, schema, schemaVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.ImportRewrites) > 0 {
+		generated.Overlay, err = RewriteImports(generated.Overlay, cfg.ImportRewrites)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite imports: %w", err)
+		}
+	}
+
+	if hooks != nil {
+		if err := hooks.After(ctx, generated); err != nil {
+			return nil, fmt.Errorf("after generation: %w", err)
+		}
+	}
+
+	return generated, nil
+}
+
+// GenerateMulti runs a single pass of the generators of several configs, e.g.
+// of a Go and a TypeScript client of the same schema, merging their overlays
+// into one. The files of each config are under its OutputDir, which must be a
+// local path, relative to the directory the merged overlay is written over.
+// It fails if two configs generate the same file.
+//
+// The configs introspecting the same Dag, and module source, share a single
+// introspection, so that the generated code can't drift apart between them.
+// The post commands of each config run in its OutputDir, or the source
+// directory of its module, and the code needs to be generated again if it
+// does for any config.
+func GenerateMulti(ctx context.Context, cfgs []Config) (*GeneratedState, error) {
+	type introspectionKey struct {
+		dag            *dagger.Client
+		moduleSourceID string
+	}
+	introspections := map[introspectionKey]string{}
+
+	merged := memfs.New()
+	generatedBy := map[string]string{}
+	state := &GeneratedState{Overlay: merged}
+	for i, cfg := range cfgs {
+		name := fmt.Sprintf("config %d (%s)", i, cfg.Lang)
+		if !filepath.IsLocal(cfg.OutputDir) {
+			return nil, fmt.Errorf("%s: output dir %q must be a local path", name, cfg.OutputDir)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if cfg.Schema == nil && cfg.SchemaSnapshotDir == "" && cfg.IntrospectionJSON == "" {
+			key := introspectionKey{dag: cfg.Dag, moduleSourceID: cfg.ModuleSourceID}
+			if _, ok := introspections[key]; !ok {
+				schema, schemaVersion, err := Introspect(ctx, cfg.Dag, cfg.ModuleSourceID)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+				dt, err := IntrospectionJSON(schema, schemaVersion)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+				introspections[key] = string(dt)
+			}
+			// each config loads its own copy of the schema, which its
+			// generator modifies
+			cfg.IntrospectionJSON = introspections[key]
+		}
+
+		schema, schemaVersion, err := LoadSchema(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		generated, err := Generate(ctx, schema, schemaVersion, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		dir := filepath.ToSlash(cfg.OutputDir)
+		err = walkOverlay(generated.Overlay, func(file string, d fs.DirEntry) error {
+			target := path.Join(dir, file)
+			if d.IsDir() {
+				return merged.MkdirAll(target, 0o755)
+			}
+			if other, ok := generatedBy[target]; ok {
+				return fmt.Errorf("%s is generated by both %s and %s", target, other, name)
+			}
+			generatedBy[target] = name
+
+			content, err := fs.ReadFile(generated.Overlay, file)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", file, err)
+			}
+			return merged.WriteFile(target, content, 0o600)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		cmdDir, err := PostCommandsDir(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, cmd := range generated.PostCommands {
+			cmd.Dir = cmdDir
+		}
+		state.PostCommands = append(state.PostCommands, generated.PostCommands...)
+		state.NeedRegenerate = state.NeedRegenerate || generated.NeedRegenerate
+	}
+	return state, nil
+}
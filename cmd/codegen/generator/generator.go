@@ -2,6 +2,9 @@ package generator
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +12,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"dagger.io/dagger"
 	"github.com/dagger/dagger/cmd/codegen/introspection"
@@ -21,6 +26,7 @@ type SDKLang string
 const (
 	SDKLangGo         SDKLang = "go"
 	SDKLangTypeScript SDKLang = "typescript"
+	SDKLangRuby       SDKLang = "ruby"
 )
 
 type ModuleSourceDependencies struct {
@@ -49,15 +55,77 @@ type Config struct {
 	// IntrospectionJSON is an optional pre-computed introspection json string.
 	IntrospectionJSON string
 
+	// Schema is an optional pre-parsed schema, for callers that already have
+	// one. It bypasses both the introspection and IntrospectionJSON.
+	Schema *introspection.Schema
+
+	// SchemaVersion is the version of Schema.
+	SchemaVersion string
+
+	// SchemaSnapshotDir is an optional directory written by
+	// WriteSchemaSnapshot, to generate code from the schema snapshot in it
+	// instead of introspecting the engine.
+	SchemaSnapshotDir string
+
+	// HiddenTypePrefixes hides the types whose name starts with one of the
+	// prefixes (e.g. `_` or `Internal`) from the generated code, along with
+	// the fields returning them, see HideTypes.
+	HiddenTypePrefixes []string
+
+	// ExcludeDeprecated removes the deprecated fields, arguments, input fields
+	// and enum values of the schema before generating code for it, to stop
+	// exposing them in new clients. What is excluded is logged, see
+	// ExcludeDeprecated. The engine lists the deprecated arguments and input
+	// fields as is, while the other servers are asked to, see IntrospectHTTP.
+	ExcludeDeprecated bool
+
+	// CompatSchemas are the schemas, e.g. of other versions of the engine,
+	// the generated code must also work with: only what is in the schema and
+	// in all of them is generated, see IntersectSchemas.
+	CompatSchemas []*introspection.Schema
+
+	// StrictSchema indicates whether to check the hygiene of the schema before
+	// generating code for it, failing with all the violations of the rules of
+	// LintSchema, e.g. a public field without description.
+	StrictSchema bool
+
+	// EmbedSchema indicates whether to embed the introspection of the schema,
+	// as minified JSON, and its version in a generated file, so that the
+	// client can describe the schema it was generated from, see
+	// IntrospectionJSON. This adds the size of the JSON to the generated code,
+	// about a megabyte for the core Dagger API.
+	EmbedSchema bool
+
+	// GenerateResponseValidation indicates whether the standalone client
+	// checks the data of each response against the schema embedded with
+	// EmbedSchema, failing the request if a field it selects is missing, of
+	// another type, or if the data has fields it doesn't select, e.g. to debug
+	// a mismatch between the versions of the engine and the client. It costs a
+	// decoding of the data and a parsing of the query per request. This is
+	// only supported in Go for now.
+	GenerateResponseValidation bool
+
 	// Merge indicates whether to merge the module deps with the existing project (i.e. a go.mod in a *parent* directory).
 	Merge bool
 
+	// GoModSearchDepth is the number of parent directories of the module
+	// source searched for a go.mod to merge with, from the closest one up to
+	// the output directory. All of them are searched if zero. This is only
+	// supported in Go for now.
+	GoModSearchDepth int
+
 	// Whether we are initializing a new module.
 	// Currently, this is only used in go codegen to enforce backwards-compatible behavior
 	// where a pre-existing go.mod file is checked during dagger init for whether its module
 	// name is the expected value.
 	IsInit bool
 
+	// ScaffoldTests indicates whether to add a starter test file to a module
+	// being initialized (see IsInit), exercising a function of the starter
+	// module code. An existing test file isn't overwritten. This is only
+	// supported in Go and TypeScript for now.
+	ScaffoldTests bool
+
 	// ClientOnly indicates that the codegen should only generate the client code.
 	ClientOnly bool
 
@@ -66,13 +134,649 @@ type Config struct {
 	// dependencies when connecting to the client.
 	ModuleDependencies []ModuleSourceDependencies
 
+	// ResolveTransitiveDependencies indicates whether the ModuleDependencies
+	// loaded from ModuleSourceID also include the dependencies of the
+	// dependencies, queried from the engine, so that the generated client
+	// serves them too, see LoadModuleDependencies.
+	ResolveTransitiveDependencies bool
+
+	// DependencyConcurrency is the number of dependencies queried at the same
+	// time with ResolveTransitiveDependencies, or DefaultResolveConcurrency if
+	// it isn't positive.
+	DependencyConcurrency int
+
+	// NamespaceByModule indicates whether to group the functions of the
+	// query coming from each module of ModuleDependencies, known from their
+	// source map, under a method of the client named after the module, e.g.
+	// `client.ModuleA().Build()` for the moduleABuild function of module-a,
+	// so that the same-named functions of several modules don't collide. The
+	// methods on the client itself are kept. This is only supported in Go for
+	// now.
+	NamespaceByModule bool
+
+	// SkipServeDependencies indicates whether the generated client connects
+	// to the engine without serving the module dependencies, which is useful
+	// when the dependencies are already provided externally.
+	// By default, the generated client serves them when connecting.
+	SkipServeDependencies bool
+
+	// ReuseConnection indicates whether to generate a constructor of the
+	// standalone client reusing an existing connection to the engine, rather
+	// than connecting again. This is only supported in Go for now.
+	ReuseConnection bool
+
+	// LazyConnect indicates whether Connect of the standalone client defers
+	// connecting to the engine to the first request, e.g. for clients created
+	// at startup before the engine is ready. The first request connects with
+	// the values of its context but not its cancellation, since the session
+	// lasts until the client is closed, and serves the module dependencies.
+	// The error of the connection is returned by the first request, and by the
+	// next ones. This is only supported in Go for now.
+	LazyConnect bool
+
+	// TypedIDs indicates whether the IDs of the objects declared with the
+	// built-in ID scalar, and the ID arguments named after them, should be
+	// generated with a scalar per object rather than a string, so that the ID
+	// of an object can't be passed for another one, see TypeIDs. This is only
+	// supported in Go for now.
+	TypedIDs bool
+
+	// GenerateIDLoaders indicates whether to generate a TFromID function
+	// loading an object T of the standalone client from its ID, e.g.
+	// `ContainerFromID(client, id)`, for each object with a loader, see
+	// IDLoader. This is only supported in Go for now.
+	GenerateIDLoaders bool
+
+	// GeneratePluggableTransport indicates whether to generate a Transport
+	// interface the standalone client sends its requests through, and a
+	// constructor taking one instead of connecting to the engine, e.g. to
+	// inject a fake one in tests, which doesn't serve the module
+	// dependencies. This is only supported in Go for now.
+	GeneratePluggableTransport bool
+
+	// GenerateDryRunTransport indicates whether to generate a dry run of the
+	// standalone client, enabled at runtime, recording the queries of its
+	// requests instead of sending them, e.g. to test the queries a pipeline
+	// builds without running it. This is only supported in Go for now.
+	GenerateDryRunTransport bool
+
+	// GenerateRequestHooks indicates whether to generate a hook on the
+	// standalone client called with each GraphQL request before it is sent,
+	// e.g. to log the requests.
+	GenerateRequestHooks bool
+
+	// GenerateMetrics indicates whether the standalone client counts the
+	// requests of the methods fetching a field and their errors, with the
+	// Metrics set on the client. This is only supported in Go for now.
+	GenerateMetrics bool
+
+	// GenerateOperationNames indicates whether the standalone client names
+	// the GraphQL operation of the request of each method fetching a field
+	// after it, see OperationName, rather than sending an anonymous query,
+	// e.g. to correlate the requests in the logs of the engine.
+	GenerateOperationNames bool
+
+	// GenerateContextLabels indicates whether the standalone client attaches
+	// the labels of the context of each request, set with WithLabels, to it as
+	// OpenTelemetry baggage, which the engine receives with the trace context,
+	// so that a whole call tree can be tagged. This is only supported in Go for
+	// now.
+	GenerateContextLabels bool
+
+	// GenerateErrorCodes indicates whether the standalone client maps the
+	// known codes of the errors of the engine, in the `_type` extension of the
+	// GraphQL errors, to errors named after them that errors.Is matches, e.g.
+	// ErrNotFound for NOT_FOUND. The known codes are ErrorCodes and the values
+	// of ErrorCodeEnum. This is only supported in Go for now.
+	GenerateErrorCodes bool
+
+	// ErrorCodes are known codes of the errors of the engine, for
+	// GenerateErrorCodes.
+	ErrorCodes []string
+
+	// ErrorCodeEnum is the name of an enum of the schema whose values are
+	// known codes of the errors of the engine, for GenerateErrorCodes.
+	ErrorCodeEnum string
+
+	// GenerateClientRetry indicates whether the standalone client retries the
+	// requests failing with a transient error, such as a network error or a
+	// 5xx HTTP status, waiting for a backoff doubling after each attempt.
+	// Only the queries are retried by default, not the mutations, which the
+	// RetryPolicy of the generated client can change. This is only supported
+	// in Go for now.
+	GenerateClientRetry bool
+
+	// ClientRetryMaxAttempts is the default maximum number of attempts of a
+	// request with GenerateClientRetry, including the first one. It defaults
+	// to 3.
+	ClientRetryMaxAttempts int
+
+	// ClientRetryBackoff is the default wait before the first retry of a
+	// request with GenerateClientRetry. It defaults to 100ms.
+	ClientRetryBackoff time.Duration
+
+	// ClientRetryExcludedFields are the names of the fields with side effects
+	// whose requests aren't retried by default with GenerateClientRetry. The
+	// engine serves all its fields as queries, so a query isn't necessarily
+	// safe to send again: the queries selecting one of these fields, whatever
+	// their type, are handled like the mutations. It defaults to the fields of
+	// the engine publishing, exporting, syncing, running or stopping
+	// something: `export`, `prune`, `publish`, `returnError`, `returnValue`,
+	// `serve`, `start`, `stop`, `sync`, `terminal` and `up`.
+	ClientRetryExcludedFields []string
+
+	// DefaultOperationTimeout is the default timeout of each request of the
+	// standalone client whose context has no deadline, which the
+	// OperationTimeout of the generated client can change. There is no
+	// timeout if it's 0. This is only supported in Go for now.
+	DefaultOperationTimeout time.Duration
+
+	// MaxQueryDepth is the maximum depth of the selections of the queries of
+	// the standalone client, e.g. 3 for `container { from { id } }`, guarding
+	// against runaway nested selections: a request selecting deeper fields
+	// fails with a QueryDepthError before it's sent. There is no limit if
+	// it's 0. This is only supported in Go for now.
+	MaxQueryDepth int
+
+	// IncludeQueryInErrors indicates whether the errors of the requests of the
+	// standalone client include their GraphQL query and variables, truncated
+	// to its MaxErrorQueryLength, in a QueryError. It's disabled by default
+	// since the arguments in the queries may be secrets, which logging the
+	// errors would leak. This is only supported in Go for now.
+	IncludeQueryInErrors bool
+
+	// GenerateConnectionPool indicates whether to generate constructors of
+	// the standalone client taking the PoolOpts of its connection, limiting
+	// the number of requests it sends concurrently, e.g.
+	// `ConnectWithPool(ctx, PoolOpts{Size: 8})`. The HTTP transport of the
+	// connection is owned by the dagger package, so its keep-alive isn't
+	// configurable. This is only supported in Go for now.
+	GenerateConnectionPool bool
+
+	// ConnectionPoolSize is the default maximum number of concurrent requests
+	// of the client with GenerateConnectionPool. It defaults to 0, no limit,
+	// as without GenerateConnectionPool.
+	ConnectionPoolSize int
+
+	// GenerateSession indicates whether to generate a Session wrapping the
+	// client, with the same methods, to apply common options such as a
+	// timeout to the context of its calls. This is only supported in Go for
+	// now.
+	GenerateSession bool
+
+	// GeneratePing indicates whether to generate a Ping method on the client,
+	// making a minimal request to check that the engine is reachable before
+	// the actual requests.
+	GeneratePing bool
+
+	// GenerateRawQuery indicates whether to generate a Raw method on the
+	// client, sending a GraphQL query as is, as an escape hatch for the
+	// features of the schema the generated code doesn't model. The response
+	// isn't validated against the types of the schema.
+	GenerateRawQuery bool
+
+	// GenerateIntrospectMethod indicates whether to generate an Introspect
+	// method on the client, sending the introspection query to return the
+	// live schema of the engine, with its types. This is only supported in Go
+	// for now.
+	GenerateIntrospectMethod bool
+
+	// GenerateBatching indicates whether to generate a Batch of calls of the
+	// client, sending the requests the calls make at the same time as a
+	// single request. This is only supported in Go for now.
+	GenerateBatching bool
+
+	// GenerateTracing indicates whether the methods of the generated client
+	// making a request start an OpenTelemetry span, named after the type and
+	// the field (e.g. `Container.stdout`), in the context they are given.
+	// This is only supported in Go for now.
+	GenerateTracing bool
+
+	// GoTarget is a platform other than the native ones, which the generated
+	// Go client must compile for: GoTargetWasm (GOARCH=wasm, with GOOS=wasip1
+	// or js) or GoTargetTinyGo. The generated files are then restricted to the
+	// target by a build constraint, and only use packages available there:
+	//
+	//   - the client can't connect to the engine itself, since provisioning
+	//     it runs commands, so it's created with NewClient from a GraphQL
+	//     client reaching the session of an engine, and there is no dag
+	//     package;
+	//   - it doesn't depend on OpenTelemetry, so GenerateTracing isn't
+	//     supported.
+	//
+	// It requires ClientOnly.
+	GoTarget string
+
+	// GoPlatformSplit indicates whether to generate the platform-specific
+	// helpers of the Go client in their own files, restricted to the platform
+	// by a build constraint only, their `.gen.go` names not being GOOS
+	// suffixes: `platform_linux.gen.go`, `platform_windows.gen.go` and
+	// `platform_other.gen.go` for the rest. The
+	// client then provides NotifyInterrupt, canceling a context on the
+	// interrupt signals of the platform.
+	//
+	// It requires ClientOnly, and isn't supported with a GoTarget.
+	GoPlatformSplit bool
+
+	// GoOptionalStyle is how the generated Go code renders the values of the
+	// nullable scalar and enum output fields, in the return types of the
+	// methods and in the fields of the generated structs:
+	//
+	//   - GoOptionalValue, the default when empty: the value itself, the zero
+	//     value of its type for null.
+	//   - GoOptionalPointer: a pointer to the value, nil for null.
+	//   - GoOptionalOptional: an Optional of the value, generated with the
+	//     client, whose Valid is false for null.
+	GoOptionalStyle string
+
+	// GoJSONPackage is the import path of the JSON package used by the
+	// generated Go code to marshal and unmarshal values, e.g. the IDs of the
+	// objects, instead of encoding/json. It's imported with the name json, so
+	// it must provide functions compatible with those of encoding/json:
+	//
+	//	func Marshal(v any) ([]byte, error)
+	//	func Unmarshal(data []byte, v any) error
+	//
+	// Defaults to encoding/json when empty.
+	GoJSONPackage string
+
+	// SkipGoFormat indicates whether to write the generated Go code as the
+	// templates render it, without formatting it and organizing its imports,
+	// e.g. to debug the templates. The code may then not compile, since the
+	// imports it doesn't use are removed when organizing them.
+	SkipGoFormat bool
+
+	// ScalarSerializers maps the names of custom scalars to their Go type and
+	// the code converting their values to and from their string on the wire,
+	// e.g. to get a time.Time for a DateTime scalar, instead of the string of
+	// the other scalars. This is only supported in Go for now.
+	ScalarSerializers map[string]ScalarSerializer
+
 	// Generate the client in bundle mode.
 	Bundle bool
 
+	// GenerateMocks indicates whether to generate mocks of the client, with
+	// one mock per object whose methods are implemented by settable function
+	// fields. Mocks are generated in a separate file (and package in Go).
+	// In Go, the mocks and the wrappers of the client implement an interface
+	// per object, whose methods return the objects as interfaces, so that
+	// chained calls can be mocked.
+	GenerateMocks bool
+
+	// SplitByType indicates whether to generate each object type in its own
+	// file, e.g. `container.gen.go`, next to the main generated file rather
+	// than in it. This is only supported in Go for now.
+	SplitByType bool
+
+	// ChangedTypes restricts the generation of the files of the types, with
+	// SplitByType, to the files of these types and of the types depending on
+	// them, see DependentTypes, e.g. from a diff of the schema. The other
+	// files are left as previously generated. All the files are generated
+	// when empty.
+	ChangedTypes []string
+
+	// GeneratedFileSuffix replaces the DefaultGeneratedFileSuffix of the
+	// names of the generated files, before their extension, e.g.
+	// `.generated` to generate `dagger.generated.go` rather than
+	// `dagger.gen.go`. The files of a TypeScript module keep their default
+	// names, which its SDK library imports. This is only supported in Go and
+	// TypeScript for now.
+	GeneratedFileSuffix string
+
+	// TypesOnly indicates whether to generate only the data types of the
+	// client (objects, inputs, enums and scalars) without the query builder.
+	// This is only supported when generating a client.
+	TypesOnly bool
+
+	// Standalone indicates whether to generate self-contained data types, as
+	// with TypesOnly, without importing nor adding the dagger SDK, so that
+	// the generated code only depends on the standard library. This is only
+	// supported in Go, when generating a client.
+	Standalone bool
+
+	// TypeScriptDeclarationOnly indicates whether to generate only the
+	// declarations of the TypeScript client, in a .d.ts file without
+	// implementation. This is only supported when generating a client.
+	TypeScriptDeclarationOnly bool
+
+	// TypeScriptCodeSplit indicates whether to write the class of each object
+	// of the TypeScript client in a chunk of its own, which the client imports
+	// lazily, so that an application only loads the objects it uses. This is
+	// only supported when generating a client.
+	TypeScriptCodeSplit bool
+
+	// GenerateInputConstructors indicates whether to generate a constructor
+	// for each input type, taking its required fields as arguments.
+	// This is only supported in Go for now.
+	GenerateInputConstructors bool
+
+	// GenerateValidationTags indicates whether to tag the fields of the input
+	// types for a validator library such as go-playground/validator, to
+	// validate the inputs before sending them: the required fields are tagged
+	// `required`, except the Boolean, Int and Float ones whose zero value is
+	// valid, and the enum fields `oneof` their values. This is only supported
+	// in Go for now.
+	GenerateValidationTags bool
+
+	// ValidationTagKey is the key of the tags of GenerateValidationTags.
+	// Defaults to DefaultValidationTagKey when empty.
+	ValidationTagKey string
+
+	// SeparatePreview indicates whether to move the preview fields of each
+	// object, which are unstable, to a separate type returned by its Preview
+	// method, e.g. `client.Container().Preview().WithGPU()`, so that relying
+	// on them is explicit. This is only supported in Go for now.
+	SeparatePreview bool
+
+	// PreviewDirective is the directive flagging the preview fields of
+	// SeparatePreview. Defaults to DefaultPreviewDirective when empty.
+	PreviewDirective string
+
+	// PreviewFieldPrefix is the prefix of the names of the preview fields of
+	// SeparatePreview, besides the ones flagged with PreviewDirective, e.g.
+	// `experimental`. No field is flagged by its name when empty.
+	PreviewFieldPrefix string
+
+	// GenerateSelectors indicates whether to generate, for each object, a
+	// SelectFields helper fetching only the given scalar fields in a single
+	// request. This is only supported in Go for now.
+	GenerateSelectors bool
+
+	// GeneratePathAccessors indicates whether to generate, for each object, a
+	// typed path to its nested fields without required arguments, e.g.
+	// `r.SelectPath().Parent().Name().Get(ctx)`, fetched in a single request.
+	// This is only supported in Go for now.
+	GeneratePathAccessors bool
+
+	// GenerateVariableStructs indicates whether to generate, for each field
+	// with arguments, a struct of its arguments and a method calling the field
+	// with it, e.g. `r.WithExecVars(vars)` with a ContainerWithExecVars built
+	// once and reused across calls. This is only supported in Go for now.
+	GenerateVariableStructs bool
+
+	// GenerateContextlessShims indicates whether to generate, for each method
+	// taking a context, a deprecated method calling it with
+	// context.Background(), e.g. StdoutNoContext for Stdout, to ease the
+	// migration of the call sites without a context. This is only supported
+	// in Go for now.
+	GenerateContextlessShims bool
+
+	// GenerateArgValidation indicates whether to generate client-side
+	// validation of the required arguments, failing before the request for
+	// empty values the engine would reject. In Go, the calls which can't
+	// return an error, the fields returning an object, fail at the first
+	// request chained from them.
+	GenerateArgValidation bool
+
+	// GuardRequiredArgs indicates whether the generated Go code checks that
+	// the required arguments whose zero value would be sent as is, the
+	// strings, enums, custom scalars and lists, aren't given their zero value,
+	// e.g. an uninitialized variable, failing the call with a RequiredArgError
+	// before the request, or the first request chained from it if the call
+	// can't return an error. The numbers and booleans aren't checked, their zero value being
+	// a valid one.
+	GuardRequiredArgs bool
+
+	// GeneratePaginationHelpers indicates whether to generate helpers paging
+	// through the fields returning a connection, as detected by
+	// PaginationConvention. This is only supported in Go for now.
+	GeneratePaginationHelpers bool
+
+	// PaginationConvention overrides the names of the fields of connection
+	// types, unset names default to DefaultPaginationConvention.
+	PaginationConvention PaginationConvention
+
+	// TypedCursors indicates whether the cursors of each connection detected
+	// by GeneratePaginationHelpers should be generated as a distinct opaque
+	// type rather than a string, so that the cursor of a connection can't be
+	// passed to another one. This is only supported in Go for now.
+	TypedCursors bool
+
+	// GenerateLogStreams indicates whether to generate a typed consumer of
+	// each log stream of the schema, see LogStreamEntry: a method sending the
+	// entries of the stream to a channel, decoded in a struct. This is only
+	// supported in Go for now.
+	GenerateLogStreams bool
+
+	// GenerateFieldCache indicates whether the standalone client should fetch
+	// the cacheable fields once per query, see IsCacheableField: the value of
+	// a field with the same parents and arguments is kept for the lifetime of
+	// the client, until its ClearFieldCache method is called. Errors aren't
+	// cached. This is only supported in Go for now.
+	GenerateFieldCache bool
+
+	// CacheableFields are patterns, as matched by path.Match, of the
+	// `Type.field` names of the fields to cache with GenerateFieldCache in
+	// addition to the ones marked with CacheableDirective, e.g. `*.id`.
+	CacheableFields []string
+
+	// NameTransform overrides the formatting of the schema names (of types,
+	// fields and arguments) into the identifiers of the generated standalone
+	// Go client, e.g. to follow another acronym casing. The names sent in the
+	// queries are unchanged. Generation fails if two names of the same scope
+	// transform to the same identifier, see CheckNameCollisions.
+	NameTransform func(schemaName string) string `json:"-"`
+
+	// DocCommentWrap is the column at which generated doc comments are
+	// word-wrapped, including their indentation, with a tab counting as 8
+	// columns in Go like gofmt. 0 disables wrapping.
+	DocCommentWrap int
+
+	// AnnotateNullability appends to the doc comment of each field of the
+	// objects whether its value is "(non-null)" or "(nullable)", see
+	// NullabilityAnnotation.
+	AnnotateNullability bool
+
+	// UserRegionMarkers delimit user-editable regions of the generated files,
+	// whose content is preserved when regenerating over existing files.
+	UserRegionMarkers UserRegionMarkers
+
+	// Backup indicates whether to back up the existing files overwritten by
+	// the generation, with BackupSuffix appended to their path.
+	Backup bool
+
+	// BackupSuffix is the suffix of the backups, DefaultBackupSuffix if empty.
+	BackupSuffix string
+
+	// ManifestPath is the path, relative to OutputDir, of a manifest listing
+	// the hash of each generated file, empty disables the manifest.
+	ManifestPath string
+
+	// ManifestProvenance indicates whether to record in the manifest how the
+	// files were generated, see Provenance.
+	ManifestProvenance bool
+
+	// ImportRewrites maps import paths to the path they should be replaced
+	// with in the generated Go and TypeScript files (e.g. to use a vendored
+	// mirror of `dagger.io/dagger`).
+	ImportRewrites map[string]string
+
+	// ModuleSourceID is the ID of a module source whose module is served
+	// before the introspection, so that the schema includes the types of the
+	// module and of its dependencies, see Introspect.
+	ModuleSourceID string
+
 	// A dagger client connected to the engine running the codegen.
 	// This may be nil if the codegen is run outside of a dagger context and should
 	// only be set if introspectionJSON or moduleSourceID are set.
-	Dag *dagger.Client
+	Dag *dagger.Client `json:"-"`
+
+	// Logs is where the generation logs its progress, e.g. the deprecated
+	// members it excludes or the go module it merges a module with. Nothing
+	// is logged if it's nil.
+	Logs io.Writer `json:"-"`
+}
+
+// Logf logs a line of the progress of the generation to Logs, if set.
+func (cfg Config) Logf(format string, args ...any) {
+	if cfg.Logs != nil {
+		fmt.Fprintf(cfg.Logs, format+"\n", args...)
+	}
+}
+
+// Validate checks that the config is consistent.
+func (cfg Config) Validate() error {
+	if cfg.Schema != nil && cfg.IntrospectionJSON != "" {
+		return errors.New("only one of schema and introspection json can be set")
+	}
+	if cfg.SchemaSnapshotDir != "" && (cfg.Schema != nil || cfg.IntrospectionJSON != "") {
+		return errors.New("a schema snapshot dir can't be set with a schema or introspection json")
+	}
+	if len(cfg.ChangedTypes) > 0 && !cfg.SplitByType {
+		return errors.New("changed types require splitting by type")
+	}
+	if cfg.TypeScriptCodeSplit {
+		if !cfg.ClientOnly {
+			return errors.New("typescript code split requires generating a client")
+		}
+		if cfg.TypesOnly || cfg.TypeScriptDeclarationOnly {
+			return errors.New("typescript code split requires generating the implementation of the client")
+		}
+	}
+	if cfg.GenerateResponseValidation {
+		if !cfg.ClientOnly {
+			return errors.New("response validation requires generating a client")
+		}
+		if !cfg.EmbedSchema {
+			return errors.New("response validation requires embedding the schema")
+		}
+	}
+	if strings.ContainsAny(cfg.GeneratedFileSuffix, `/\`) {
+		return fmt.Errorf("generated file suffix %q must not contain a path separator", cfg.GeneratedFileSuffix)
+	}
+	switch cfg.GoTarget {
+	case "":
+	case GoTargetWasm, GoTargetTinyGo:
+		if !cfg.ClientOnly {
+			return fmt.Errorf("go target %q requires generating a client", cfg.GoTarget)
+		}
+		if cfg.GenerateTracing {
+			return fmt.Errorf("go target %q doesn't support tracing", cfg.GoTarget)
+		}
+		if cfg.LazyConnect {
+			// there's no Connect, only NewClient
+			return fmt.Errorf("go target %q doesn't support lazy connection", cfg.GoTarget)
+		}
+	default:
+		return fmt.Errorf("unknown go target %q", cfg.GoTarget)
+	}
+	if cfg.GoPlatformSplit {
+		if !cfg.ClientOnly {
+			return errors.New("go platform split requires generating a client")
+		}
+		if cfg.GoTarget != "" {
+			return fmt.Errorf("go platform split isn't supported with go target %q", cfg.GoTarget)
+		}
+	}
+	switch cfg.GoOptionalStyle {
+	case "", GoOptionalValue, GoOptionalPointer, GoOptionalOptional:
+	default:
+		return fmt.Errorf("unknown go optional style %q", cfg.GoOptionalStyle)
+	}
+	if err := validateCacheableFields(cfg.CacheableFields); err != nil {
+		return err
+	}
+	if err := validateScalarSerializers(cfg.ScalarSerializers); err != nil {
+		return err
+	}
+	if cfg.GenerateErrorCodes && len(cfg.ErrorCodes) == 0 && cfg.ErrorCodeEnum == "" {
+		return errors.New("error codes require a list of codes or an enum of the schema")
+	}
+	if cfg.TypedCursors && !cfg.GeneratePaginationHelpers {
+		return errors.New("typed cursors require pagination helpers")
+	}
+	if cfg.ConnectionPoolSize < 0 {
+		return errors.New("connection pool size must not be negative")
+	}
+	if cfg.GoModSearchDepth < 0 {
+		return errors.New("go.mod search depth must not be negative")
+	}
+	if cfg.ClientRetryMaxAttempts < 0 {
+		return errors.New("client retry max attempts must not be negative")
+	}
+	if cfg.DefaultOperationTimeout < 0 {
+		return errors.New("default operation timeout must not be negative")
+	}
+	if cfg.MaxQueryDepth < 0 {
+		return errors.New("max query depth must not be negative")
+	}
+	if cfg.ClientRetryBackoff < 0 {
+		return errors.New("client retry backoff must not be negative")
+	}
+	for _, name := range cfg.ClientRetryExcludedFields {
+		if !graphQLNameRe.MatchString(name) {
+			return fmt.Errorf("invalid client retry excluded field %q", name)
+		}
+	}
+	if strings.ContainsAny(cfg.ValidationTagKey, " :\"`") || cfg.ValidationTagKey == "json" {
+		return fmt.Errorf("invalid validation tag key %q", cfg.ValidationTagKey)
+	}
+	return nil
+}
+
+// The styles of Config.GoOptionalStyle.
+const (
+	GoOptionalValue    = "Value"
+	GoOptionalPointer  = "Pointer"
+	GoOptionalOptional = "Optional[T]"
+)
+
+// The Go targets of Config.GoTarget.
+const (
+	GoTargetWasm   = "wasm"
+	GoTargetTinyGo = "tinygo"
+)
+
+// DefaultValidationTagKey is the key of the tags of
+// Config.GenerateValidationTags, used by go-playground/validator.
+const DefaultValidationTagKey = "validate"
+
+// DefaultGeneratedFileSuffix is the suffix of the names of the generated
+// files before their extension, e.g. `dagger.gen.go`.
+const DefaultGeneratedFileSuffix = ".gen"
+
+// GeneratedFileName returns the path of a generated file, given with the
+// DefaultGeneratedFileSuffix, with the GeneratedFileSuffix of the config, e.g.
+// `dag/dag.generated.go` for `dag/dag.gen.go` with `.generated`.
+func (cfg Config) GeneratedFileName(path string) string {
+	if cfg.GeneratedFileSuffix == "" {
+		return path
+	}
+	dir, file := filepath.Split(path)
+	return dir + strings.Replace(file, DefaultGeneratedFileSuffix+".", cfg.GeneratedFileSuffix+".", 1)
+}
+
+// Fingerprint returns a hash of the config fields affecting the generated
+// code, that is stable across runs. The Dag client and the Logs are excluded,
+// and the NameTransform function only contributes whether it is set. The
+// IntrospectionJSON is normalized, see NormalizeIntrospection, and the files
+// of the SchemaSnapshotDir contribute their contents.
+func (cfg Config) Fingerprint() string {
+	if cfg.IntrospectionJSON != "" {
+		// an invalid document fails the generation, hash it as is
+		if dt, err := NormalizeIntrospection([]byte(cfg.IntrospectionJSON)); err == nil {
+			cfg.IntrospectionJSON = string(dt)
+		}
+	}
+	dt, err := json.Marshal(cfg)
+	if err != nil {
+		// all the fields are marshallable
+		panic(fmt.Errorf("marshal config: %w", err))
+	}
+	h := sha256.New()
+	h.Write(dt)
+	if cfg.NameTransform != nil {
+		h.Write([]byte("nameTransform"))
+	}
+	if cfg.SchemaSnapshotDir != "" {
+		for _, name := range []string{SchemaSnapshotFile, SchemaSnapshotVersionFile} {
+			// a missing file fails the generation, hash the others
+			if dt, err := os.ReadFile(filepath.Join(cfg.SchemaSnapshotDir, name)); err == nil {
+				h.Write(dt)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 type Generator interface {
@@ -85,6 +789,17 @@ type Generator interface {
 	GenerateClient(ctx context.Context, schema *introspection.Schema, schemaVersion string) (*GeneratedState, error)
 }
 
+// GeneratorHooks can optionally be implemented by a Generator to run code
+// around each generation, e.g. to validate or transform the schema, or to
+// observe the generated state.
+type GeneratorHooks interface {
+	// Before is called with the schema before generating code from it.
+	Before(ctx context.Context, schema *introspection.Schema) error
+
+	// After is called with the state of a successful generation.
+	After(ctx context.Context, state *GeneratedState) error
+}
+
 type GeneratedState struct {
 	// Overlay is the overlay filesystem that contains generated code to write
 	// over the output directory.
@@ -111,8 +826,19 @@ func SetSchemaParents(schema *introspection.Schema) {
 	}
 }
 
-// Introspect gets the Dagger Schema
-func Introspect(ctx context.Context, dag *dagger.Client) (*introspection.Schema, string, error) {
+// Introspect gets the Dagger Schema.
+// If moduleSourceID is set, the module of the source is served with its
+// dependencies first, so that the schema is the one extended by the module.
+func Introspect(ctx context.Context, dag *dagger.Client, moduleSourceID string) (*introspection.Schema, string, error) {
+	if moduleSourceID != "" {
+		err := dag.LoadModuleSourceFromID(dagger.ModuleSourceID(moduleSourceID)).
+			AsModule().
+			Serve(ctx, dagger.ModuleServeOpts{IncludeDependencies: true})
+		if err != nil {
+			return nil, "", fmt.Errorf("serve module: %w", err)
+		}
+	}
+
 	var introspectionResp introspection.Response
 	err := dag.Do(ctx, &dagger.Request{
 		Query:  introspection.Query,
@@ -127,12 +853,102 @@ func Introspect(ctx context.Context, dag *dagger.Client) (*introspection.Schema,
 	return introspectionResp.Schema, introspectionResp.SchemaVersion, nil
 }
 
-func Overlay(ctx context.Context, logsW io.Writer, overlay fs.FS, outputDir string) (rerr error) {
+// walkOverlay calls fn for each entry of the overlay, in lexical order.
+func walkOverlay(overlay fs.FS, fn func(path string, d fs.DirEntry) error) error {
 	return fs.WalkDir(overlay, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		return fn(path, d)
+	})
+}
+
+// ValidateOverlay returns an error if two entries of the overlay have the
+// same path, or paths only differing by case, which would be written to the
+// same file on case-insensitive filesystems (e.g. on macOS or Windows).
+func ValidateOverlay(overlay fs.FS) error {
+	seen := map[string]string{}
+	return walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		key := strings.ToLower(path)
+		if other, ok := seen[key]; ok {
+			if other == path {
+				return fmt.Errorf("overlay has duplicate path %q", path)
+			}
+			return fmt.Errorf("overlay paths %q and %q collide on case-insensitive filesystems", other, path)
+		}
+		seen[key] = path
+		return nil
+	})
+}
+
+// checkOutputPath returns an error if the overlay path escapes outputDir once
+// joined to it, e.g. `../escape`.
+func checkOutputPath(outputDir, path string) error {
+	rel, err := filepath.Rel(outputDir, filepath.Join(outputDir, path))
+	if err != nil {
+		return fmt.Errorf("overlay path %q: %w", path, err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("overlay path %q escapes output directory %q", path, outputDir)
+	}
+	return nil
+}
+
+// DefaultBackupSuffix is the suffix of the backups of overwritten files when
+// OverlayOptions.BackupSuffix is empty.
+const DefaultBackupSuffix = ".bak"
+
+// OverlayOptions configures how Overlay writes the files.
+type OverlayOptions struct {
+	// UserRegionMarkers delimit the user regions to preserve in the existing
+	// files, see SpliceUserRegions.
+	UserRegionMarkers UserRegionMarkers
+
+	// Backup indicates whether to copy an existing file to the path with
+	// BackupSuffix appended before overwriting it. Unchanged files aren't
+	// backed up.
+	Backup bool
+
+	// BackupSuffix is the suffix of the backups, DefaultBackupSuffix if empty.
+	BackupSuffix string
+}
+
+// Overlay writes the files of the overlay to the output directory, skipping
+// files that are unchanged. User regions delimited by markers in the existing
+// files are preserved in the new content, see SpliceUserRegions. The paths
+// matching the patterns of the DaggerIgnoreFile of the output directory
+// aren't written.
+func Overlay(ctx context.Context, logsW io.Writer, overlay fs.FS, outputDir string, opts OverlayOptions) (rerr error) {
+	backupSuffix := opts.BackupSuffix
+	if backupSuffix == "" {
+		backupSuffix = DefaultBackupSuffix
+	}
+
+	ignore, err := loadIgnore(outputDir)
+	if err != nil {
+		return err
+	}
+
+	return walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		if err := checkOutputPath(outputDir, path); err != nil {
+			return err
+		}
+
+		ignored, err := isIgnored(ignore, path)
+		if err != nil {
+			return err
+		}
+
 		if d.IsDir() {
+			if ignored {
+				fmt.Fprintln(logsW, "creating directory", path, "[skipped (ignored)]")
+				if !ignore.Exclusions() {
+					return fs.SkipDir
+				}
+				// an exception may still match some of the files of the
+				// directory, which then create it
+				return nil
+			}
 			if _, err := os.Stat(filepath.Join(outputDir, path)); err == nil {
 				fmt.Fprintln(logsW, "creating directory", path, "[skipped]")
 				return nil
@@ -141,6 +957,11 @@ func Overlay(ctx context.Context, logsW io.Writer, overlay fs.FS, outputDir stri
 			return os.MkdirAll(filepath.Join(outputDir, path), 0o755)
 		}
 
+		if ignored {
+			fmt.Fprintln(logsW, "writing", path, "[skipped (ignored)]")
+			return nil
+		}
+
 		var needsWrite bool
 
 		newContent, err := fs.ReadFile(overlay, path)
@@ -150,9 +971,14 @@ func Overlay(ctx context.Context, logsW io.Writer, overlay fs.FS, outputDir stri
 
 		outPath := filepath.Join(outputDir, path)
 		oldContent, err := os.ReadFile(outPath)
-		if err != nil {
+		exists := err == nil
+		if !exists {
 			needsWrite = true
 		} else {
+			newContent, err = SpliceUserRegions(oldContent, newContent, opts.UserRegionMarkers)
+			if err != nil {
+				return fmt.Errorf("preserve user regions of %s: %w", path, err)
+			}
 			needsWrite = string(oldContent) != string(newContent)
 		}
 
@@ -161,7 +987,19 @@ func Overlay(ctx context.Context, logsW io.Writer, overlay fs.FS, outputDir stri
 			return nil
 		}
 
+		if exists && opts.Backup {
+			fmt.Fprintln(logsW, "backing up", path, "to", path+backupSuffix)
+			if err := os.WriteFile(outPath+backupSuffix, oldContent, 0o600); err != nil {
+				return fmt.Errorf("back up %s: %w", path, err)
+			}
+		}
+
 		fmt.Fprintln(logsW, "writing", path)
+		if ignore != nil {
+			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+				return err
+			}
+		}
 		return os.WriteFile(outPath, newContent, 0o600)
 	})
 }
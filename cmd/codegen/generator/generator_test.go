@@ -0,0 +1,1776 @@
+package generator
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/psanford/memfs"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func testOverlay(t *testing.T, files map[string]string) *memfs.FS {
+	t.Helper()
+	mfs := memfs.New()
+	for name, content := range files {
+		require.NoError(t, mfs.MkdirAll(path.Dir(name), 0o755))
+		require.NoError(t, mfs.WriteFile(name, []byte(content), 0o600))
+	}
+	return mfs
+}
+
+func TestOverlayToTar(t *testing.T) {
+	overlay := testOverlay(t, map[string]string{
+		"dagger.gen.go":        "package main",
+		"internal/dagger/a.go": "package dagger",
+	})
+
+	var first, second bytes.Buffer
+	require.NoError(t, OverlayToTar(overlay, &first))
+	require.NoError(t, OverlayToTar(overlay, &second))
+	require.Equal(t, first.Bytes(), second.Bytes())
+
+	var names []string
+	contents := map[string]string{}
+	tr := tar.NewReader(&first)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Zero(t, hdr.ModTime.Unix())
+		names = append(names, hdr.Name)
+
+		if hdr.Typeflag == tar.TypeReg {
+			require.EqualValues(t, 0o644, hdr.Mode)
+			dt, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			contents[hdr.Name] = string(dt)
+		}
+	}
+
+	require.Equal(t, []string{
+		"dagger.gen.go",
+		"internal/",
+		"internal/dagger/",
+		"internal/dagger/a.go",
+	}, names)
+	require.Equal(t, "package dagger", contents["internal/dagger/a.go"])
+}
+
+func TestRewriteImports(t *testing.T) {
+	rewrites := map[string]string{
+		"dagger.io/dagger":   "example.com/mirror/dagger",
+		"github.com/foo/bar": "example.com/mirror/baz",
+	}
+
+	overlay := testOverlay(t, map[string]string{
+		"dagger.gen.go": `package main
+
+import (
+	"context"
+
+	"dagger.io/dagger"
+	"dagger.io/dagger/querybuilder"
+	"dagger.io/daggerfoo"
+	"github.com/foo/bar"
+)
+
+// dagger.io/dagger is left untouched outside of the imports
+var _ = "dagger.io/dagger"
+`,
+		"sdk/client.gen.ts": `import { Context } from "dagger.io/dagger"
+import {
+  connect,
+} from 'dagger.io/dagger/core'
+import "dagger.io/daggerfoo"
+export * from "dagger.io/dagger"
+
+const path = "dagger.io/dagger"
+`,
+		"README.md": `import "dagger.io/dagger"`,
+	})
+
+	rewritten, err := RewriteImports(overlay, rewrites)
+	require.NoError(t, err)
+
+	goSrc, err := fs.ReadFile(rewritten, "dagger.gen.go")
+	require.NoError(t, err)
+	require.Equal(t, `package main
+
+import (
+	"context"
+
+	"example.com/mirror/dagger"
+	"example.com/mirror/dagger/querybuilder"
+	"dagger.io/daggerfoo"
+	bar "example.com/mirror/baz"
+)
+
+// dagger.io/dagger is left untouched outside of the imports
+var _ = "dagger.io/dagger"
+`, string(goSrc))
+
+	tsSrc, err := fs.ReadFile(rewritten, "sdk/client.gen.ts")
+	require.NoError(t, err)
+	require.Equal(t, `import { Context } from "example.com/mirror/dagger"
+import {
+  connect,
+} from 'example.com/mirror/dagger/core'
+import "dagger.io/daggerfoo"
+export * from "example.com/mirror/dagger"
+
+const path = "dagger.io/dagger"
+`, string(tsSrc))
+
+	other, err := fs.ReadFile(rewritten, "README.md")
+	require.NoError(t, err)
+	require.Equal(t, `import "dagger.io/dagger"`, string(other))
+}
+
+func TestWrapText(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		s := "a long line that is not wrapped since wrapping is disabled"
+		require.Equal(t, s, WrapText(s, 0))
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		s := "Retrieves this container after executing the specified command inside it.\nSee also the documentation."
+		require.Equal(t, "Retrieves this container after\nexecuting the specified command\ninside it.\nSee also the documentation.", WrapText(s, 32))
+	})
+
+	t.Run("code spans and urls", func(t *testing.T) {
+		s := "Run `go build ./...` then read https://docs.dagger.io/api/reference/container for details."
+		require.Equal(t, "Run\n`go build ./...`\nthen read\nhttps://docs.dagger.io/api/reference/container\nfor details.", WrapText(s, 16))
+	})
+
+	t.Run("indentation", func(t *testing.T) {
+		s := "  - a list item that needs to be wrapped"
+		require.Equal(t, "  - a list item that\n  needs to be wrapped", WrapText(s, 22))
+	})
+}
+
+var testUserRegionMarkers = UserRegionMarkers{
+	Start: "dagger:user-region:start",
+	End:   "dagger:user-region:end",
+}
+
+func TestDocLinks(t *testing.T) {
+	desc := "See [the docs](https://docs.dagger.io/api), https://dagger.io/blog. and [the docs](https://docs.dagger.io/api) again, but not `https://in.code`."
+	require.Equal(t, []DocLink{
+		{Text: "the docs", URL: "https://docs.dagger.io/api"},
+		{URL: "https://dagger.io/blog"},
+	}, DocLinks(desc))
+
+	replaced, links := ReplaceMarkdownLinks(desc, func(link DocLink) string { return "<" + link.Text + ">" })
+	require.Equal(t, "See <the docs>, https://dagger.io/blog. and <the docs> again, but not `https://in.code`.", replaced)
+	require.Len(t, links, 2)
+
+	// the URLs with parentheses would be truncated
+	require.Empty(t, DocLinks("e.g. curl [http://example.com?token=$(cat token)](http://example.com?token=$(cat token))"))
+}
+
+func TestSpliceUserRegions(t *testing.T) {
+	t.Run("multiple regions", func(t *testing.T) {
+		existing := `package main
+
+// dagger:user-region:start imports
+import "strings"
+// dagger:user-region:end
+
+func old() {}
+
+// dagger:user-region:start helpers
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+// dagger:user-region:end
+`
+		generated := `package main
+
+// dagger:user-region:start imports
+// dagger:user-region:end
+
+func new() {}
+
+// dagger:user-region:start helpers
+// dagger:user-region:end
+
+// dagger:user-region:start extra
+// default content
+// dagger:user-region:end
+`
+		out, err := SpliceUserRegions([]byte(existing), []byte(generated), testUserRegionMarkers)
+		require.NoError(t, err)
+		require.Equal(t, `package main
+
+// dagger:user-region:start imports
+import "strings"
+// dagger:user-region:end
+
+func new() {}
+
+// dagger:user-region:start helpers
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+// dagger:user-region:end
+
+// dagger:user-region:start extra
+// default content
+// dagger:user-region:end
+`, string(out))
+	})
+
+	t.Run("same start lines", func(t *testing.T) {
+		existing := "// dagger:user-region:start\na\n// dagger:user-region:end\n// dagger:user-region:start\nb\n// dagger:user-region:end\n"
+		generated := "x\n// dagger:user-region:start\n// dagger:user-region:end\ny\n// dagger:user-region:start\n// dagger:user-region:end\n"
+		out, err := SpliceUserRegions([]byte(existing), []byte(generated), testUserRegionMarkers)
+		require.NoError(t, err)
+		require.Equal(t, "x\n// dagger:user-region:start\na\n// dagger:user-region:end\ny\n// dagger:user-region:start\nb\n// dagger:user-region:end\n", string(out))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		existing := "// dagger:user-region:start\na\n// dagger:user-region:end\n"
+		generated := "// dagger:user-region:start\n// dagger:user-region:end\n"
+		out, err := SpliceUserRegions([]byte(existing), []byte(generated), UserRegionMarkers{})
+		require.NoError(t, err)
+		require.Equal(t, generated, string(out))
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		existing := "// dagger:user-region:start\na\n"
+		_, err := SpliceUserRegions([]byte(existing), []byte("b\n"), testUserRegionMarkers)
+		require.ErrorContains(t, err, "unterminated user region at line 1")
+	})
+}
+
+func TestOverlayUserRegions(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "main.go"), []byte("package main\n// dagger:user-region:start\nvar x = 1\n// dagger:user-region:end\n"), 0o600))
+
+	overlay := testOverlay(t, map[string]string{
+		"main.go": "package main\n\nvar y = 2\n// dagger:user-region:start\n// dagger:user-region:end\n",
+	})
+	require.NoError(t, Overlay(context.Background(), io.Discard, overlay, outputDir, OverlayOptions{UserRegionMarkers: testUserRegionMarkers}))
+
+	dt, err := os.ReadFile(filepath.Join(outputDir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nvar y = 2\n// dagger:user-region:start\nvar x = 1\n// dagger:user-region:end\n", string(dt))
+}
+
+func TestOverlayBackup(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "changed.go"), []byte("package old\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "unchanged.go"), []byte("package same\n"), 0o600))
+
+	overlay := testOverlay(t, map[string]string{
+		"changed.go":   "package new\n",
+		"unchanged.go": "package same\n",
+		"created.go":   "package created\n",
+	})
+
+	t.Run("default suffix", func(t *testing.T) {
+		require.NoError(t, Overlay(context.Background(), io.Discard, overlay, outputDir, OverlayOptions{Backup: true}))
+
+		dt, err := os.ReadFile(filepath.Join(outputDir, "changed.go"))
+		require.NoError(t, err)
+		require.Equal(t, "package new\n", string(dt))
+		dt, err = os.ReadFile(filepath.Join(outputDir, "changed.go.bak"))
+		require.NoError(t, err)
+		require.Equal(t, "package old\n", string(dt))
+
+		_, err = os.Stat(filepath.Join(outputDir, "unchanged.go.bak"))
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = os.Stat(filepath.Join(outputDir, "created.go.bak"))
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("custom suffix", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, "changed.go"), []byte("package edited\n"), 0o600))
+		require.NoError(t, Overlay(context.Background(), io.Discard, overlay, outputDir, OverlayOptions{Backup: true, BackupSuffix: ".orig"}))
+
+		dt, err := os.ReadFile(filepath.Join(outputDir, "changed.go.orig"))
+		require.NoError(t, err)
+		require.Equal(t, "package edited\n", string(dt))
+	})
+}
+
+func TestOverlayDaggerIgnore(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, DaggerIgnoreFile), []byte("# generated elsewhere\ninternal\n**/*_mock.go\nsdk/*\n!sdk/kept.go\n"), 0o600))
+
+	overlay := testOverlay(t, map[string]string{
+		"main.go":             "package main\n",
+		"client_mock.go":      "package main\n",
+		"internal/a/a.go":     "package a\n",
+		"internal/b.go":       "package internal\n",
+		"sdk/ignored.go":      "package sdk\n",
+		"sdk/kept.go":         "package sdk\n",
+		"pkg/client_mock.go":  "package pkg\n",
+		"pkg/client_types.go": "package pkg\n",
+	})
+	var logs bytes.Buffer
+	require.NoError(t, Overlay(context.Background(), &logs, overlay, outputDir, OverlayOptions{}))
+
+	for _, path := range []string{"main.go", "sdk/kept.go", "pkg/client_types.go"} {
+		_, err := os.Stat(filepath.Join(outputDir, path))
+		require.NoError(t, err, path)
+	}
+	for _, path := range []string{"client_mock.go", "internal", "sdk/ignored.go", "pkg/client_mock.go"} {
+		_, err := os.Stat(filepath.Join(outputDir, path))
+		require.ErrorIs(t, err, fs.ErrNotExist, path)
+	}
+	require.Contains(t, logs.String(), "creating directory internal [skipped (ignored)]\n")
+	require.Contains(t, logs.String(), "writing sdk/ignored.go [skipped (ignored)]\n")
+}
+
+func TestLoadSchema(t *testing.T) {
+	schema, schemaVersion, err := LoadSchema(context.Background(), Config{
+		IntrospectionJSON: `{
+			"__schemaVersion": "v0.18.10",
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "OBJECT", "name": "Query", "fields": [{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]},
+					{"kind": "OBJECT", "name": "Query", "fields": [{"name": "defaultPlatform", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]}
+				]
+			}
+		}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v0.18.10", schemaVersion)
+	require.Len(t, schema.Types, 1)
+	require.Len(t, schema.Types[0].Fields, 2)
+}
+
+// escapeFS is an overlay listing a file outside of its root.
+type escapeFS struct {
+	fstest.MapFS
+}
+
+func (escapeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, fs.ErrNotExist
+	}
+	return []fs.DirEntry{escapeEntry{}}, nil
+}
+
+type escapeEntry struct{}
+
+func (escapeEntry) Name() string               { return "../escape" }
+func (escapeEntry) IsDir() bool                { return false }
+func (escapeEntry) Type() fs.FileMode          { return 0 }
+func (escapeEntry) Info() (fs.FileInfo, error) { return nil, fs.ErrNotExist }
+
+func TestOverlayEscape(t *testing.T) {
+	outputDir := t.TempDir()
+
+	err := Overlay(context.Background(), io.Discard, escapeFS{fstest.MapFS{}}, outputDir, OverlayOptions{})
+	require.ErrorContains(t, err, `overlay path "../escape" escapes output directory`)
+
+	_, err = os.Stat(filepath.Join(outputDir, "..", "escape"))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// duplicateFS is an overlay listing the entries of its directories twice.
+type duplicateFS struct {
+	fstest.MapFS
+}
+
+func (f duplicateFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.MapFS.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, entries...), nil
+}
+
+func TestDiffOverlays(t *testing.T) {
+	a := fstest.MapFS{
+		"dagger.gen.go":          {Data: []byte("package main\n\nfunc a() {}\n")},
+		"internal/dagger/old.go": {Data: []byte("package dagger\n")},
+		"internal/same.go":       {Data: []byte("package internal\n")},
+		"logo.png":               {Data: []byte("\x89PNG\x00a")},
+	}
+	b := fstest.MapFS{
+		"dagger.gen.go":          {Data: []byte("package main\n\nfunc b() {}\n")},
+		"internal/dagger/new.go": {Data: []byte("package dagger\n")},
+		"internal/same.go":       {Data: []byte("package internal\n")},
+		"logo.png":               {Data: []byte("\x89PNG\x00b")},
+		"src":                    {Mode: fs.ModeDir},
+	}
+
+	diffs, err := DiffOverlays(a, b)
+	require.NoError(t, err)
+	require.Equal(t, []OverlayDiff{
+		{Path: "dagger.gen.go", Change: OverlayModified, Diff: `--- a/dagger.gen.go
++++ b/dagger.gen.go
+@@ -1,3 +1,3 @@
+ package main
+ 
+-func a() {}
++func b() {}
+`},
+		{Path: "internal/dagger/new.go", Change: OverlayAdded, Diff: `--- /dev/null
++++ b/internal/dagger/new.go
+@@ -0,0 +1 @@
++package dagger
+`},
+		{Path: "internal/dagger/old.go", Change: OverlayRemoved, Diff: `--- a/internal/dagger/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package dagger
+`},
+		{Path: "logo.png", Change: OverlayModified},
+		{Path: "src", Change: OverlayAdded},
+	}, diffs)
+
+	// the reverse diff swaps the added and removed paths
+	diffs, err = DiffOverlays(b, a)
+	require.NoError(t, err)
+	require.Len(t, diffs, 5)
+	require.Equal(t, OverlayRemoved, diffs[1].Change)
+	require.Equal(t, OverlayAdded, diffs[2].Change)
+	require.Equal(t, OverlayDiff{Path: "src", Change: OverlayRemoved}, diffs[4])
+
+	diffs, err = DiffOverlays(a, a)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
+
+func TestOverlaysEqual(t *testing.T) {
+	files := map[string]string{
+		"dagger.gen.go":        "package main\n",
+		"internal/dagger/a.go": "package dagger\n",
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		// a memfs and a map have the same overlay
+		equal, paths, err := OverlaysEqual(testOverlay(t, files), fstest.MapFS{
+			"dagger.gen.go":        {Data: []byte("package main\n")},
+			"internal/dagger/a.go": {Data: []byte("package dagger\n")},
+		})
+		require.NoError(t, err)
+		require.True(t, equal)
+		require.Empty(t, paths)
+	})
+
+	t.Run("content", func(t *testing.T) {
+		equal, paths, err := OverlaysEqual(testOverlay(t, files), testOverlay(t, map[string]string{
+			"dagger.gen.go":        "package main\n",
+			"internal/dagger/a.go": "package other\n",
+		}))
+		require.NoError(t, err)
+		require.False(t, equal)
+		require.Equal(t, []string{"internal/dagger/a.go"}, paths)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		a := testOverlay(t, files)
+		b := testOverlay(t, map[string]string{
+			"dagger.gen.go": "package main\n",
+			"b.go":          "package main\n",
+		})
+		equal, paths, err := OverlaysEqual(a, b)
+		require.NoError(t, err)
+		require.False(t, equal)
+		require.Equal(t, []string{"b.go", "internal", "internal/dagger", "internal/dagger/a.go"}, paths)
+
+		// the same paths differ both ways
+		_, reversed, err := OverlaysEqual(b, a)
+		require.NoError(t, err)
+		require.Equal(t, paths, reversed)
+	})
+
+	t.Run("file and directory", func(t *testing.T) {
+		equal, paths, err := OverlaysEqual(fstest.MapFS{
+			"src": {Data: []byte("package main\n")},
+		}, fstest.MapFS{
+			"src": {Mode: fs.ModeDir},
+		})
+		require.NoError(t, err)
+		require.False(t, equal)
+		require.Equal(t, []string{"src"}, paths)
+	})
+}
+
+func TestValidateOverlay(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, ValidateOverlay(fstest.MapFS{
+			"dagger.gen.go":        {Data: []byte("package main")},
+			"internal/dagger/a.go": {Data: []byte("package dagger")},
+			"internal/dagger/b.go": {Data: []byte("package dagger")},
+		}))
+	})
+
+	t.Run("duplicate path", func(t *testing.T) {
+		err := ValidateOverlay(duplicateFS{fstest.MapFS{
+			"dagger.gen.go": {Data: []byte("package main")},
+		}})
+		require.ErrorContains(t, err, `overlay has duplicate path "dagger.gen.go"`)
+	})
+
+	t.Run("case-insensitive collision", func(t *testing.T) {
+		err := ValidateOverlay(fstest.MapFS{
+			"internal/dagger/Container.go": {Data: []byte("package dagger")},
+			"internal/dagger/container.go": {Data: []byte("package dagger")},
+		})
+		require.ErrorContains(t, err, `overlay paths "internal/dagger/Container.go" and "internal/dagger/container.go" collide on case-insensitive filesystems`)
+	})
+
+	t.Run("case-insensitive directory collision", func(t *testing.T) {
+		err := ValidateOverlay(fstest.MapFS{
+			"Internal/a.go": {Data: []byte("package internal")},
+			"internal/b.go": {Data: []byte("package internal")},
+		})
+		require.ErrorContains(t, err, `overlay paths "Internal" and "internal" collide`)
+	})
+}
+
+func TestLoadSchemaFromSchema(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindObject, Name: "Query"},
+		},
+	}
+
+	loaded, schemaVersion, err := LoadSchema(context.Background(), Config{
+		Schema:        schema,
+		SchemaVersion: "v0.18.10",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v0.18.10", schemaVersion)
+	require.Equal(t, "Query", loaded.Types[0].Name)
+}
+
+func TestSchemaSnapshot(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "version", "description": "The <semver> of the engine.", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]}
+			]
+		}
+	}`), &resp))
+
+	dir := filepath.Join(t.TempDir(), "schema")
+	require.NoError(t, writeSchemaSnapshot(dir, resp.Schema, "v0.18.10"))
+
+	dt, err := os.ReadFile(filepath.Join(dir, SchemaSnapshotFile))
+	require.NoError(t, err)
+	normalized, err := NormalizeIntrospection(dt)
+	require.NoError(t, err)
+	require.Equal(t, string(normalized)+"\n", string(dt))
+	require.Contains(t, string(dt), "The <semver> of the engine.")
+	version, err := os.ReadFile(filepath.Join(dir, SchemaSnapshotVersionFile))
+	require.NoError(t, err)
+	require.Equal(t, "v0.18.10\n", string(version))
+
+	schema, schemaVersion, err := ReadSchemaSnapshot(dir)
+	require.NoError(t, err)
+	require.Equal(t, "v0.18.10", schemaVersion)
+	require.Equal(t, resp.Schema, schema)
+
+	// the snapshot of the read schema is the same
+	fingerprint := Config{SchemaSnapshotDir: dir}.Fingerprint()
+	require.NoError(t, writeSchemaSnapshot(dir, schema, schemaVersion))
+	rewritten, err := os.ReadFile(filepath.Join(dir, SchemaSnapshotFile))
+	require.NoError(t, err)
+	require.Equal(t, string(dt), string(rewritten))
+	require.Equal(t, fingerprint, Config{SchemaSnapshotDir: dir}.Fingerprint())
+
+	loaded, schemaVersion, err := LoadSchema(context.Background(), Config{SchemaSnapshotDir: dir})
+	require.NoError(t, err)
+	require.Equal(t, "v0.18.10", schemaVersion)
+	require.Equal(t, "version", loaded.Query().Fields[0].Name)
+
+	// the fingerprint follows the contents of the snapshot
+	require.NoError(t, writeSchemaSnapshot(dir, schema, "v0.19.0"))
+	require.NotEqual(t, fingerprint, Config{SchemaSnapshotDir: dir}.Fingerprint())
+
+	_, _, err = ReadSchemaSnapshot(t.TempDir())
+	require.ErrorContains(t, err, "read schema snapshot")
+}
+
+func TestLoadSchemaCompatSchemas(t *testing.T) {
+	field := func(name string) *introspection.Field {
+		return &introspection.Field{Name: name, TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}}
+	}
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindObject, Name: "Query", Fields: []*introspection.Field{field("version"), field("newField")}},
+		},
+	}
+	schema.QueryType.Name = "Query"
+	compat := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindObject, Name: "Query", Fields: []*introspection.Field{field("version")}},
+		},
+	}
+	compat.QueryType.Name = "Query"
+
+	loaded, _, err := LoadSchema(context.Background(), Config{
+		Schema:        schema,
+		CompatSchemas: []*introspection.Schema{compat},
+	})
+	require.NoError(t, err)
+	require.Len(t, loaded.Query().Fields, 1)
+	require.Equal(t, "version", loaded.Query().Fields[0].Name)
+}
+
+// hooksGenerator is a Generator implementing GeneratorHooks, recording the
+// calls it gets.
+type hooksGenerator struct {
+	calls     []string
+	beforeErr error
+}
+
+func (g *hooksGenerator) GenerateModule(ctx context.Context, schema *introspection.Schema, schemaVersion string) (*GeneratedState, error) {
+	return g.GenerateClient(ctx, schema, schemaVersion)
+}
+
+func (g *hooksGenerator) GenerateClient(ctx context.Context, schema *introspection.Schema, schemaVersion string) (*GeneratedState, error) {
+	g.calls = append(g.calls, "generate "+schema.Types[0].Name)
+	return &GeneratedState{Overlay: fstest.MapFS{}}, nil
+}
+
+func (g *hooksGenerator) Before(ctx context.Context, schema *introspection.Schema) error {
+	g.calls = append(g.calls, "before")
+	schema.Types[0].Name = "Renamed"
+	return g.beforeErr
+}
+
+func (g *hooksGenerator) After(ctx context.Context, state *GeneratedState) error {
+	g.calls = append(g.calls, "after")
+	state.NeedRegenerate = true
+	return nil
+}
+
+func TestGenerateHooks(t *testing.T) {
+	const lang SDKLang = "test-hooks"
+	gen := &hooksGenerator{}
+	registryMu.Lock()
+	registry[lang] = func(Config) Generator { return gen }
+	registryMu.Unlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, lang)
+		registryMu.Unlock()
+	})
+
+	schema := func() *introspection.Schema {
+		return &introspection.Schema{
+			Types: introspection.Types{
+				{Kind: introspection.TypeKindObject, Name: "Query"},
+			},
+		}
+	}
+
+	t.Run("called", func(t *testing.T) {
+		gen.calls = nil
+		generated, err := Generate(context.Background(), schema(), "", Config{Lang: lang, ClientOnly: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"before", "generate Renamed", "after"}, gen.calls)
+		require.True(t, generated.NeedRegenerate)
+	})
+
+	t.Run("before error", func(t *testing.T) {
+		gen.calls = nil
+		gen.beforeErr = errors.New("invalid schema")
+		t.Cleanup(func() { gen.beforeErr = nil })
+
+		_, err := Generate(context.Background(), schema(), "", Config{Lang: lang, ClientOnly: true})
+		require.ErrorContains(t, err, "before generation: invalid schema")
+		require.Equal(t, []string{"before"}, gen.calls)
+	})
+}
+
+func TestLintSchema(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "description": "Creates a container.", "args": [
+						{"name": "Platform", "description": "", "type": {"kind": "SCALAR", "name": "String"}}
+					], "type": {"kind": "OBJECT", "name": "Container"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "description": "A container.", "fields": [
+					{"name": "with_exec", "description": "Runs a command.", "args": [], "type": {"kind": "OBJECT", "name": "Container"}}
+				]},
+				{"kind": "ENUM", "name": "network_protocol", "description": "A protocol.", "enumValues": [
+					{"name": "TCP", "description": "TCP."},
+					{"name": "UDP", "description": ""}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "", "inputFields": []},
+				{"kind": "OBJECT", "name": "__Type", "fields": [
+					{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "SCALAR", "name": "String"}
+			]
+		}
+	}`), &resp))
+
+	err := LintSchema(resp.Schema)
+	var lintErr *SchemaLintError
+	require.ErrorAs(t, err, &lintErr)
+	require.Equal(t, []string{
+		"argument Query.container.Platform: name is not in camelCase",
+		"argument Query.container.Platform: no description",
+		"field Container.with_exec: name is not in camelCase",
+		"type network_protocol: name is not in PascalCase",
+		"enum value network_protocol.UDP: no description",
+		"input_object type has no name",
+	}, lintErr.Violations)
+	require.ErrorContains(t, err, "schema has 6 violation(s):\n- argument Query.container.Platform: name is not in camelCase\n")
+
+	t.Run("strict generation", func(t *testing.T) {
+		_, err := Generate(context.Background(), resp.Schema, "", Config{Lang: SDKLangGo, ClientOnly: true, StrictSchema: true})
+		require.ErrorAs(t, err, &lintErr)
+		require.ErrorContains(t, err, "strict schema: schema has 6 violation(s)")
+	})
+
+	require.NoError(t, LintSchema(&introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindObject, Name: "Query", Description: "The root of the API."},
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+		},
+	}))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{Schema: &introspection.Schema{}}.Validate())
+	require.NoError(t, Config{IntrospectionJSON: "{}"}.Validate())
+	require.ErrorContains(t, Config{
+		Schema:            &introspection.Schema{},
+		IntrospectionJSON: "{}",
+	}.Validate(), "only one of schema and introspection json can be set")
+	require.ErrorContains(t, Config{
+		SchemaSnapshotDir: "schema",
+		IntrospectionJSON: "{}",
+	}.Validate(), "a schema snapshot dir can't be set with a schema or introspection json")
+	require.NoError(t, Config{ChangedTypes: []string{"Container"}, SplitByType: true}.Validate())
+	require.ErrorContains(t, Config{ChangedTypes: []string{"Container"}}.Validate(), "changed types require splitting by type")
+	require.NoError(t, Config{TypeScriptCodeSplit: true, ClientOnly: true}.Validate())
+	require.ErrorContains(t, Config{TypeScriptCodeSplit: true}.Validate(), "typescript code split requires generating a client")
+	require.ErrorContains(t, Config{TypeScriptCodeSplit: true, ClientOnly: true, TypeScriptDeclarationOnly: true}.Validate(), "typescript code split requires generating the implementation of the client")
+	require.NoError(t, Config{GenerateResponseValidation: true, ClientOnly: true, EmbedSchema: true}.Validate())
+	require.ErrorContains(t, Config{GenerateResponseValidation: true, EmbedSchema: true}.Validate(), "response validation requires generating a client")
+	require.ErrorContains(t, Config{GenerateResponseValidation: true, ClientOnly: true}.Validate(), "response validation requires embedding the schema")
+	require.NoError(t, Config{GoOptionalStyle: GoOptionalOptional}.Validate())
+	require.ErrorContains(t, Config{GoOptionalStyle: "Nullable"}.Validate(), `unknown go optional style "Nullable"`)
+	require.ErrorContains(t, Config{GeneratedFileSuffix: "/gen"}.Validate(), `generated file suffix "/gen" must not contain a path separator`)
+	require.NoError(t, Config{GoTarget: GoTargetWasm, ClientOnly: true}.Validate())
+	require.ErrorContains(t, Config{GoTarget: GoTargetTinyGo}.Validate(), `go target "tinygo" requires generating a client`)
+	require.ErrorContains(t, Config{GoTarget: GoTargetWasm, ClientOnly: true, GenerateTracing: true}.Validate(), `go target "wasm" doesn't support tracing`)
+	require.ErrorContains(t, Config{GoTarget: GoTargetTinyGo, ClientOnly: true, LazyConnect: true}.Validate(), `go target "tinygo" doesn't support lazy connection`)
+	require.ErrorContains(t, Config{GoTarget: "arm"}.Validate(), `unknown go target "arm"`)
+	require.NoError(t, Config{GoPlatformSplit: true, ClientOnly: true}.Validate())
+	require.ErrorContains(t, Config{GoPlatformSplit: true}.Validate(), "go platform split requires generating a client")
+	require.ErrorContains(t, Config{GoPlatformSplit: true, ClientOnly: true, GoTarget: GoTargetWasm}.Validate(), `go platform split isn't supported with go target "wasm"`)
+	require.ErrorContains(t, Config{ClientRetryMaxAttempts: -1}.Validate(), "client retry max attempts must not be negative")
+	require.ErrorContains(t, Config{ClientRetryBackoff: -time.Second}.Validate(), "client retry backoff must not be negative")
+	require.ErrorContains(t, Config{ClientRetryExcludedFields: []string{"Container.publish"}}.Validate(), `invalid client retry excluded field "Container.publish"`)
+	require.ErrorContains(t, Config{DefaultOperationTimeout: -time.Second}.Validate(), "default operation timeout must not be negative")
+	require.ErrorContains(t, Config{MaxQueryDepth: -1}.Validate(), "max query depth must not be negative")
+	require.ErrorContains(t, Config{ConnectionPoolSize: -1}.Validate(), "connection pool size must not be negative")
+	require.ErrorContains(t, Config{GenerateErrorCodes: true}.Validate(), "error codes require a list of codes or an enum of the schema")
+	require.NoError(t, Config{GenerateErrorCodes: true, ErrorCodeEnum: "ErrorCode"}.Validate())
+	require.ErrorContains(t, Config{TypedCursors: true}.Validate(), "typed cursors require pagination helpers")
+	require.NoError(t, Config{TypedCursors: true, GeneratePaginationHelpers: true}.Validate())
+	require.ErrorContains(t, Config{GoModSearchDepth: -1}.Validate(), "go.mod search depth must not be negative")
+	require.ErrorContains(t, Config{ScalarSerializers: map[string]ScalarSerializer{"DateTime": {GoType: "time.Time"}}}.Validate(), "serializer of scalar DateTime requires a Go type, marshal and unmarshal code")
+	require.NoError(t, Config{CacheableFields: []string{"*.id"}}.Validate())
+	require.ErrorContains(t, Config{CacheableFields: []string{"Container.["}}.Validate(), `cacheable field pattern "Container.["`)
+	require.NoError(t, Config{ValidationTagKey: "binding"}.Validate())
+	require.ErrorContains(t, Config{ValidationTagKey: "json"}.Validate(), `invalid validation tag key "json"`)
+	require.ErrorContains(t, Config{ValidationTagKey: "a:b"}.Validate(), `invalid validation tag key "a:b"`)
+}
+
+func TestHideTypes(t *testing.T) {
+	load := func() *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "OBJECT", "name": "Query", "fields": [
+						{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+						{"name": "internalState", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "InternalState"}}},
+						{"name": "debug", "args": [
+							{"name": "state", "type": {"kind": "INPUT_OBJECT", "name": "InternalStateInput"}}
+						], "type": {"kind": "SCALAR", "name": "String"}}
+					]},
+					{"kind": "OBJECT", "name": "InternalState", "fields": []},
+					{"kind": "INPUT_OBJECT", "name": "InternalStateInput", "inputFields": []}
+				]
+			}
+		}`), &resp))
+		return resp.Schema
+	}
+
+	schema := load()
+	err := HideTypes(schema, []string{"Internal"})
+	require.ErrorContains(t, err, `argument Query.debug.state depends on hidden type "InternalStateInput"`)
+
+	schema = load()
+	schema.Types[0].Fields = schema.Types[0].Fields[:2]
+	require.NoError(t, HideTypes(schema, []string{"Internal"}))
+	require.Len(t, schema.Types, 1)
+	require.Len(t, schema.Types[0].Fields, 1)
+	require.Equal(t, "version", schema.Types[0].Fields[0].Name)
+
+	schema = load()
+	require.NoError(t, HideTypes(schema, nil))
+	require.Len(t, schema.Types, 3)
+}
+
+func TestSeparatePreviewFields(t *testing.T) {
+	load := func() *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "OBJECT", "name": "Query", "fields": [
+						{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+						{"name": "experimentalState", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+						{"name": "gpu", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "directives": [
+							{"name": "preview", "args": []}
+						]}
+					]},
+					{"kind": "INTERFACE", "name": "Node", "fields": [
+						{"name": "experimentalId", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+					]}
+				]
+			}
+		}`), &resp))
+		return resp.Schema
+	}
+
+	schema := load()
+	require.NoError(t, SeparatePreviewFields(schema, "preview", "experimental"))
+	query := schema.Types.Get("Query")
+	require.Len(t, query.Fields, 1)
+	require.Equal(t, "version", query.Fields[0].Name)
+	previewType := PreviewTypeOf(schema, query)
+	require.NotNil(t, previewType)
+	require.Equal(t, "QueryPreview", previewType.Name)
+	require.Len(t, previewType.Fields, 2)
+	require.Same(t, previewType, previewType.Fields[0].ParentObject)
+	require.Contains(t, previewType.Description, "The preview fields of Client.")
+	// the interfaces are left as is
+	require.Len(t, schema.Types.Get("Node").Fields, 1)
+	require.Nil(t, PreviewTypeOf(schema, schema.Types.Get("Node")))
+
+	// separating again changes nothing
+	require.NoError(t, SeparatePreviewFields(schema, "preview", "experimental"))
+	require.Len(t, schema.Types, 3)
+	require.Nil(t, PreviewTypeOf(schema, previewType))
+
+	schema = load()
+	require.NoError(t, SeparatePreviewFields(schema, "preview", ""))
+	require.Len(t, schema.Types.Get("Query").Fields, 2)
+
+	schema = load()
+	schema.Types = append(schema.Types, &introspection.Type{Kind: introspection.TypeKindObject, Name: "QueryPreview"})
+	require.ErrorContains(t, SeparatePreviewFields(schema, "preview", ""), "type QueryPreview of the preview fields of Query collides with a type of the schema")
+}
+
+func TestNormalizeIntrospection(t *testing.T) {
+	a := []byte(`{
+		"__schema": {
+			"types": [
+				{"name": "Query", "kind": "OBJECT", "fields": [{"name": "a<b", "args": []}]},
+				{"kind": "SCALAR", "name": "Int", "description": null}
+			],
+			"queryType": {"name": "Query"}
+		},
+		"__schemaVersion": "v0.1.0"
+	}`)
+	b := []byte(`{"__schemaVersion":"v0.1.0","__schema":{"queryType":{"name":"Query"},` +
+		`"types":[{"fields":[{"args":[],"name":"a<b"}],"kind":"OBJECT","name":"Query"},` +
+		`{"description":null,"name":"Int","kind":"SCALAR"}]}}`)
+
+	normalizedA, err := NormalizeIntrospection(a)
+	require.NoError(t, err)
+	normalizedB, err := NormalizeIntrospection(b)
+	require.NoError(t, err)
+	require.Equal(t, string(normalizedA), string(normalizedB))
+	require.Equal(t, `{"__schema":{"queryType":{"name":"Query"},`+
+		`"types":[{"fields":[{"args":[],"name":"a<b"}],"kind":"OBJECT","name":"Query"},`+
+		`{"description":null,"kind":"SCALAR","name":"Int"}]},"__schemaVersion":"v0.1.0"}`, string(normalizedA))
+
+	// the config fingerprint doesn't depend on the order either
+	require.Equal(t,
+		Config{Lang: SDKLangGo, IntrospectionJSON: string(a)}.Fingerprint(),
+		Config{Lang: SDKLangGo, IntrospectionJSON: string(b)}.Fingerprint())
+
+	_, err = NormalizeIntrospection([]byte(`{"__schema": {}} {}`))
+	require.ErrorContains(t, err, "unexpected data after the document")
+	_, err = NormalizeIntrospection([]byte(`{"__schema":`))
+	require.Error(t, err)
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	load := func(schemaJSON string) *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(schemaJSON), &resp))
+		return resp.Schema
+	}
+	oldSchema := load(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "OBJECT", "name": "Container"}},
+					{"name": "legacy", "args": [], "type": {"kind": "OBJECT", "name": "Legacy"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "withExec", "args": [], "type": {"kind": "OBJECT", "name": "Container"}},
+					{"name": "exec", "args": [], "type": {"kind": "OBJECT", "name": "Container"}},
+					{"name": "stdout", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "OBJECT", "name": "Legacy", "fields": []},
+				{"kind": "ENUM", "name": "NetworkProtocol", "enumValues": [{"name": "TCP"}, {"name": "UDP"}]},
+				{"kind": "INPUT_OBJECT", "name": "PortForward", "inputFields": [
+					{"name": "backend", "type": {"kind": "SCALAR", "name": "Int"}}
+				]},
+				{"kind": "OBJECT", "name": "__Type", "fields": []}
+			]
+		}
+	}`)
+	newSchema := load(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "OBJECT", "name": "Container"}},
+					{"name": "gpu", "args": [], "type": {"kind": "OBJECT", "name": "GPU"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "withExec", "args": [], "type": {"kind": "OBJECT", "name": "Container"}, "isDeprecated": true, "deprecationReason": "Use ` + "`withCommand`" + ` instead."},
+					{"name": "withCommand", "args": [], "type": {"kind": "OBJECT", "name": "Container"}},
+					{"name": "stdout", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+					{"name": "stderr", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "OBJECT", "name": "GPU", "fields": []},
+				{"kind": "ENUM", "name": "NetworkProtocol", "enumValues": [{"name": "TCP"}, {"name": "UDP", "isDeprecated": true}, {"name": "SCTP"}]},
+				{"kind": "INPUT_OBJECT", "name": "PortForward", "inputFields": [
+					{"name": "backend", "type": {"kind": "SCALAR", "name": "Int"}},
+					{"name": "frontend", "type": {"kind": "SCALAR", "name": "Int"}}
+				]},
+				{"kind": "OBJECT", "name": "__Type", "fields": [{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]}
+			]
+		}
+	}`)
+
+	changelog, err := GenerateChangelog(oldSchema, newSchema)
+	require.NoError(t, err)
+	golden, err := os.ReadFile(filepath.Join("testdata", "changelog.md"))
+	require.NoError(t, err)
+	require.Equal(t, string(golden), changelog)
+
+	changelog, err = GenerateChangelog(newSchema, newSchema)
+	require.NoError(t, err)
+	require.Equal(t, "# API changes\n\nNo changes.\n", changelog)
+
+	_, err = GenerateChangelog(nil, newSchema)
+	require.ErrorContains(t, err, "changelog requires two schemas")
+}
+
+func TestIntersectSchemas(t *testing.T) {
+	load := func(schemaJSON string) *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(schemaJSON), &resp))
+		return resp.Schema
+	}
+	schema := load(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "newThing", "args": [], "type": {"kind": "OBJECT", "name": "NewThing"}},
+					{"name": "retyped", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "withExec", "args": [
+						{"name": "args", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+						{"name": "expand", "type": {"kind": "SCALAR", "name": "Boolean"}},
+						{"name": "newOption", "type": {"kind": "SCALAR", "name": "String"}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "withMount", "args": [
+						{"name": "mount", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "MountInput"}}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "withLabel", "args": [
+						{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "platform", "args": [], "type": {"kind": "ENUM", "name": "Platform"}}
+				]},
+				{"kind": "OBJECT", "name": "NewThing", "fields": [
+					{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "MountInput", "inputFields": [
+					{"name": "path", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+					{"name": "owner", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+				]},
+				{"kind": "ENUM", "name": "Platform", "enumValues": [{"name": "LINUX"}, {"name": "DARWIN"}]},
+				{"kind": "SCALAR", "name": "String"},
+				{"kind": "SCALAR", "name": "Boolean"}
+			]
+		}
+	}`)
+	older := load(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "retyped", "args": [], "type": {"kind": "SCALAR", "name": "Int"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "withExec", "args": [
+						{"name": "args", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+						{"name": "expand", "type": {"kind": "SCALAR", "name": "Boolean"}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "withMount", "args": [
+						{"name": "mount", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "MountInput"}}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "withLabel", "args": [
+						{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+						{"name": "value", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "platform", "args": [], "type": {"kind": "ENUM", "name": "Platform"}}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "MountInput", "inputFields": [
+					{"name": "path", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+				]},
+				{"kind": "ENUM", "name": "Platform", "enumValues": [{"name": "LINUX"}, {"name": "WINDOWS"}]}
+			]
+		}
+	}`)
+
+	require.NoError(t, IntersectSchemas(schema, []*introspection.Schema{older}))
+	names := func(t *introspection.Type) []string {
+		var names []string
+		for _, f := range t.Fields {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+	require.Nil(t, schema.Types.Get("NewThing"))
+	// the new required input field isn't in the older schema
+	require.Nil(t, schema.Types.Get("MountInput"))
+	require.NotNil(t, schema.Types.Get("String"))
+	require.Equal(t, []string{"container"}, names(schema.Query()))
+	// withLabel has a new required argument in the older schema
+	container := schema.Types.Get("Container")
+	require.Equal(t, []string{"withExec", "platform"}, names(container))
+	require.Len(t, container.Fields[0].Args, 2)
+	require.Equal(t, []introspection.EnumValue{{Name: "LINUX"}}, schema.Types.Get("Platform").EnumValues)
+
+	schema = load(`{"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]}
+	]}}`)
+	require.ErrorContains(t, IntersectSchemas(schema, []*introspection.Schema{older}), `no field of the query type "Query" is in all the schemas`)
+
+	schema = load(`{"__schema": {"queryType": {"name": "Root"}, "types": [
+		{"kind": "OBJECT", "name": "Root", "fields": [{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]}
+	]}}`)
+	require.ErrorContains(t, IntersectSchemas(schema, []*introspection.Schema{older}), `query type "Root" isn't in all the schemas`)
+}
+
+func TestDependentTypes(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}},
+					{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "withMount", "args": [
+						{"name": "source", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "MountInput"}}}
+					], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "MountInput", "inputFields": [
+					{"name": "cache", "type": {"kind": "OBJECT", "name": "Cache"}}
+				]},
+				{"kind": "OBJECT", "name": "Cache", "fields": []},
+				{"kind": "OBJECT", "name": "Unrelated", "fields": [
+					{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "SCALAR", "name": "String"}
+			]
+		}
+	}`), &resp))
+
+	dependents, err := DependentTypes(resp.Schema, []string{"Cache"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"Cache": true, "MountInput": true, "Container": true, "Query": true}, dependents)
+
+	dependents, err = DependentTypes(resp.Schema, []string{"Unrelated"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"Unrelated": true}, dependents)
+
+	_, err = DependentTypes(resp.Schema, []string{"Missing"})
+	require.ErrorContains(t, err, `unknown type "Missing"`)
+}
+
+func TestIsRecursiveInputField(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "INPUT_OBJECT", "name": "Even", "inputFields": [
+					{"name": "next", "type": {"kind": "INPUT_OBJECT", "name": "Odd"}}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "Odd", "inputFields": [
+					{"name": "next", "type": {"kind": "INPUT_OBJECT", "name": "Even"}},
+					{"name": "leaf", "type": {"kind": "INPUT_OBJECT", "name": "Leaf"}},
+					{"name": "children", "type": {"kind": "LIST", "ofType": {"kind": "INPUT_OBJECT", "name": "Odd"}}}
+				]},
+				{"kind": "INPUT_OBJECT", "name": "Leaf", "inputFields": [
+					{"name": "value", "type": {"kind": "SCALAR", "name": "String"}}
+				]},
+				{"kind": "SCALAR", "name": "String"}
+			]
+		}
+	}`), &resp))
+
+	field := func(typeName, name string) introspection.InputValue {
+		for _, f := range resp.Schema.Types.Get(typeName).InputFields {
+			if f.Name == name {
+				return f
+			}
+		}
+		t.Fatalf("no field %s.%s", typeName, name)
+		return introspection.InputValue{}
+	}
+	require.True(t, IsRecursiveInputField(resp.Schema, "Even", field("Even", "next")))
+	require.True(t, IsRecursiveInputField(resp.Schema, "Odd", field("Odd", "next")))
+	require.False(t, IsRecursiveInputField(resp.Schema, "Odd", field("Odd", "leaf")))
+	require.False(t, IsRecursiveInputField(resp.Schema, "Odd", field("Odd", "children")))
+	require.False(t, IsRecursiveInputField(resp.Schema, "Leaf", field("Leaf", "value")))
+}
+
+func TestGeneratedStateDump(t *testing.T) {
+	cmd := exec.Command("go", "mod", "edit", "-replace", "dagger.io/dagger=../it's here")
+	cmd.Dir = "internal/dagger"
+	state := &GeneratedState{
+		Overlay: testOverlay(t, map[string]string{
+			"dagger.gen.go":        "package main",
+			"internal/dagger/a.go": "package dagger",
+		}),
+		PostCommands:   []*exec.Cmd{exec.Command("go", "mod", "tidy"), cmd},
+		NeedRegenerate: true,
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, state.Dump(&b))
+	require.Equal(t, `overlay:
+  dagger.gen.go (12 bytes)
+  internal/
+  internal/dagger/
+  internal/dagger/a.go (14 bytes)
+post commands:
+  go mod tidy
+  cd internal/dagger && go mod edit -replace 'dagger.io/dagger=../it'\''s here'
+need regenerate: true
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, (&GeneratedState{}).Dump(&b))
+	require.Equal(t, "overlay:\npost commands:\nneed regenerate: false\n", b.String())
+}
+
+func TestEmitJSONSchema(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [
+					{"name": "container", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Container"}}}
+				]},
+				{"kind": "OBJECT", "name": "Container", "description": "A container.", "fields": [
+					{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ContainerID"}}},
+					{"name": "platform", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true},
+					{"name": "exitCode", "description": "The exit code.", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}},
+					{"name": "protocols", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "ENUM", "name": "Protocol"}}}}
+				]},
+				{"kind": "SCALAR", "name": "ContainerID", "description": "The ID of a container."},
+				{"kind": "SCALAR", "name": "Int"},
+				{"kind": "SCALAR", "name": "String"},
+				{"kind": "ENUM", "name": "Protocol", "enumValues": [{"name": "TCP"}, {"name": "UDP"}]},
+				{"kind": "INPUT_OBJECT", "name": "PortInput", "inputFields": [
+					{"name": "port", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}},
+					{"name": "protocol", "type": {"kind": "NON_NULL", "ofType": {"kind": "ENUM", "name": "Protocol"}}, "defaultValue": "TCP"}
+				]},
+				{"kind": "OBJECT", "name": "__Type", "fields": []}
+			]
+		}
+	}`), &resp))
+
+	out, err := EmitJSONSchema(resp.Schema)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "$defs": {
+    "Container": {
+      "description": "A container.",
+      "properties": {
+        "exitCode": {
+          "description": "The exit code.",
+          "type": "integer"
+        },
+        "id": {
+          "$ref": "#/$defs/ContainerID"
+        },
+        "platform": {
+          "anyOf": [
+            {
+              "type": "string"
+            },
+            {
+              "type": "null"
+            }
+          ],
+          "deprecated": true
+        },
+        "protocols": {
+          "anyOf": [
+            {
+              "items": {
+                "$ref": "#/$defs/Protocol"
+              },
+              "type": "array"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "type": "object"
+    },
+    "ContainerID": {
+      "description": "The ID of a container."
+    },
+    "PortInput": {
+      "additionalProperties": false,
+      "properties": {
+        "port": {
+          "type": "integer"
+        },
+        "protocol": {
+          "$ref": "#/$defs/Protocol"
+        }
+      },
+      "required": [
+        "port"
+      ],
+      "type": "object"
+    },
+    "Protocol": {
+      "enum": [
+        "TCP",
+        "UDP"
+      ],
+      "type": "string"
+    },
+    "Query": {
+      "properties": {
+        "container": {
+          "$ref": "#/$defs/Container"
+        }
+      },
+      "type": "object"
+    }
+  },
+  "$schema": "https://json-schema.org/draft/2020-12/schema"
+}`, string(out))
+}
+
+func TestResolveDependencies(t *testing.T) {
+	dep := func(name string) ModuleSourceDependencies {
+		return ModuleSourceDependencies{Kind: "GIT_SOURCE", Name: name, Source: "github.com/example/" + name}
+	}
+	graph := map[string][]ModuleSourceDependencies{
+		"a": {dep("shared"), dep("d")},
+		"b": {dep("shared")},
+		"d": {dep("e")},
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	resolve := func(ctx context.Context, d ModuleSourceDependencies) ([]ModuleSourceDependencies, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if d.Name == "fail" {
+			return nil, errors.New("not found")
+		}
+		return graph[d.Name], nil
+	}
+
+	deps := []ModuleSourceDependencies{dep("a"), dep("b"), dep("c"), dep("f"), dep("g")}
+	resolved, err := ResolveDependencies(context.Background(), deps, 2, resolve)
+	require.NoError(t, err)
+	require.Equal(t, append(deps, dep("shared"), dep("d"), dep("e")), resolved)
+	require.LessOrEqual(t, maxInFlight.Load(), int32(2))
+
+	_, err = ResolveDependencies(context.Background(), append(deps, dep("fail")), 0, resolve)
+	require.ErrorContains(t, err, "resolve dependency fail: not found")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ResolveDependencies(ctx, deps, 2, resolve)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// testEngineConn is a connection to a fake engine served by an HTTP server.
+type testEngineConn struct {
+	*httptest.Server
+}
+
+func (c testEngineConn) Do(req *http.Request) (*http.Response, error) {
+	return c.Client().Do(req)
+}
+
+func (c testEngineConn) Host() string {
+	return c.Listener.Addr().String()
+}
+
+func (c testEngineConn) Close() error {
+	return nil
+}
+
+func TestLoadModuleDependencies(t *testing.T) {
+	ctx := context.Background()
+	source := func(name string) map[string]any {
+		return map[string]any{"kind": "GIT_SOURCE", "moduleOriginalName": name, "pin": name + "-pin", "asString": "github.com/example/" + name}
+	}
+	graph := map[string][]map[string]any{
+		"github.com/example/a": {source("shared")},
+		"github.com/example/b": {source("shared")},
+	}
+
+	var queried atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+		require.NoError(t, err)
+
+		deps := []map[string]any{source("a"), source("b")}
+		if req.OperationName == "DependencyDependencies" {
+			queried.Add(1)
+			ref, _ := req.Variables["refString"].(string)
+			require.Equal(t, strings.TrimPrefix(ref, "github.com/example/")+"-pin", req.Variables["refPin"])
+			deps = graph[ref]
+		} else {
+			require.Equal(t, "ModuleSourceDependencies", req.OperationName)
+			require.Equal(t, "mod", req.Variables["source"])
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"source": map[string]any{"dependencies": deps}},
+		}))
+	}))
+	defer srv.Close()
+
+	dag, err := dagger.Connect(ctx, dagger.WithConn(testEngineConn{srv}))
+	require.NoError(t, err)
+	defer dag.Close()
+
+	dep := func(name string) ModuleSourceDependencies {
+		return ModuleSourceDependencies{Kind: "GIT_SOURCE", Name: name, Pin: name + "-pin", Source: "github.com/example/" + name}
+	}
+
+	cfg := Config{Dag: dag, ModuleSourceID: "mod"}
+	deps, err := LoadModuleDependencies(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, []ModuleSourceDependencies{dep("a"), dep("b")}, deps)
+	require.Zero(t, queried.Load())
+
+	cfg.ResolveTransitiveDependencies = true
+	deps, err = LoadModuleDependencies(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, []ModuleSourceDependencies{dep("a"), dep("b"), dep("shared")}, deps)
+	require.Equal(t, int32(3), queried.Load())
+}
+
+func TestIsCacheableField(t *testing.T) {
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Container", "fields": [
+					{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ContainerID"}}},
+					{"name": "digest", "type": {"kind": "SCALAR", "name": "String"}, "directives": [{"name": "cacheable", "args": []}]},
+					{"name": "entrypoint", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "String"}}},
+					{"name": "platform", "type": {"kind": "ENUM", "name": "Platform"}},
+					{"name": "stdout", "type": {"kind": "SCALAR", "name": "String"}},
+					{"name": "sync", "type": {"kind": "SCALAR", "name": "Void"}},
+					{"name": "parent", "type": {"kind": "OBJECT", "name": "Container"}, "directives": [{"name": "cacheable", "args": []}]}
+				]}
+			]
+		}
+	}`), &resp))
+
+	field := func(name string) *introspection.Field {
+		for _, f := range resp.Schema.Types.Get("Container").Fields {
+			if f.Name == name {
+				return f
+			}
+		}
+		t.Fatalf("no field Container.%s", name)
+		return nil
+	}
+	patterns := []string{"*.id", "Container.entrypoint", "Container.platform", "*.sync"}
+	require.True(t, IsCacheableField("Container", field("id"), patterns))
+	require.True(t, IsCacheableField("Container", field("digest"), nil))
+	require.True(t, IsCacheableField("Container", field("entrypoint"), patterns))
+	require.True(t, IsCacheableField("Container", field("platform"), patterns))
+	require.False(t, IsCacheableField("Container", field("stdout"), patterns))
+	// not fetched by a request
+	require.False(t, IsCacheableField("Container", field("sync"), patterns))
+	require.False(t, IsCacheableField("Container", field("parent"), patterns))
+}
+
+func TestConfigGeneratedFileName(t *testing.T) {
+	require.Equal(t, "dag/dag.gen.go", Config{}.GeneratedFileName("dag/dag.gen.go"))
+	cfg := Config{GeneratedFileSuffix: ".generated"}
+	require.Equal(t, "dag/dag.generated.go", cfg.GeneratedFileName("dag/dag.gen.go"))
+	require.Equal(t, "client.generated.mock.ts", cfg.GeneratedFileName("client.gen.mock.ts"))
+	require.Equal(t, "main.go", cfg.GeneratedFileName("main.go"))
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	cfg := Config{
+		Lang:           SDKLangGo,
+		ModuleName:     "test",
+		ImportRewrites: map[string]string{"a": "b", "c": "d"},
+	}
+	same := Config{
+		Lang:           SDKLangGo,
+		ModuleName:     "test",
+		ImportRewrites: map[string]string{"c": "d", "a": "b"},
+		Dag:            &dagger.Client{},
+		Logs:           io.Discard,
+	}
+	require.Equal(t, cfg.Fingerprint(), same.Fingerprint())
+	require.Len(t, cfg.Fingerprint(), 64)
+
+	// every field but the client and the logs changes the fingerprint
+	v := reflect.ValueOf(&cfg).Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Name == "Dag" || field.Name == "Logs" {
+			continue
+		}
+		t.Run(field.Name, func(t *testing.T) {
+			changed := cfg
+			setNonZero(t, reflect.ValueOf(&changed).Elem().Field(i))
+			require.NotEqual(t, cfg.Fingerprint(), changed.Fingerprint())
+		})
+	}
+}
+
+// setNonZero sets a value different from the one of the test config.
+func setNonZero(t *testing.T, v reflect.Value) {
+	t.Helper()
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.String:
+		v.SetString(v.String() + "x")
+	case reflect.Int, reflect.Int64:
+		v.SetInt(80)
+	case reflect.Slice:
+		elem := reflect.New(v.Type().Elem()).Elem()
+		setNonZero(t, elem)
+		v.Set(reflect.Append(v, elem))
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		setNonZero(t, elem)
+		m.SetMapIndex(reflect.ValueOf("x"), elem)
+		v.Set(m)
+	case reflect.Struct:
+		setNonZero(t, v.Field(0))
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+	case reflect.Func:
+		v.Set(reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+			return args
+		}))
+	default:
+		t.Fatalf("unhandled field kind %s", v.Kind())
+	}
+}
+
+func TestManifest(t *testing.T) {
+	outputDir := t.TempDir()
+	overlay := fstest.MapFS{
+		"dagger.gen.go":  &fstest.MapFile{Data: []byte("package dagger\n")},
+		"sub/foo.gen.go": &fstest.MapFile{Data: []byte("package sub\n")},
+	}
+	require.NoError(t, Overlay(context.Background(), io.Discard, overlay, outputDir, OverlayOptions{}))
+
+	manifest, err := NewManifest(outputDir, overlay)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		// sha256 of the contents
+		"dagger.gen.go":  "0b6bb7b39628a2e1a0daffcf84e38732a04a99eec90742f4e470405d04bdbde6",
+		"sub/foo.gen.go": "0f8a30f26053fb832032c5006ccc5646189b16e5f881e36170a813968226becd",
+	}, manifest.Files)
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, WriteManifest(path, manifest))
+	first, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, WriteManifest(path, manifest))
+	second, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	read, err := ReadManifest(path)
+	require.NoError(t, err)
+	require.Equal(t, manifest, read)
+	require.NotContains(t, string(first), "provenance")
+
+	manifest.Provenance = NewProvenance("v0.18.10", outputDir)
+	require.Equal(t, "v0.18.10", manifest.Provenance.EngineVersion)
+	require.NotEmpty(t, manifest.Provenance.GeneratorVersion)
+	require.WithinDuration(t, time.Now(), manifest.Provenance.Timestamp, time.Minute)
+	require.NoError(t, WriteManifest(path, manifest))
+	dt, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(dt), `"provenance": {`)
+	require.Contains(t, string(dt), `"engineVersion": "v0.18.10"`)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	want := Config{
+		Lang:              SDKLangGo,
+		OutputDir:         "./dagger",
+		ClientOnly:        true,
+		DocCommentWrap:    80,
+		ImportRewrites:    map[string]string{"dagger.io/dagger": "example.com/dagger"},
+		UserRegionMarkers: UserRegionMarkers{Start: "start", End: "end"},
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		cfg, err := LoadConfig(write("codegen.yaml", `
+lang: go
+outputDir: ./dagger
+clientOnly: true
+docCommentWrap: 80
+importRewrites:
+  dagger.io/dagger: example.com/dagger
+userRegionMarkers:
+  start: start
+  end: end
+`))
+		require.NoError(t, err)
+		require.Equal(t, want, cfg)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cfg, err := LoadConfig(write("codegen.json", `{
+			"lang": "go",
+			"outputDir": "./dagger",
+			"clientOnly": true,
+			"docCommentWrap": 80,
+			"importRewrites": {"dagger.io/dagger": "example.com/dagger"},
+			"userRegionMarkers": {"start": "start", "end": "end"}
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, want, cfg)
+	})
+
+	t.Run("durations", func(t *testing.T) {
+		cfg, err := LoadConfig(write("durations.yaml", "clientRetryBackoff: 100ms\ndefaultOperationTimeout: 90000000000\n"))
+		require.NoError(t, err)
+		require.Equal(t, 100*time.Millisecond, cfg.ClientRetryBackoff)
+		require.Equal(t, 90*time.Second, cfg.DefaultOperationTimeout)
+
+		cfg, err = LoadConfig(write("durations.json", `{"clientRetryBackoff": "1m30s"}`))
+		require.NoError(t, err)
+		require.Equal(t, 90*time.Second, cfg.ClientRetryBackoff)
+
+		_, err = LoadConfig(write("invalid-duration.yaml", "clientRetryBackoff: soon\n"))
+		require.ErrorContains(t, err, `clientRetryBackoff: time: invalid duration "soon"`)
+	})
+
+	t.Run("base", func(t *testing.T) {
+		cfg, err := ReadConfig(write("base.yaml", "clientOnly: true\n"), Config{Lang: SDKLangGo, OutputDir: "."})
+		require.NoError(t, err)
+		require.Equal(t, Config{Lang: SDKLangGo, OutputDir: ".", ClientOnly: true}, cfg)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := LoadConfig(write("typo.yaml", "lang: go\nouptutDir: ./dagger\n"))
+		require.ErrorContains(t, err, `unknown field "ouptutDir"`)
+
+		_, err = LoadConfig(write("dag.json", `{"dag": {}}`))
+		require.ErrorContains(t, err, `unknown field "dag"`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := LoadConfig(write("invalid.yaml", "introspectionJSON: '{}'\nschema:\n  types: []\n"))
+		require.ErrorContains(t, err, "only one of schema and introspection json can be set")
+
+		_, err = LoadConfig(write("codegen.toml", ""))
+		require.ErrorContains(t, err, `unsupported config format ".toml"`)
+	})
+}
+
+func TestIntrospectHTTP(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("schema", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			var req struct {
+				Query         string `json:"query"`
+				OperationName string `json:"operationName"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "IntrospectionQuery", req.OperationName)
+			require.Contains(t, req.Query, "__schema")
+			require.NotContains(t, req.Query, "__schemaVersion")
+			// only the fields of the specification are queried: the only
+			// directives are the ones of the schema, not the applied ones
+			require.Equal(t, 1, strings.Count(req.Query, "directives {"), req.Query)
+			require.Contains(t, req.Query, "directives {\n      name\n")
+			require.NotContains(t, req.Query, "DirectiveApplication")
+			// unless asked to, the deprecated arguments and input fields are
+			// left out of the query, which older servers would reject
+			require.NotContains(t, req.Query, "args(includeDeprecated")
+			require.NotContains(t, req.Query, "inputFields(includeDeprecated")
+			_, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+			require.NoError(t, err)
+
+			io.WriteString(w, `{"data":{"__schema":{"queryType":{"name":"Query"},"types":[{"kind":"OBJECT","name":"Query"}]}}}`)
+		}))
+		defer srv.Close()
+
+		schema, version, err := IntrospectHTTP(ctx, srv.URL, http.Header{"Authorization": {"Bearer token"}}, false)
+		require.NoError(t, err)
+		require.Empty(t, version)
+		require.Equal(t, "Query", schema.QueryType.Name)
+		require.NotNil(t, schema.Query())
+	})
+
+	t.Run("include deprecated", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Query string `json:"query"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			// the arguments of the fields and directives, and the input fields
+			require.Equal(t, 2, strings.Count(req.Query, "args(includeDeprecated: true) {"), req.Query)
+			require.Equal(t, 1, strings.Count(req.Query, "inputFields(includeDeprecated: true) {"), req.Query)
+			_, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+			require.NoError(t, err)
+
+			io.WriteString(w, `{"data":{"__schema":{"queryType":{"name":"Query"},"types":[{"kind":"OBJECT","name":"Query"}]}}}`)
+		}))
+		defer srv.Close()
+
+		_, _, err := IntrospectHTTP(ctx, srv.URL, nil, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("graphql errors", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"errors":[{"message":"introspection is disabled"}]}`)
+		}))
+		defer srv.Close()
+
+		_, _, err := IntrospectHTTP(ctx, srv.URL, nil, false)
+		require.ErrorContains(t, err, "introspection is disabled")
+	})
+
+	t.Run("status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		_, _, err := IntrospectHTTP(ctx, srv.URL, nil, false)
+		require.ErrorContains(t, err, "401 Unauthorized: missing token")
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		done := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}))
+		defer srv.Close()
+		defer close(done)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, _, err := IntrospectHTTP(ctx, srv.URL, nil, false)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
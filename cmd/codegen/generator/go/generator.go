@@ -10,11 +10,13 @@ import (
 	"go/parser"
 	"go/token"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -40,6 +42,10 @@ const (
 
 	// StarterTemplateFile is the path to write the default module code
 	StarterTemplateFile = "main.go"
+
+	// StarterTestFile is the path to write the test of the default module
+	// code, with Config.ScaffoldTests
+	StarterTestFile = "main_test.go"
 )
 
 var goVersion = strings.TrimPrefix(runtime.Version(), "go")
@@ -48,6 +54,12 @@ type GoGenerator struct {
 	Config generator.Config
 }
 
+func init() {
+	generator.Register(generator.SDKLangGo, func(cfg generator.Config) generator.Generator {
+		return &GoGenerator{Config: cfg}
+	})
+}
+
 func (g *GoGenerator) GenerateModule(ctx context.Context, schema *introspection.Schema, schemaVersion string) (*generator.GeneratedState, error) {
 	generator.SetSchema(schema)
 
@@ -57,10 +69,15 @@ func (g *GoGenerator) GenerateModule(ctx context.Context, schema *introspection.
 	//  2b. add stub main.go
 	// 3. load package, generate dagger.gen.go (possibly again)
 
-	outDir := "."
+	layout := generator.Layout{SourceDir: ".", GeneratedDir: ".", Entrypoint: StarterTemplateFile}
 	if g.Config.ModuleName != "" {
-		outDir = filepath.Clean(g.Config.ModuleSourcePath)
+		var err error
+		layout, err = g.ModuleLayout(g.Config)
+		if err != nil {
+			return nil, err
+		}
 	}
+	outDir := layout.SourceDir
 
 	mfs := memfs.New()
 
@@ -95,7 +112,7 @@ func (g *GoGenerator) GenerateModule(ctx context.Context, schema *introspection.
 		return nil, fmt.Errorf("glob go files: %w", err)
 	}
 
-	genFile := filepath.Join(g.Config.OutputDir, outDir, ClientGenFile)
+	genFile := filepath.Join(g.Config.OutputDir, layout.GeneratedDir, g.Config.GeneratedFileName(ClientGenFile))
 	if _, err := os.Stat(genFile); err != nil {
 		// assume package main, default for modules
 		pkgInfo.PackageName = "main"
@@ -116,6 +133,12 @@ func (g *GoGenerator) GenerateModule(ctx context.Context, schema *introspection.
 
 		// main.go is actually an input to codegen, so this requires another pass
 		partial = true
+
+		if g.Config.IsInit && g.Config.ScaffoldTests {
+			if err := mfs.WriteFile(StarterTestFile, []byte(g.baseModuleTest()), 0600); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if partial {
@@ -138,6 +161,21 @@ func (g *GoGenerator) GenerateModule(ctx context.Context, schema *introspection.
 	return genSt, nil
 }
 
+// ModuleLayout returns the layout of a Go module: the generated client and
+// the main package are at the root of the module source, which is a Go
+// package of the Go module it merges with, or of its own.
+func (g *GoGenerator) ModuleLayout(cfg generator.Config) (generator.Layout, error) {
+	dir, err := generator.ModuleSourceDir(cfg)
+	if err != nil {
+		return generator.Layout{}, err
+	}
+	return generator.Layout{
+		SourceDir:    dir,
+		GeneratedDir: dir,
+		Entrypoint:   filepath.Join(dir, StarterTemplateFile),
+	}, nil
+}
+
 func (g *GoGenerator) daggerPackageReplacement() (string, bool, error) {
 	goModFile, err := os.ReadFile("go.mod")
 	if err != nil {
@@ -193,8 +231,9 @@ func (g *GoGenerator) GenerateClient(ctx context.Context, schema *introspection.
 		return nil, fmt.Errorf("failed to check if dagger.io/dagger package is replaced: %w", err)
 	}
 
-	// If dagger.io/dagger package is replaced, we need to add the SDK locally
-	if replaced {
+	// If dagger.io/dagger package is replaced, we need to add the SDK locally,
+	// unless the generated code doesn't depend on it
+	if replaced && !g.Config.Standalone {
 		layers = append(
 			layers,
 			&MountedFS{FS: dagger.GoSDK, Name: replacedPath},
@@ -234,6 +273,18 @@ func (g *GoGenerator) GenerateClient(ctx context.Context, schema *introspection.
 	return genSt, nil
 }
 
+// GenerateClientFS renders the standalone client of the schema as the package
+// pkgInfo, and returns its files. Unlike GenerateClient, it generates neither
+// the SDK nor the post commands, and doesn't load the package from disk.
+func GenerateClientFS(ctx context.Context, cfg generator.Config, schema *introspection.Schema, schemaVersion string, pkgInfo PackageInfo) (fs.FS, error) {
+	generator.SetSchema(schema)
+	mfs := memfs.New()
+	if err := generateCode(ctx, cfg, schema, schemaVersion, mfs, &pkgInfo, nil, nil, 1); err != nil {
+		return nil, fmt.Errorf("generate code: %w", err)
+	}
+	return mfs, nil
+}
+
 type PackageInfo struct {
 	PackageName   string // Go package name, typically "main"
 	PackageImport string // import path of package in which this file appears
@@ -278,12 +329,22 @@ func (g *GoGenerator) bootstrapMod(ctx context.Context, mfs *memfs.FS, genSt *ge
 	// will want to have a runtime module that lives in the same Go module as
 	// the generated client, which typically lives in the parent directory.
 	if goMod == nil && g.Config.Merge {
-		if content, err := os.ReadFile(filepath.Join(g.Config.OutputDir, "go.mod")); err == nil {
-			daggerModPath = "."
+		parentModPath, err := findParentGoMod(g.Config.OutputDir, g.Config.ModuleSourcePath, g.Config.GoModSearchDepth)
+		if err != nil {
+			return nil, false, err
+		}
+		if parentModPath != "" {
+			content, err := os.ReadFile(filepath.Join(g.Config.OutputDir, parentModPath, "go.mod"))
+			if err != nil {
+				return nil, false, fmt.Errorf("read go.mod: %w", err)
+			}
+			daggerModPath = parentModPath
 			goMod, err = modfile.ParseLax("go.mod", content, nil)
 			if err != nil {
 				return nil, false, fmt.Errorf("parse go.mod: %w", err)
 			}
+			g.Config.Logf("merging module %s with go module %s of %s",
+				g.Config.ModuleName, goMod.Module.Mod.Path, filepath.Join(parentModPath, "go.mod"))
 		}
 	}
 	// could not find a go.mod, so we can init a basic one
@@ -345,6 +406,49 @@ func (g *GoGenerator) bootstrapMod(ctx context.Context, mfs *memfs.FS, genSt *ge
 	}, needsRegen, nil
 }
 
+// findParentGoMod returns the directory, relative to the output directory, of
+// the go.mod of a parent directory of the module source, or "" if there's
+// none. The parent directories are searched from the closest one up to the
+// output directory, at most depth of them if it's positive. It fails if
+// several of them have a go.mod, since the one to merge with is ambiguous in
+// nested Go modules.
+func findParentGoMod(outputDir, moduleSourcePath string, depth int) (string, error) {
+	// a module source out of the output directory can only merge with the
+	// output directory
+	dirs := []string{"."}
+	if dir := filepath.Clean(moduleSourcePath); filepath.IsLocal(dir) {
+		dirs = nil
+		for dir != "." && (depth <= 0 || len(dirs) < depth) {
+			dir = filepath.Dir(dir)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var found []string
+	for _, dir := range dirs {
+		_, err := os.Stat(filepath.Join(outputDir, dir, "go.mod"))
+		if err == nil {
+			found = append(found, dir)
+			continue
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("stat go.mod: %w", err)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	goMods := make([]string, len(found))
+	for i, dir := range found {
+		goMods[i] = filepath.Join(dir, "go.mod")
+	}
+	return "", fmt.Errorf("several go.mod to merge module source %s with: %s, set a go.mod in the module source or a lower search depth",
+		moduleSourcePath, strings.Join(goMods, ", "))
+}
+
 func (g *GoGenerator) syncModReplaceAndTidy(mod *modfile.File, genSt *generator.GeneratedState, modPath string) error {
 	modDir := filepath.Join(g.Config.OutputDir, modPath)
 
@@ -415,11 +519,34 @@ func generateCode(
 	fset *token.FileSet,
 	pass int,
 ) error {
+	if cfg.SeparatePreview {
+		directive := cfg.PreviewDirective
+		if directive == "" {
+			directive = generator.DefaultPreviewDirective
+		}
+		if err := generator.SeparatePreviewFields(schema, directive, cfg.PreviewFieldPrefix); err != nil {
+			return fmt.Errorf("separate preview fields: %w", err)
+		}
+	}
+
+	if cfg.TypedIDs {
+		if err := generator.TypeIDs(schema); err != nil {
+			return fmt.Errorf("type ids: %w", err)
+		}
+	}
+
+	if cfg.NameTransform != nil {
+		if err := generator.CheckNameCollisions(schema, templates.NameFormatter(cfg)); err != nil {
+			return err
+		}
+	}
+
 	funcs := templates.GoTemplateFuncs(ctx, schema, schemaVersion, cfg, pkg, fset, pass)
 	tmpls := templates.Templates(funcs)
 
+	types := withSerializedScalars(schema, schema.Visit(), cfg.ScalarSerializers)
 	for k, tmpl := range tmpls {
-		dt, err := renderFile(cfg, schema, schemaVersion, pkgInfo, tmpl)
+		dt, err := renderFile(cfg, schema, schemaVersion, pkgInfo, tmpl, types)
 		if err != nil {
 			return err
 		}
@@ -427,23 +554,160 @@ func generateCode(
 			// no contents, skip
 			continue
 		}
-		if err := mfs.MkdirAll(filepath.Dir(k), 0o755); err != nil {
+		path := cfg.GeneratedFileName(k)
+		if err := mfs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 			return err
 		}
-		if err := mfs.WriteFile(k, dt, 0600); err != nil {
+		if err := mfs.WriteFile(path, dt, 0600); err != nil {
 			return err
 		}
 	}
 
+	if err := generatePlatformFiles(cfg, schema, schemaVersion, mfs, pkgInfo, tmpls[ClientGenFile], types); err != nil {
+		return err
+	}
+
+	if cfg.SplitByType {
+		return generateTypeFiles(cfg, schema, schemaVersion, mfs, pkgInfo, tmpls[ClientGenFile], types)
+	}
+
 	return nil
 }
 
+// withSerializedScalars adds the scalars with a serializer skipped by the
+// visitor as built-in ones, e.g. DateTime, before the types, since their
+// serializer needs a type to generate.
+func withSerializedScalars(schema *introspection.Schema, types []*introspection.Type, serializers map[string]generator.ScalarSerializer) []*introspection.Type {
+	var scalars []*introspection.Type
+	for _, name := range slices.Sorted(maps.Keys(serializers)) {
+		t := schema.Types.Get(name)
+		if t == nil || t.Kind != introspection.TypeKindScalar || slices.Contains(types, t) {
+			continue
+		}
+		scalars = append(scalars, t)
+	}
+	return append(scalars, types...)
+}
+
+// generateTypeFiles writes each object type in its own file, next to the file
+// of the rest of the types.
+func generateTypeFiles(
+	cfg generator.Config,
+	schema *introspection.Schema,
+	schemaVersion string,
+	mfs *memfs.FS,
+	pkgInfo *PackageInfo,
+	tmpl *template.Template,
+	types []*introspection.Type,
+) error {
+	dir := "."
+	if cfg.ModuleName != "" {
+		dir = filepath.Join("internal", "dagger")
+	}
+	tmpl = tmpl.Lookup("_dagger.gen.go/object.gen.go.tmpl")
+
+	var changed map[string]bool
+	if len(cfg.ChangedTypes) > 0 {
+		var err error
+		changed, err = generator.DependentTypes(schema, cfg.ChangedTypes)
+		if err != nil {
+			return fmt.Errorf("changed types: %w", err)
+		}
+	}
+
+	formatName := templates.NameFormatter(cfg)
+	// the main file is named after the package
+	seen := map[string]bool{"dagger": true}
+	for _, t := range types {
+		if t.Kind != introspection.TypeKindObject {
+			continue
+		}
+		name := typeFileName(formatName(t.Name), seen)
+		if changed != nil && !changed[t.Name] {
+			// still named, so that the names of the files don't depend on
+			// which types changed
+			continue
+		}
+
+		dt, err := renderFile(cfg, schema, schemaVersion, pkgInfo, tmpl, []*introspection.Type{t})
+		if err != nil {
+			return fmt.Errorf("generate %s: %w", t.Name, err)
+		}
+		if err := mfs.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := mfs.WriteFile(filepath.Join(dir, cfg.GeneratedFileName(name)), dt, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goPlatformFiles are the files of the platform-specific helpers of the
+// client, with Config.GoPlatformSplit: their template and the build
+// constraint restricting them to the platform. Their names end in `.gen.go`,
+// not in a GOOS suffix, so the constraint is the only restriction.
+var goPlatformFiles = []struct {
+	name       string
+	template   string
+	constraint string
+}{
+	{"platform_linux.gen.go", "_platform/linux.go.tmpl", "linux"},
+	{"platform_windows.gen.go", "_platform/windows.go.tmpl", "windows"},
+	{"platform_other.gen.go", "_platform/other.go.tmpl", "!linux && !windows"},
+}
+
+// generatePlatformFiles writes the files of the platform-specific helpers of
+// the client, each with the build constraint of its platform.
+func generatePlatformFiles(
+	cfg generator.Config,
+	schema *introspection.Schema,
+	schemaVersion string,
+	mfs *memfs.FS,
+	pkgInfo *PackageInfo,
+	tmpl *template.Template,
+	types []*introspection.Type,
+) error {
+	for _, file := range goPlatformFiles {
+		dt, err := renderFile(cfg, schema, schemaVersion, pkgInfo, tmpl.Lookup(file.template), types)
+		if err != nil {
+			return fmt.Errorf("generate %s: %w", file.name, err)
+		}
+		if dt == nil {
+			// not split
+			continue
+		}
+		dt = append([]byte("//go:build "+file.constraint+"\n\n"), dt...)
+		if err := mfs.WriteFile(cfg.GeneratedFileName(file.name), dt, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeFileName returns the name of the file of a type with the given Go name,
+// e.g. `container.gen.go` for `Container`.
+// The name is lowercased, so that it doesn't depend on the case sensitivity of
+// the file system, and has no underscore, which could make it a build
+// constraint (e.g. `_linux`). A number is appended to the names already seen,
+// in order of the types.
+func typeFileName(name string, seen map[string]bool) string {
+	base := strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	file := base
+	for i := 2; seen[file]; i++ {
+		file = fmt.Sprintf("%s%d", base, i)
+	}
+	seen[file] = true
+	return file + ".gen.go"
+}
+
 func renderFile(
 	cfg generator.Config,
 	schema *introspection.Schema,
 	schemaVersion string,
 	pkgInfo *PackageInfo,
 	tmpl *template.Template,
+	types []*introspection.Type,
 ) ([]byte, error) {
 	data := struct {
 		*PackageInfo
@@ -454,7 +718,7 @@ func renderFile(
 		PackageInfo:   pkgInfo,
 		Schema:        schema,
 		SchemaVersion: schemaVersion,
-		Types:         schema.Visit(),
+		Types:         types,
 	}
 
 	var render bytes.Buffer
@@ -468,19 +732,38 @@ func renderFile(
 		return nil, nil
 	}
 
-	formatted, err := format.Source(source)
-	if err != nil {
-		os.Stderr.Write(source)
-		return nil, fmt.Errorf("error formatting generated code: %w", err)
+	formatted := append(source, '\n')
+	if !cfg.SkipGoFormat {
+		var err error
+		formatted, err = format.Source(source)
+		if err != nil {
+			os.Stderr.Write(source)
+			return nil, fmt.Errorf("error formatting generated code: %w", err)
+		}
+		formatted, err = imports.Process(filepath.Join(cfg.OutputDir, "dummy.go"), formatted, nil)
+		if err != nil {
+			os.Stderr.Write(source)
+			return nil, fmt.Errorf("error processing imports in generated code: %w", err)
+		}
 	}
-	formatted, err = imports.Process(filepath.Join(cfg.OutputDir, "dummy.go"), formatted, nil)
-	if err != nil {
-		os.Stderr.Write(source)
-		return nil, fmt.Errorf("error processing imports in generated code: %w", err)
+	if constraint := goBuildConstraint(cfg.GoTarget); constraint != "" {
+		formatted = append([]byte("//go:build "+constraint+"\n\n"), formatted...)
 	}
 	return formatted, nil
 }
 
+// goBuildConstraint returns the build constraint of the files generated for a
+// Go target, empty for the native ones.
+func goBuildConstraint(target string) string {
+	switch target {
+	case generator.GoTargetWasm:
+		return "wasm"
+	case generator.GoTargetTinyGo:
+		return "tinygo"
+	}
+	return ""
+}
+
 func loadPackage(ctx context.Context, dir string) (_ *packages.Package, _ *token.FileSet, rerr error) {
 	ctx, span := trace.Tracer().Start(ctx, "loadPackage")
 	defer telemetry.End(span, func() error { return rerr })
@@ -576,6 +859,27 @@ func (m *%[1]s) GrepDir(ctx context.Context, directoryArg *dagger.Directory, pat
 `, moduleStructName, pkgInfo.PackageImport)
 }
 
+func (g *GoGenerator) baseModuleTest() string {
+	moduleStructName := strcase.ToCamel(g.Config.ModuleName)
+
+	return fmt.Sprintf(`package main
+
+// The tests of the module call the Dagger API, so they must run in a Dagger
+// session, e.g. with:
+//
+//	dagger run go test ./...
+
+import "testing"
+
+func TestContainerEcho(t *testing.T) {
+	ctr := (&%[1]s{}).ContainerEcho("hello")
+	if ctr == nil {
+		t.Fatal("ContainerEcho returned no container")
+	}
+}
+`, moduleStructName)
+}
+
 func goEnv(dir string, env string) (string, error) {
 	buf := new(bytes.Buffer)
 	findGoWork := exec.Command("go", "env", env)
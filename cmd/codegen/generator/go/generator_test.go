@@ -0,0 +1,975 @@
+package gogenerator
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/psanford/memfs"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/imports"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/templates"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// loadFixture reads an introspection response from testdata.
+func loadFixture(t *testing.T, name string) (*introspection.Schema, string) {
+	t.Helper()
+	return testutil.LoadFixture(t, filepath.Join("testdata", name))
+}
+
+// generateFixture renders the standalone client for the given fixture and
+// returns the generated files.
+func generateFixture(t *testing.T, cfg generator.Config, name string) *memfs.FS {
+	t.Helper()
+	schema, schemaVersion := loadFixture(t, name)
+	return generateSchema(t, cfg, schema, schemaVersion)
+}
+
+// generateSchema renders the standalone client for the given schema, loaded
+// by testutil.LoadSchema, and returns the generated files. The client is
+// generated as the dagger package of the modules of testutil.WritePackage.
+func generateSchema(t *testing.T, cfg generator.Config, schema *introspection.Schema, schemaVersion string) *memfs.FS {
+	t.Helper()
+	cfg, schema, schemaVersion = testutil.LoadSchema(t, cfg, schema, schemaVersion)
+	mfs := memfs.New()
+	err := generateCode(context.Background(), cfg, schema, schemaVersion, mfs, &PackageInfo{
+		PackageName:   "dagger",
+		PackageImport: testutil.ModulePath + "/dagger",
+	}, nil, nil, 1)
+	require.NoError(t, err)
+	return mfs
+}
+
+func TestGenerateInterfaces(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{}, "interfaces.json")
+	src := testutil.ReadFile(t, mfs, ClientGenFile)
+
+	require.Contains(t, src, "type Pet interface {")
+	require.Contains(t, src, "Name(ctx context.Context) (string, error)")
+	require.Contains(t, src, "type petImpl struct {")
+	require.Contains(t, src, "func (r *Client) Pet(name string) Pet {")
+	require.Contains(t, src, "func PetAsDog(ctx context.Context, v Pet) (*Dog, bool, error) {")
+	require.Contains(t, src, "func PetAsCat(ctx context.Context, v Pet) (*Cat, bool, error) {")
+	require.Contains(t, src, `if typename != "Dog" {`)
+}
+
+func TestGenerateClientServeDependencies(t *testing.T) {
+	t.Run("serve by default", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "if err := serveModuleDependencies(ctx, c); err != nil {")
+		require.Contains(t, src, "func serveModuleDependencies(ctx context.Context, client *Client) error {")
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		// the transport isn't an engine to serve them to
+		mfs := generateFixture(t, generator.Config{GeneratePluggableTransport: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "func NewClientWithTransport(ctx context.Context, transport Transport) (*Client, error) {")
+		require.Equal(t, 1, strings.Count(src, "if err := serveModuleDependencies(ctx, c); err != nil {"))
+	})
+
+	t.Run("no serve", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "func Connect(ctx context.Context, opts ...dagger.ClientOpt) (*Client, error) {")
+		require.NotContains(t, src, "serveModuleDependencies")
+	})
+}
+
+func TestGoFormat(t *testing.T) {
+	cfg := generator.Config{GenerateMocks: true, SplitByType: true}
+	mfs := generateFixture(t, cfg, "basic.json")
+	var files []string
+	require.NoError(t, fs.WalkDir(mfs, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return err
+	}))
+	require.Contains(t, files, ClientGenFile)
+	require.Contains(t, files, "container.gen.go")
+
+	// the files are written as gofmt and goimports would format them
+	for _, path := range files {
+		src := testutil.ReadFile(t, mfs, path)
+		formatted, err := format.Source([]byte(src))
+		require.NoError(t, err, path)
+		require.Equal(t, string(formatted), src, path)
+		formatted, err = imports.Process(path, []byte(src), nil)
+		require.NoError(t, err, path)
+		require.Equal(t, string(formatted), src, path)
+	}
+
+	cfg.SkipGoFormat = true
+	src := testutil.ReadFile(t, generateFixture(t, cfg, "basic.json"), ClientGenFile)
+	formatted, err := format.Source([]byte(src))
+	require.NoError(t, err)
+	require.NotEqual(t, string(formatted), src)
+}
+
+func TestGenerateTypesOnly(t *testing.T) {
+	for _, fixture := range []string{"basic.json", "interfaces.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			mfs := generateFixture(t, generator.Config{TypesOnly: true, GenerateMocks: true}, fixture)
+			src := testutil.ReadFile(t, mfs, ClientGenFile)
+
+			require.NotContains(t, src, "querybuilder")
+			require.NotContains(t, src, "func Connect(")
+
+			_, err := fs.Stat(mfs, "dag/dag.gen.go")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = fs.Stat(mfs, "mock/mock.gen.go")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+
+			// the types must be self-contained
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, ClientGenFile, src, 0)
+			require.NoError(t, err)
+			_, err = (&types.Config{Importer: importer.Default()}).Check("dagger", fset, []*ast.File{f}, nil)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{TypesOnly: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "type Container struct {")
+		require.Regexp(t, "Stdout +string +`json:\"stdout,omitempty\"`", src)
+		require.Regexp(t, "EnvVariables +\\[\\]EnvVariable +`json:\"envVariables,omitempty\"`", src)
+		require.NotContains(t, src, "WithExec")
+	})
+}
+
+func TestGenerateStandalone(t *testing.T) {
+	for _, fixture := range []string{"basic.json", "interfaces.json", "keywords.json", "pagination.json", "recursive.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			mfs := generateFixture(t, generator.Config{
+				Standalone:                true,
+				SplitByType:               true,
+				GenerateMocks:             true,
+				GenerateInputConstructors: true,
+				GeneratePaginationHelpers: true,
+			}, fixture)
+
+			// the files must make up a package compiling with only the
+			// standard library
+			fset := token.NewFileSet()
+			var files []*ast.File
+			err := fs.WalkDir(mfs, ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				src := testutil.ReadFile(t, mfs, path)
+				require.NotContains(t, src, "dagger.io", path)
+				f, err := parser.ParseFile(fset, path, src, 0)
+				require.NoError(t, err)
+				files = append(files, f)
+				return nil
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, files)
+			_, err = (&types.Config{Importer: importer.Default()}).Check("dagger", fset, files, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateArgValidation(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateArgValidation: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, `func (r *Container) From(address string) *Container {
+	q := r.query.Select("from")
+	if address == "" {
+		q = q.WithError(errors.New("Container.From: required argument \"address\" is empty"))
+	}
+`)
+		require.Contains(t, src, `	q := r.query.Select("envVariable")
+	if name == "" {
+		return "", errors.New("Container.EnvVariable: required argument \"name\" is empty")
+	}
+`)
+	})
+
+	t.Run("no validation", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.NotContains(t, src, "is empty")
+	})
+}
+
+func TestGenerateNameTransform(t *testing.T) {
+	// house style casing acronyms like words, e.g. `ContainerId`
+	transform := func(s string) string {
+		s = strings.ToUpper(s[:1]) + s[1:]
+		return strings.ReplaceAll(s, "ID", "Id")
+	}
+
+	t.Run("transform", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{NameTransform: transform}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+
+		require.Contains(t, src, "type ContainerId string")
+		require.Contains(t, src, "func (r *Container) Id(ctx context.Context) (ContainerId, error) {")
+		require.Contains(t, src, "func (r *Client) LoadContainerFromId(id ContainerId) *Container {")
+		// the wire names are unchanged
+		require.Contains(t, src, `q := r.query.Select("loadContainerFromID")`)
+		require.Contains(t, src, `q := r.query.Select("id")`)
+
+		f, err := parser.ParseFile(token.NewFileSet(), ClientGenFile, src, 0)
+		require.NoError(t, err)
+		ast.Inspect(f, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && !strings.HasPrefix(id.Name, "XXX_") {
+				require.NotContains(t, id.Name, "ID")
+			}
+			return true
+		})
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		schema, _ := loadFixture(t, "basic.json")
+		singular := func(s string) string {
+			return strings.TrimSuffix(transform(s), "s")
+		}
+		err := generator.CheckNameCollisions(schema, singular)
+		require.ErrorContains(t, err, `name collision in fields of Container: "envVariable" and "envVariables" are both generated as "EnvVariable"`)
+
+		err = generator.CheckNameCollisions(schema, transform)
+		require.NoError(t, err)
+	})
+}
+
+func TestGenerateValidationTags(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateValidationTags: true}, "recursive.json"), ClientGenFile)
+	require.Contains(t, src, "Value string `json:\"value\" validate:\"required\"`")
+	require.Contains(t, src, "Parent *TreeInput `json:\"parent\"`")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateValidationTags: true, ValidationTagKey: "binding"}, "basic.json"), ClientGenFile)
+	// the zero value of an Int is valid
+	require.Contains(t, src, "Backend int `json:\"backend\"`")
+	require.Contains(t, src, "Protocol NetworkProtocol `json:\"protocol,omitempty\" binding:\"omitempty,oneof=TCP UDP\"`")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{}, "recursive.json"), ClientGenFile)
+	require.NotContains(t, src, "validate:")
+}
+
+func TestGenerateInputConstructors(t *testing.T) {
+	t.Run("constructors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateInputConstructors: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		// frontend is nullable and protocol has a default, both stay optional
+		require.Contains(t, src, `func NewPortForward(backend int) PortForward {
+	return PortForward{
+		Backend: backend,
+	}
+}`)
+	})
+
+	t.Run("no constructors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.NotContains(t, src, "func NewPortForward(")
+	})
+}
+
+func TestGenerateHiddenTypes(t *testing.T) {
+	t.Run("hidden", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{HiddenTypePrefixes: []string{"_", "Internal"}}, "hidden.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "func (r *Container) Stdout(ctx context.Context) (string, error) {")
+		require.NotContains(t, src, "EngineState")
+		require.NotContains(t, src, "InternalCacheKey")
+	})
+
+	t.Run("visible", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "hidden.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "func (r *Container) InternalCacheKey() *InternalCacheKey {")
+	})
+}
+
+func TestGenerateReuseConnection(t *testing.T) {
+	t.Run("reuse", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{ReuseConnection: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "func NewClient(ctx context.Context, client graphql.Client) (*Client, error) {")
+		require.Contains(t, src, "c := &Client{client: client}")
+		require.Contains(t, src, `	if c.dag == nil {
+		// the connection is owned by the caller of NewClient
+		return nil
+	}`)
+	})
+
+	t.Run("no reuse", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.NotContains(t, src, "func NewClient(")
+		require.NotContains(t, src, "c.dag == nil")
+	})
+}
+
+func TestGenerateStableOutput(t *testing.T) {
+	cfg := generator.Config{GenerateMocks: true}
+	for _, fixture := range []string{"basic.json", "interfaces.json", "pagination.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			want := testutil.ReadFile(t, generateFixture(t, cfg, fixture), ClientGenFile)
+			wantMock := testutil.ReadFile(t, generateFixture(t, cfg, fixture), "mock/mock.gen.go")
+
+			// the same schema listed in another order generates the same code
+			schema, schemaVersion := loadFixture(t, fixture)
+			slices.Reverse(schema.Types)
+			for _, t := range schema.Types {
+				slices.Reverse(t.Fields)
+				slices.Reverse(t.InputFields)
+				slices.Reverse(t.EnumValues)
+				slices.Reverse(t.Interfaces)
+				slices.Reverse(t.PossibleTypes)
+			}
+			mfs := generateSchema(t, cfg, schema, schemaVersion)
+			require.Equal(t, want, testutil.ReadFile(t, mfs, ClientGenFile))
+			require.Equal(t, wantMock, testutil.ReadFile(t, mfs, "mock/mock.gen.go"))
+		})
+	}
+}
+
+func TestGenerateRequestHooks(t *testing.T) {
+	t.Run("hooks", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateRequestHooks: true, ReuseConnection: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.Contains(t, src, "OnRequest func(query string)")
+		// both constructors share the wrappers
+		require.Equal(t, 1, strings.Count(src, "\tc.hookRequests()\n"))
+		require.Equal(t, 2, strings.Count(src, "\tc.decorate()\n"))
+		require.Equal(t, 1, strings.Count(src, "c.query = querybuilder.Query().Client(c.client)"))
+		require.Contains(t, src, `	if hook := c.hooks.OnRequest; hook != nil {
+		hook(req.Query)
+	}
+	return c.Client.MakeRequest(ctx, req, resp)`)
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+		require.NotContains(t, src, "OnRequest")
+	})
+}
+
+func TestGenerateSplitByType(t *testing.T) {
+	t.Run("split", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SplitByType: true}, "basic.json")
+		require.NotContains(t, testutil.ReadFile(t, mfs, ClientGenFile), "type Container struct {")
+
+		for name, decl := range map[string]string{
+			"client.gen.go":      "func (r *Client) Container(",
+			"container.gen.go":   "type Container struct {",
+			"envvariable.gen.go": "type EnvVariable struct {",
+		} {
+			src := testutil.ReadFile(t, mfs, name)
+			require.True(t, strings.HasPrefix(src, "// Code generated by dagger. DO NOT EDIT.\n\npackage dagger\n"), name)
+			require.Contains(t, src, decl, name)
+		}
+	})
+
+	t.Run("types only", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SplitByType: true, TypesOnly: true}, "basic.json")
+
+		// the files must make up a self-contained package
+		fset := token.NewFileSet()
+		var files []*ast.File
+		for _, name := range []string{ClientGenFile, "client.gen.go", "container.gen.go", "envvariable.gen.go"} {
+			f, err := parser.ParseFile(fset, name, testutil.ReadFile(t, mfs, name), 0)
+			require.NoError(t, err)
+			files = append(files, f)
+		}
+		_, err := (&types.Config{Importer: importer.Default()}).Check("dagger", fset, files, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("changed types", func(t *testing.T) {
+		// Container depends on EnvVariable by its envVariables field, and the
+		// client by its load functions
+		mfs := generateFixture(t, generator.Config{SplitByType: true, ChangedTypes: []string{"Container"}}, "basic.json")
+		for _, name := range []string{ClientGenFile, "client.gen.go", "container.gen.go"} {
+			_, err := fs.Stat(mfs, name)
+			require.NoError(t, err, name)
+		}
+		_, err := fs.Stat(mfs, "envvariable.gen.go")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+
+		mfs = generateFixture(t, generator.Config{SplitByType: true, ChangedTypes: []string{"EnvVariable"}}, "basic.json")
+		for _, name := range []string{"client.gen.go", "container.gen.go", "envvariable.gen.go"} {
+			_, err := fs.Stat(mfs, name)
+			require.NoError(t, err, name)
+		}
+	})
+
+	t.Run("no split", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		require.Contains(t, testutil.ReadFile(t, mfs, ClientGenFile), "type Container struct {")
+		_, err := fs.Stat(mfs, "container.gen.go")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestTypeFileName(t *testing.T) {
+	seen := map[string]bool{"dagger": true}
+	require.Equal(t, "container.gen.go", typeFileName("Container", seen))
+	require.Equal(t, "httpserver.gen.go", typeFileName("HTTPServer", seen))
+	require.Equal(t, "httpserver2.gen.go", typeFileName("HttpServer", seen))
+	require.Equal(t, "dagger2.gen.go", typeFileName("Dagger", seen))
+}
+
+func TestGenerateSession(t *testing.T) {
+	t.Run("session", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateSession: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, ClientGenFile)
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, ClientGenFile, src, 0)
+		require.NoError(t, err)
+
+		// signatures of the exported methods by receiver
+		methods := map[string]map[string]string{}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+				continue
+			}
+			star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+			if !ok {
+				continue
+			}
+			recv := star.X.(*ast.Ident).Name
+			var sig strings.Builder
+			require.NoError(t, printer.Fprint(&sig, fset, fn.Type))
+			if methods[recv] == nil {
+				methods[recv] = map[string]string{}
+			}
+			methods[recv][fn.Name.Name] = sig.String()
+		}
+
+		schema, _ := loadFixture(t, "basic.json")
+		var root []string
+		for _, field := range schema.Query().Fields {
+			root = append(root, templates.NameFormatter(generator.Config{})(field.Name))
+		}
+		require.ElementsMatch(t, root, slices.Collect(maps.Keys(methods["Session"])))
+		for _, name := range root {
+			require.Equal(t, methods["Client"][name], methods["Session"][name], name)
+		}
+
+		require.Contains(t, src, `func (s *Session) Version(ctx context.Context) (string, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	return s.client.Version(ctx)
+}`)
+	})
+
+	t.Run("no session", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		require.NotContains(t, testutil.ReadFile(t, mfs, ClientGenFile), "Session")
+	})
+}
+
+func TestGenerateKeywords(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{GenerateArgValidation: true, GenerateInputConstructors: true, GenerateMocks: true}, "keywords.json")
+	src := testutil.ReadFile(t, mfs, ClientGenFile)
+
+	// the identifiers are aliased, the queries keep the GraphQL names
+	require.Regexp(t, `\n\ttype_ +\*string\n`, src)
+	require.Contains(t, src, "func (r *Item) Select(case_ string, opts ...ItemSelectOpts) *Item {")
+	require.Contains(t, src, `q = q.Arg("case", case_)`)
+	require.Contains(t, src, `q := r.query.Select("type")`)
+	require.Contains(t, src, "if package_ == \"\" {")
+	require.Contains(t, src, "func NewFilter(type_ string) Filter {")
+	require.Regexp(t, `Type: +type_,`, src)
+
+	require.Contains(t, testutil.ReadFile(t, mfs, "dag/dag.gen.go"), "return client.Item(type_, opts...)")
+	require.Contains(t, testutil.ReadFile(t, mfs, "mock/mock.gen.go"), "return m.SelectFunc(case_, opts...)")
+}
+
+func TestGenerateJSONPackage(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		src := testutil.ReadFile(t, generateFixture(t, generator.Config{}, "basic.json"), ClientGenFile)
+		require.Contains(t, src, "\t\"encoding/json\"\n")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		src := testutil.ReadFile(t, generateFixture(t, generator.Config{GoJSONPackage: "github.com/goccy/go-json"}, "basic.json"), ClientGenFile)
+		require.Contains(t, src, "\tjson \"github.com/goccy/go-json\"\n")
+		require.NotContains(t, src, "\"encoding/json\"")
+		require.Contains(t, src, "return json.Marshal(id)")
+	})
+}
+
+func TestGeneratePing(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GeneratePing: true}, "basic.json"), ClientGenFile)
+	require.Contains(t, src, "func (r *Client) Ping(ctx context.Context) error {")
+	require.Contains(t, src, `r.query.Select("__typename")`)
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{}, "basic.json"), ClientGenFile)
+	require.NotContains(t, src, "Ping(")
+}
+
+func TestGenerateExcludeDeprecated(t *testing.T) {
+	schema, _ := loadFixture(t, "deprecated.json")
+	require.Equal(t, []string{
+		"Query.legacyEngine",
+		"Container.withExec(skipEntrypoint)",
+		"Container.output",
+		"LegacyEngine",
+		"NetworkProtocol.SCTP",
+	}, generator.ExcludeDeprecated(schema))
+
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{ExcludeDeprecated: true}, "deprecated.json"), ClientGenFile)
+	require.NotContains(t, src, "LegacyEngine")
+	require.NotContains(t, src, "func (r *Container) Output(")
+	require.NotContains(t, src, "SkipEntrypoint")
+	require.NotContains(t, src, `"SCTP"`)
+	require.Contains(t, src, "type ContainerWithExecOpts struct {\n"+
+		"\t// Replace the environment variables in the arguments.\n"+
+		"\tExpand bool\n"+
+		"}")
+	// a type with only deprecated fields is kept while a kept field returns it
+	require.Contains(t, src, "func (r *Container) OldConfig() *OldConfig {")
+	require.Contains(t, src, "func (r *OldConfig) User(ctx context.Context) (string, error) {")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{}, "deprecated.json"), ClientGenFile)
+	require.Contains(t, src, "func (r *Client) LegacyEngine() *LegacyEngine {")
+	require.Contains(t, src, "SkipEntrypoint bool")
+	require.Contains(t, src, `"SCTP"`)
+}
+
+func TestGenerateIDLoaders(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateIDLoaders: true}, "loaders.json"), ClientGenFile)
+	require.Contains(t, src, "func WidgetFromID(c *Client, id WidgetID) *Widget {\n\treturn c.LoadWidgetFromID(id)\n}")
+	// the objects without an ID or without a loader have no helper
+	require.NotContains(t, src, "func GadgetFromID(")
+	require.NotContains(t, src, "func NoteFromID(")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{}, "loaders.json"), ClientGenFile), "func WidgetFromID(")
+
+	// the loaders of the built-in ID scalar take the scalar of the object
+	// with TypedIDs
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateIDLoaders: true}, "typedids.json"), ClientGenFile)
+	require.Contains(t, src, "func FileFromID(c *Client, id string) *File {")
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateIDLoaders: true, TypedIDs: true}, "typedids.json"), ClientGenFile)
+	require.Contains(t, src, "func FileFromID(c *Client, id FileID) *File {")
+
+	t.Run("collision", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "loaders.json")
+		schema.Types = append(schema.Types, &introspection.Type{Kind: introspection.TypeKindScalar, Name: "WidgetFromID"})
+		generator.SetSchema(schema)
+		err := generateCode(context.Background(), generator.Config{GenerateIDLoaders: true, ClientOnly: true, OutputDir: t.TempDir()}, schema, schemaVersion, memfs.New(), &PackageInfo{
+			PackageName:   "dagger",
+			PackageImport: testutil.ModulePath + "/dagger",
+		}, nil, nil, 1)
+		require.ErrorContains(t, err, "id loader WidgetFromID collides with a type of the schema")
+	})
+}
+
+func TestGenerateTracing(t *testing.T) {
+	t.Run("enabled", func(t *testing.T) {
+		src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateTracing: true}, "basic.json"), ClientGenFile)
+		require.Contains(t, src, "func (r *Container) Stdout(ctx context.Context) (string, error) {\n\tctx, span := Tracer().Start(ctx, \"Container.stdout\")\n\tdefer span.End()\n")
+		require.Contains(t, src, `Tracer().Start(ctx, "Container.envVariables")`)
+		// the methods not making a request have no context
+		require.NotContains(t, src, `"Container.from"`)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		src := testutil.ReadFile(t, generateFixture(t, generator.Config{}, "basic.json"), ClientGenFile)
+		require.NotContains(t, src, "span := Tracer().Start(")
+	})
+}
+
+func TestGenerateFileSuffix(t *testing.T) {
+	paths := func(mfs fs.FS) []string {
+		var paths []string
+		err := fs.WalkDir(mfs, ".", func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				paths = append(paths, path)
+			}
+			return err
+		})
+		require.NoError(t, err)
+		return paths
+	}
+
+	t.Run("suffix", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{
+			GeneratedFileSuffix: ".generated",
+			SplitByType:         true,
+			GenerateMocks:       true,
+		}, "basic.json")
+		require.ElementsMatch(t, []string{
+			"dagger.generated.go",
+			"dag/dag.generated.go",
+			"client.generated.go",
+			"container.generated.go",
+			"envvariable.generated.go",
+			"mock/mock.generated.go",
+		}, paths(mfs))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SplitByType: true, GenerateMocks: true}, "basic.json")
+		require.ElementsMatch(t, []string{
+			"dagger.gen.go",
+			"dag/dag.gen.go",
+			"client.gen.go",
+			"container.gen.go",
+			"envvariable.gen.go",
+			"mock/mock.gen.go",
+		}, paths(mfs))
+	})
+}
+
+func TestGenerateGoTarget(t *testing.T) {
+	for _, tc := range []struct {
+		target     string
+		constraint string
+		build      func(dir string) *exec.Cmd
+	}{
+		{
+			target:     generator.GoTargetWasm,
+			constraint: "//go:build wasm\n",
+			build: func(dir string) *exec.Cmd {
+				cmd := testutil.GoCommand(dir, "vet", ".")
+				cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+				return cmd
+			},
+		},
+		{
+			target:     generator.GoTargetTinyGo,
+			constraint: "//go:build tinygo\n",
+			build: func(dir string) *exec.Cmd {
+				cmd := exec.Command("tinygo", "build", "-target=wasip1", "-o", os.DevNull, ".")
+				cmd.Dir = dir
+				return cmd
+			},
+		},
+	} {
+		t.Run(tc.target, func(t *testing.T) {
+			mfs := generateFixture(t, generator.Config{
+				GoTarget:              tc.target,
+				GenerateSession:       true,
+				GenerateBatching:      true,
+				SkipServeDependencies: true,
+			}, "basic.json")
+			src := testutil.ReadFile(t, mfs, ClientGenFile)
+			require.True(t, strings.HasPrefix(src, tc.constraint), src[:min(len(src), 100)])
+			require.Contains(t, src, "func NewClient(ctx context.Context, client graphql.Client) (*Client, error) {")
+			require.NotContains(t, src, "func Connect(")
+			require.NotContains(t, src, "go.opentelemetry.io")
+			_, err := fs.Stat(mfs, "dag")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+
+			if _, err := exec.LookPath(tc.build("").Args[0]); err != nil {
+				t.Skipf("%s isn't installed", tc.build("").Args[0])
+			}
+
+			// the client must build for the target
+			out, err := tc.build(testutil.WritePackage(t, map[string]string{ClientGenFile: src})).CombinedOutput()
+			require.NoError(t, err, string(out))
+		})
+	}
+}
+
+func TestGeneratePlatformSplit(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json")
+		require.NotContains(t, testutil.ReadFile(t, mfs, ClientGenFile), "func NotifyInterrupt(")
+		matches, err := fs.Glob(mfs, "platform_*")
+		require.NoError(t, err)
+		require.Empty(t, matches)
+	})
+
+	mfs := generateFixture(t, generator.Config{GoPlatformSplit: true, SkipServeDependencies: true}, "basic.json")
+	src := testutil.ReadFile(t, mfs, ClientGenFile)
+	require.Contains(t, src, "func NotifyInterrupt(ctx context.Context) (context.Context, context.CancelFunc) {")
+
+	dir := testutil.WritePackage(t, map[string]string{ClientGenFile: src})
+
+	for _, tc := range []struct {
+		name       string
+		constraint string
+		goos       []string
+	}{
+		{"platform_linux.gen.go", "linux", []string{"linux"}},
+		{"platform_windows.gen.go", "windows", []string{"windows"}},
+		{"platform_other.gen.go", "!linux && !windows", []string{"darwin", "freebsd"}},
+	} {
+		platform := testutil.ReadFile(t, mfs, tc.name)
+		require.True(t, strings.HasPrefix(platform, "//go:build "+tc.constraint+"\n\n"), platform[:min(len(platform), 100)])
+		require.Contains(t, platform, "var interruptSignals = []os.Signal{")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, tc.name), []byte(platform), 0o600))
+
+		// exactly one of the files is built for each platform
+		for _, goos := range []string{"linux", "windows", "darwin", "freebsd"} {
+			ctxt := build.Default
+			ctxt.GOOS = goos
+			match, err := ctxt.MatchFile(dir, tc.name)
+			require.NoError(t, err)
+			require.Equal(t, slices.Contains(tc.goos, goos), match, "%s for %s", tc.name, goos)
+		}
+	}
+
+	// the client must build for each platform
+	for _, goos := range []string{"linux", "windows", "darwin"} {
+		cmd := testutil.GoCommand(dir, "vet", ".")
+		cmd.Env = append(os.Environ(), "GOOS="+goos)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s: %s", goos, out)
+	}
+}
+
+func TestGenerateContextlessShims(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateContextlessShims: true}, "basic.json"), ClientGenFile)
+	require.Contains(t, src, "// Deprecated: pass a context to EnvVariable instead.\n"+
+		"func (r *Container) EnvVariableNoContext(name string) (string, error) {\n"+
+		"\treturn r.EnvVariable(context.Background(), name)\n"+
+		"}")
+	require.Contains(t, src, "func (r *Container) SyncNoContext() (*Container, error) {\n"+
+		"\treturn r.Sync(context.Background())\n"+
+		"}")
+	// the methods without a context have no shim
+	require.NotContains(t, src, "WithExecNoContext")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateContextlessShims: true}, "namespaces.json"), ClientGenFile)
+	require.Contains(t, src, "func (r *Client) ModuleBBuildNoContext(src string, opts ...ModuleBBuildOpts) (string, error) {\n"+
+		"\treturn r.ModuleBBuild(context.Background(), src, opts...)\n"+
+		"}")
+
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{}, "basic.json"), ClientGenFile), "NoContext")
+}
+
+func TestModuleLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		source string
+		want   generator.Layout
+	}{
+		{
+			name:   "root",
+			source: ".",
+			want:   generator.Layout{SourceDir: ".", GeneratedDir: ".", Entrypoint: "main.go"},
+		},
+		{
+			name:   "subdirectory",
+			source: "./ci/dagger/",
+			want:   generator.Layout{SourceDir: "ci/dagger", GeneratedDir: "ci/dagger", Entrypoint: "ci/dagger/main.go"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := generator.Config{Lang: generator.SDKLangGo, ModuleName: "my-module", ModuleSourcePath: tc.source}
+			layout, err := (&GoGenerator{}).ModuleLayout(cfg)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, layout)
+
+			layout, err = generator.ResolveModuleLayout(cfg)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, layout)
+		})
+	}
+
+	t.Run("out of output dir", func(t *testing.T) {
+		_, err := (&GoGenerator{}).ModuleLayout(generator.Config{ModuleName: "my-module", ModuleSourcePath: "../dagger"})
+		require.ErrorContains(t, err, `module source path "../dagger" must be in the output directory`)
+	})
+
+	t.Run("no module", func(t *testing.T) {
+		_, err := (&GoGenerator{}).ModuleLayout(generator.Config{ModuleSourcePath: "."})
+		require.ErrorContains(t, err, "no module name configured")
+	})
+
+	t.Run("init", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "basic.json")
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "ci", "dagger"), 0o700))
+		g := &GoGenerator{Config: generator.Config{
+			ModuleName:       "my-module",
+			ModuleSourcePath: "ci/dagger",
+			OutputDir:        dir,
+			IsInit:           true,
+		}}
+		generated, err := g.GenerateModule(context.Background(), schema, schemaVersion)
+		require.NoError(t, err)
+		for _, name := range []string{"ci/dagger/go.mod", "ci/dagger/" + ClientGenFile, "ci/dagger/" + StarterTemplateFile} {
+			_, err := fs.Stat(generated.Overlay, name)
+			require.NoError(t, err, name)
+		}
+	})
+}
+
+func TestGenerateModuleScaffoldTests(t *testing.T) {
+	schema, schemaVersion := loadFixture(t, "basic.json")
+	generate := func(t *testing.T, dir string, scaffoldTests bool) fs.FS {
+		g := &GoGenerator{Config: generator.Config{
+			ModuleName:       "my-module",
+			ModuleSourcePath: ".",
+			OutputDir:        dir,
+			IsInit:           true,
+			ScaffoldTests:    scaffoldTests,
+		}}
+		generated, err := g.GenerateModule(context.Background(), schema, schemaVersion)
+		require.NoError(t, err)
+		return generated.Overlay
+	}
+
+	t.Run("scaffold", func(t *testing.T) {
+		dt, err := fs.ReadFile(generate(t, t.TempDir(), true), StarterTestFile)
+		require.NoError(t, err)
+		require.Contains(t, string(dt), `(&MyModule{}).ContainerEcho("hello")`)
+		_, err = parser.ParseFile(token.NewFileSet(), StarterTestFile, dt, 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("existing code", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o600))
+		_, err := fs.Stat(generate(t, dir, true), StarterTestFile)
+		// the layered overlay doesn't wrap fs.ErrNotExist
+		require.Error(t, err)
+	})
+
+	t.Run("no scaffold", func(t *testing.T) {
+		_, err := fs.Stat(generate(t, t.TempDir(), false), StarterTestFile)
+		// the layered overlay doesn't wrap fs.ErrNotExist
+		require.Error(t, err)
+	})
+}
+
+func TestMergeNestedGoMod(t *testing.T) {
+	layout := func(t *testing.T, goMods ...string) string {
+		dir := t.TempDir()
+		for _, modDir := range goMods {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, modDir), 0o700))
+			content := "module example.com/" + filepath.ToSlash(modDir) + "\n\ngo 1.21\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, modDir, "go.mod"), []byte(content), 0o600))
+		}
+		return dir
+	}
+
+	t.Run("find", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			goMods []string
+			source string
+			depth  int
+			want   string
+			err    string
+		}{
+			{name: "root", goMods: []string{"."}, source: "dagger", want: "."},
+			{name: "closest", goMods: []string{"ci"}, source: "ci/dagger", want: "ci"},
+			{name: "none", source: "ci/dagger"},
+			{name: "module source", goMods: []string{"ci/dagger"}, source: "ci/dagger"},
+			{name: "out of depth", goMods: []string{"."}, source: "ci/tools/dagger", depth: 2},
+			{name: "within depth", goMods: []string{".", "ci/tools"}, source: "ci/tools/dagger", depth: 1, want: "ci/tools"},
+			{name: "out of output dir", goMods: []string{"."}, source: "../dagger", want: "."},
+			{
+				name:   "ambiguous",
+				goMods: []string{".", "ci"},
+				source: "ci/dagger",
+				err:    "several go.mod to merge module source ci/dagger with: ci/go.mod, go.mod",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				got, err := findParentGoMod(layout(t, tc.goMods...), tc.source, tc.depth)
+				if tc.err != "" {
+					require.ErrorContains(t, err, tc.err)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, tc.want, got)
+			})
+		}
+	})
+
+	t.Run("import path", func(t *testing.T) {
+		g := &GoGenerator{Config: generator.Config{
+			ModuleName:       "my-module",
+			ModuleSourcePath: "ci/tools/dagger",
+			OutputDir:        layout(t, ".", "ci"),
+			Merge:            true,
+			GoModSearchDepth: 2,
+		}}
+		pkgInfo, _, err := g.bootstrapMod(context.Background(), memfs.New(), &generator.GeneratedState{})
+		require.NoError(t, err)
+		require.Equal(t, "example.com/ci/tools/dagger", pkgInfo.PackageImport)
+
+		g.Config.GoModSearchDepth = 0
+		_, _, err = g.bootstrapMod(context.Background(), memfs.New(), &generator.GeneratedState{})
+		require.ErrorContains(t, err, "several go.mod to merge module source ci/tools/dagger with")
+	})
+}
+
+func TestGenerateEmbedSchema(t *testing.T) {
+	t.Run("embed", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "basic.json")
+		src := testutil.ReadFile(t, generateFixture(t, generator.Config{EmbedSchema: true}, "basic.json"), "schema.gen.go")
+		require.Contains(t, src, "package dagger\n")
+		// the client declares the version, see TestGenerateSchemaVersion
+		require.NotContains(t, src, "const SchemaVersion")
+
+		// the embedded json is the introspection of the schema
+		file, err := parser.ParseFile(token.NewFileSet(), "schema.gen.go", src, 0)
+		require.NoError(t, err)
+		obj := file.Scope.Lookup("IntrospectionJSON")
+		require.NotNil(t, obj)
+		lit, ok := obj.Decl.(*ast.ValueSpec).Values[0].(*ast.BasicLit)
+		require.True(t, ok)
+		dt, err := strconv.Unquote(lit.Value)
+		require.NoError(t, err)
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(dt), &resp))
+		require.Equal(t, schemaVersion, resp.SchemaVersion)
+		require.Len(t, resp.Schema.Types, len(schema.Types))
+
+		// and it's deterministic, to skip unchanged files
+		again := testutil.ReadFile(t, generateFixture(t, generator.Config{EmbedSchema: true}, "basic.json"), "schema.gen.go")
+		require.Equal(t, src, again)
+	})
+
+	t.Run("no embed", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		_, err := fs.Stat(mfs, "schema.gen.go")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestGenerateAnnotateNullability(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{AnnotateNullability: true}, "basic.json"), ClientGenFile)
+	require.Contains(t, src, "// Retrieves the value of the specified environment variable.\n"+
+		"//\n"+
+		"// (nullable)\n"+
+		"func (r *Container) EnvVariable(")
+	require.Contains(t, src, "// Retrieves the list of environment variables passed to commands.\n"+
+		"//\n"+
+		"// (non-null)\n"+
+		"func (r *Container) EnvVariables(")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{}, "basic.json"), ClientGenFile)
+	require.NotContains(t, src, "(nullable)")
+	require.NotContains(t, src, "(non-null)")
+}
@@ -0,0 +1,190 @@
+// Package testutil provides helpers to test the clients generated by the Go
+// generator, by building and running them in temporary modules.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// ModulePath is the path of the modules written by WritePackage. The client
+// is imported as ModulePath + "/dagger" when generated in its own package.
+const ModulePath = "example.com/test"
+
+// LoadFixture reads an introspection response from a file.
+func LoadFixture(t testing.TB, path string) (*introspection.Schema, string) {
+	t.Helper()
+	dt, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+	return resp.Schema, resp.SchemaVersion
+}
+
+// LoadSchema loads the schema of a standalone Go client as the generation of
+// the command line would, e.g. with its hidden types and deprecated members
+// excluded, and returns it with the validated config of the generation.
+func LoadSchema(t testing.TB, cfg generator.Config, schema *introspection.Schema, schemaVersion string) (generator.Config, *introspection.Schema, string) {
+	t.Helper()
+	cfg.Lang = generator.SDKLangGo
+	cfg.ClientOnly = true
+	cfg.Schema = schema
+	cfg.SchemaVersion = schemaVersion
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = t.TempDir()
+	}
+	require.NoError(t, cfg.Validate())
+
+	schema, schemaVersion, err := generator.LoadSchema(context.Background(), cfg)
+	require.NoError(t, err)
+	generator.SetSchemaParents(schema)
+	generator.SetSchema(schema)
+	return cfg, schema, schemaVersion
+}
+
+// ReadFile returns the content of a generated file.
+func ReadFile(t testing.TB, fsys fs.FS, name string) string {
+	t.Helper()
+	dt, err := fs.ReadFile(fsys, name)
+	require.NoError(t, err)
+	return string(dt)
+}
+
+// WritePackage writes the files to the root package of a new module, removed
+// once the test is done, and returns its directory. The module is named
+// ModulePath, and requires the dependencies of the repository module, with
+// dagger.io/dagger replaced by its SDK, so that the generated code can import
+// them.
+func WritePackage(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	goMod, goSum := testModule(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), goMod, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o600))
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+// testModule returns the go.mod and go.sum of the modules of WritePackage:
+// the ones of the repository module, renamed, with its local replacements
+// made absolute.
+func testModule(t testing.TB) ([]byte, []byte) {
+	t.Helper()
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	require.NoError(t, err)
+	goModPath := strings.TrimSpace(string(out))
+	root := filepath.Dir(goModPath)
+
+	dt, err := os.ReadFile(goModPath)
+	require.NoError(t, err)
+	goMod, err := modfile.Parse(goModPath, dt, nil)
+	require.NoError(t, err)
+	require.NoError(t, goMod.AddModuleStmt(ModulePath))
+	for _, replace := range goMod.Replace {
+		if modfile.IsDirectoryPath(replace.New.Path) {
+			newPath := filepath.Join(root, filepath.FromSlash(replace.New.Path))
+			require.NoError(t, goMod.AddReplace(replace.Old.Path, replace.Old.Version, newPath, ""))
+		}
+	}
+	dt, err = goMod.Format()
+	require.NoError(t, err)
+
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+	return dt, goSum
+}
+
+// GoCommand returns the go command running with args in dir.
+func GoCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// MainPackage moves the generated code to the main package.
+func MainPackage(src string) string {
+	return strings.Replace(src, "package dagger\n", "package main\n", 1)
+}
+
+// RunPackage runs the main package of dir, and returns its output.
+func RunPackage(t testing.TB, dir string) string {
+	t.Helper()
+	out, err := GoCommand(dir, "run", ".").CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+// RunGenerated runs mainSrc along with the generated client src and
+// FakeClient, and returns the output.
+func RunGenerated(t testing.TB, src, mainSrc string) string {
+	t.Helper()
+	return RunPackage(t, WritePackage(t, map[string]string{
+		"dagger.gen.go": MainPackage(src),
+		"fakeclient.go": FakeClient,
+		"main.go":       mainSrc,
+	}))
+}
+
+// FakeClient is the source of a graphql.Client of the main package, for the
+// programs of RunGenerated to call the generated client without an engine.
+const FakeClient = `package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// fakeClient answers each request with the JSON data returned by respond,
+// and records the queries it is sent.
+type fakeClient struct {
+	respond func(ctx context.Context, req *graphql.Request) (string, error)
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// respondWith returns a fakeClient answering all the requests with data.
+func respondWith(data string) *fakeClient {
+	return &fakeClient{
+		respond: func(context.Context, *graphql.Request) (string, error) {
+			return data, nil
+		},
+	}
+}
+
+func (c *fakeClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	c.mu.Lock()
+	c.queries = append(c.queries, req.Query)
+	c.mu.Unlock()
+
+	data, err := c.respond(ctx, req)
+	if err != nil {
+		return err
+	}
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), resp.Data)
+}
+`
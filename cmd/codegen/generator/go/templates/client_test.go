@@ -0,0 +1,933 @@
+package templates_test
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+)
+
+func TestGenerateLazyConnect(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{LazyConnect: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "lazy := &lazyClient{opts: opts}")
+	require.Contains(t, src, "c.dag, c.err = dagger.Connect(ctx, c.opts...)")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "lazyClient")
+
+	// the dependencies are served by the first request, not by Connect
+	served := testutil.ReadFile(t, generateFixture(t, generator.Config{LazyConnect: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, served, "if err := serveModuleDependencies(ctx, &Client{")
+	require.NotContains(t, served, "serveModuleDependencies(ctx, c)")
+
+	// connect with a CLI that doesn't exist: connecting would fail
+	t.Setenv("_EXPERIMENTAL_DAGGER_CLI_BIN", filepath.Join(t.TempDir(), "dagger"))
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	client, err := Connect(ctx)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("connected")
+
+	for range 2 {
+		if _, err := client.Container().Stdout(ctx); err != nil {
+			fmt.Println("request failed")
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		panic(err)
+	}
+
+	closed, err := Connect(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if err := closed.Close(); err != nil {
+		panic(err)
+	}
+	_, err = closed.Container().Stdout(ctx)
+	fmt.Println(err)
+}
+`)
+	require.Equal(t, "connected\nrequest failed\nrequest failed\nclient is closed\n", out)
+}
+
+func TestGeneratePluggableTransport(t *testing.T) {
+	t.Run("transport", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GeneratePluggableTransport: true, SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "type Transport interface {")
+		require.Contains(t, src, "func NewClientWithTransport(ctx context.Context, transport Transport) (*Client, error) {")
+		// the transport isn't a connection to close
+		require.Contains(t, src, "if c.dag == nil {")
+
+		// inject a fake transport
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	var queries []string
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		queries = append(queries, req.Query)
+		return json.Unmarshal([]byte(`+"`"+`{"loadContainerFromID":{"envVariable":"/bin"}}`+"`"+`), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	value, err := client.LoadContainerFromID("ctr").EnvVariable(ctx, "PATH")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(queries, value)
+}
+`)
+		require.Equal(t, "[query{loadContainerFromID(id:\"ctr\"){envVariable(name:\"PATH\")}}] /bin\n", out)
+	})
+
+	t.Run("no transport", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "type Transport interface")
+		require.NotContains(t, src, "NewClientWithTransport")
+	})
+}
+
+func TestGenerateDryRunTransport(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.NotContains(t, src, "SetDryRun")
+
+	mfs := generateFixture(t, generator.Config{GenerateDryRunTransport: true, GeneratePluggableTransport: true, SkipServeDependencies: true}, "basic.json")
+	src = testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (c *Client) SetDryRun(enabled bool) {")
+	require.Contains(t, src, "func (c *Client) DryRunQueries() []string {")
+
+	// toggle the dry run of a client with a fake transport
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	sent := 0
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		sent++
+		return json.Unmarshal([]byte(`+"`"+`{"loadContainerFromID":{"envVariable":"/bin"}}`+"`"+`), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	client.SetDryRun(true)
+	value, err := client.LoadContainerFromID("ctr").EnvVariable(ctx, "PATH")
+	fmt.Printf("%q %v %d %v\n", value, err, sent, client.DryRunQueries())
+
+	client.SetDryRun(false)
+	value, err = client.LoadContainerFromID("ctr").EnvVariable(ctx, "PATH")
+	fmt.Printf("%q %v %d %d\n", value, err, sent, len(client.DryRunQueries()))
+}
+`)
+	require.Equal(t, `"" <nil> 0 [query{loadContainerFromID(id:"ctr"){envVariable(name:"PATH")}}]
+"/bin" <nil> 1 1
+`, out)
+}
+
+func TestGenerateOperationNames(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.NotContains(t, src, "withOperationName")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateOperationNames: true, GeneratePluggableTransport: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, `ctx = withOperationName(ctx, "ContainerEnvVariable")`)
+	require.Contains(t, src, `ctx = withOperationName(ctx, "QueryVersion")`)
+
+	// print the requests of a fake transport
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		fmt.Println(req.OpName, req.Query)
+		return json.Unmarshal([]byte(`+"`"+`{"version":"v0.18.10","loadContainerFromID":{"envVariable":"/bin"}}`+"`"+`), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	if _, err := client.LoadContainerFromID("ctr").EnvVariable(ctx, "PATH"); err != nil {
+		panic(err)
+	}
+	if _, err := client.Version(ctx); err != nil {
+		panic(err)
+	}
+}
+`)
+	require.Equal(t, `ContainerEnvVariable query ContainerEnvVariable{loadContainerFromID(id:"ctr"){envVariable(name:"PATH")}}
+QueryVersion query QueryVersion{version}
+`, out)
+}
+
+func TestGenerateContextLabels(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.NotContains(t, src, "func WithLabels(")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateContextLabels: true, GeneratePluggableTransport: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func WithLabels(ctx context.Context, labels map[string]string) context.Context {")
+	require.Contains(t, src, "c.labelRequests()")
+
+	// print the baggage of the requests of a fake transport
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func main() {
+	ctx := context.Background()
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		bag := baggage.FromContext(ctx)
+		fmt.Println(req.Query, bag.Len(), bag.Member("team").Value(), bag.Member("step").Value())
+		return json.Unmarshal([]byte(`+"`"+`{"version":"v0.18.10"}`+"`"+`), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Version(ctx); err != nil {
+		panic(err)
+	}
+	ctx = WithLabels(ctx, map[string]string{"team": "infra", "step": "build"})
+	if _, err := client.Version(ctx); err != nil {
+		panic(err)
+	}
+	if _, err := client.Version(WithLabels(ctx, map[string]string{"step": "test"})); err != nil {
+		panic(err)
+	}
+	if _, err := client.Version(ctx); err != nil {
+		panic(err)
+	}
+}
+`)
+	require.Equal(t, `query{version} 0  
+query{version} 2 infra build
+query{version} 2 infra test
+query{version} 2 infra build
+`, out)
+}
+
+func TestGenerateMetrics(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateMetrics: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "\tctx = withMetricsField(ctx, \"Container.stdout\")\n")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "Metrics")
+
+	// count the requests of a client failing the ones of the exit code
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+type fakeCollector struct {
+	requests map[string]int
+	errors   map[string]int
+}
+
+func (c *fakeCollector) CountRequest(field string) { c.requests[field]++ }
+
+func (c *fakeCollector) CountError(field string) { c.errors[field]++ }
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			if strings.Contains(req.Query, "exitCode") {
+				return "", errors.New("no exit code")
+			}
+			return `+"`"+`{"container":{"stdout":"hello"}}`+"`"+`, nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	collector := &fakeCollector{requests: map[string]int{}, errors: map[string]int{}}
+	client.Metrics = collector
+
+	for range 2 {
+		if _, err := client.Container().Stdout(ctx); err != nil {
+			panic(err)
+		}
+	}
+	if _, err := client.Container().ExitCode(ctx); err == nil {
+		panic("no error")
+	}
+	fmt.Println(collector.requests, collector.errors)
+}
+`)
+	require.Equal(t, "map[Container.exitCode:1 Container.stdout:2] map[Container.exitCode:1]\n", out)
+}
+
+func TestGenerateRawQuery(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateRawQuery: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *Client) Raw(ctx context.Context, query string, vars map[string]any, out any) error {")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "Raw(")
+
+	// send a raw query with a client answering with its variables
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			dt, err := json.Marshal(map[string]any{"query": req.Query, "vars": req.Variables})
+			return string(dt), err
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var out struct {
+		Query string
+		Vars  map[string]string
+	}
+	if err := client.Raw(ctx, "query($name: String!){experimental(name: $name)}", map[string]any{"name": "x"}, &out); err != nil {
+		panic(err)
+	}
+	fmt.Println(out.Query, out.Vars["name"])
+}
+`)
+	require.Equal(t, "query($name: String!){experimental(name: $name)} x\n", out)
+}
+
+func TestGenerateIntrospectMethod(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateIntrospectMethod: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *Client) Introspect(ctx context.Context) (*Schema, error) {")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "Introspect(")
+
+	// introspect with a client answering with a schema
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			if req.OpName != "IntrospectionQuery" || !strings.Contains(req.Query, "__schema {") {
+				return "", fmt.Errorf("unexpected query %s", req.OpName)
+			}
+			return `+"`"+`{
+				"__schemaVersion": "v0.1.0",
+				"__schema": {
+					"queryType": {"name": "Query"},
+					"types": [{"kind": "OBJECT", "name": "Query", "fields": [
+						{"name": "version", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					]}]
+				}
+			}`+"`"+`, nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	schema, err := client.Introspect(ctx)
+	if err != nil {
+		panic(err)
+	}
+	field := schema.Type(schema.QueryType.Name).Fields[0]
+	fmt.Println(schema.Version, field.Name, field.Type.Kind, field.Type.OfType.Name)
+}
+`)
+	require.Equal(t, "v0.1.0 version NON_NULL String\n", out)
+}
+
+func TestGenerateBatching(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{GenerateBatching: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json")
+	src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *Client) Batch() *Batch {")
+
+	// run a batch of three calls with a client recording the requests
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			data := map[string]any{}
+			for _, alias := range []string{"batch0", "batch1"} {
+				switch {
+				case strings.Contains(req.Query, alias+":container{"):
+					data[alias] = map[string]any{"from": map[string]any{"stdout": "hello"}}
+				case strings.Contains(req.Query, alias+":version "):
+					data[alias] = "v0.18.10"
+				}
+			}
+			dt, err := json.Marshal(data)
+			return string(dt), err
+		},
+	}
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	var stdout, version, again string
+	err = client.Batch().
+		Add(func(ctx context.Context, c *Client) (err error) {
+			stdout, err = c.Container().From("alpine").Stdout(ctx)
+			return err
+		}).
+		Add(func(ctx context.Context, c *Client) (err error) {
+			version, err = c.Version(ctx)
+			return err
+		}).
+		Add(func(ctx context.Context, c *Client) (err error) {
+			again, err = c.Version(ctx)
+			return err
+		}).
+		Execute(ctx)
+	if err != nil {
+		panic(err)
+	}
+	// the version is selected once for both calls
+	fmt.Println(len(gql.queries), stdout, version, again, strings.Count(gql.queries[0], "version"))
+}
+`)
+	require.Equal(t, "1 hello v0.18.10 v0.18.10 1\n", out)
+}
+
+func TestGenerateClientRetry(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{
+		GenerateClientRetry:   true,
+		ClientRetryBackoff:    time.Millisecond,
+		ReuseConnection:       true,
+		SkipServeDependencies: true,
+	}, "basic.json")
+	src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "MaxAttempts: 3,")
+	require.Contains(t, src, "Backoff:     time.Millisecond,")
+	require.Contains(t, src, "\t\"publish\":     true,\n")
+
+	// make requests with a client failing once
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// version gets the version from a client failing the first request with
+// failure.
+func version(failure error) {
+	gql := &fakeClient{}
+	gql.respond = func(ctx context.Context, req *graphql.Request) (string, error) {
+		if len(gql.queries) == 1 {
+			return "", failure
+		}
+		return `+"`"+`{"version":"v0.18.10"}`+"`"+`, nil
+	}
+	client, err := NewClient(context.Background(), gql)
+	if err != nil {
+		panic(err)
+	}
+	version, err := client.Version(context.Background())
+	fmt.Println(len(gql.queries), version, err != nil)
+}
+
+func main() {
+	version(&graphql.HTTPError{StatusCode: http.StatusServiceUnavailable})
+	version(&graphql.HTTPError{StatusCode: http.StatusBadRequest})
+	version(gqlerror.List{{Message: "invalid query"}})
+	version(fmt.Errorf("connection reset"))
+	fmt.Println(IsTransientQueryError(&graphql.Request{Query: "mutation{reset}"}, fmt.Errorf("connection reset")))
+	// the queries with side effects aren't retried either
+	fmt.Println(IsTransientQueryError(&graphql.Request{Query: "query{container{from(address:\"alpine\"){id}}}"}, fmt.Errorf("connection reset")))
+	fmt.Println(IsTransientQueryError(&graphql.Request{Query: "query{container{from(address:\"alpine\"){publish(address:\"ttl.sh/alpine\")}}}"}, fmt.Errorf("connection reset")))
+	fmt.Println(IsTransientQueryError(&graphql.Request{Query: "query{container{...on Container{sync}}}"}, fmt.Errorf("connection reset")))
+}
+`)
+	require.Equal(t, "2 v0.18.10 false\n1  true\n1  true\n2 v0.18.10 false\nfalse\ntrue\nfalse\nfalse\n", out)
+
+	// the excluded fields are configurable
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{
+		GenerateClientRetry:       true,
+		ClientRetryExcludedFields: []string{"version", "reset", "version"},
+		ReuseConnection:           true,
+		SkipServeDependencies:     true,
+	}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "var nonRetryableFields = map[string]bool{\n\t\"reset\":   true,\n\t\"version\": true,\n}\n")
+}
+
+func TestGenerateOperationTimeout(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{DefaultOperationTimeout: 90 * time.Second, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "const DefaultOperationTimeout = 90 * time.Second\n")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "OperationTimeout")
+
+	// report the deadlines of the requests of a client
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	client, err := NewClient(context.Background(), &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			deadline, ok := ctx.Deadline()
+			fmt.Println(ok, time.Until(deadline).Round(time.Second))
+			return `+"`"+`{"version":"v0.18.10"}`+"`"+`, nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	if _, err := client.Version(context.Background()); err != nil {
+		panic(err)
+	}
+
+	// the deadline of the caller is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if _, err := client.Version(ctx); err != nil {
+		panic(err)
+	}
+
+	client.OperationTimeout = 0
+	if _, err := client.Version(context.Background()); err != nil {
+		panic(err)
+	}
+}
+`)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3, out)
+	require.Equal(t, "true 1m30s", lines[0])
+	require.Equal(t, "true 1h0m0s", lines[1])
+	require.True(t, strings.HasPrefix(lines[2], "false "), lines[2])
+}
+
+func TestGenerateMaxQueryDepth(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{MaxQueryDepth: 3, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "const MaxQueryDepth = 3\n")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "MaxQueryDepth")
+
+	// select fields up to and beyond the maximum depth
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"container":{"from":{"stdout":"hello"}}}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+	stdout, err := client.Container().From("alpine").Stdout(ctx)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(stdout)
+
+	_, err = client.Container().From("alpine").WithExec([]string{"echo"}).Stdout(ctx)
+	var depthErr *QueryDepthError
+	fmt.Println(errors.As(err, &depthErr), err)
+	// the too deep query isn't sent
+	fmt.Println(len(gql.queries))
+}
+`)
+	require.Equal(t, "hello\ntrue query depth 4 exceeds the maximum of 3\n1\n", out)
+}
+
+func TestGenerateIncludeQueryInErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{
+			name:    "enabled",
+			enabled: true,
+			want: `true boom (query: query{container{from(address:"alpine"){stdout}}})
+true boom (query: query{cont...)
+`,
+		},
+		{
+			name: "disabled",
+			want: `true boom
+true boom
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := testutil.ReadFile(t, generateFixture(t, generator.Config{IncludeQueryInErrors: tc.enabled, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+			require.Equal(t, tc.enabled, strings.Contains(src, "type QueryError struct {"))
+
+			// print the errors of failing requests
+			truncate := ""
+			if tc.enabled {
+				truncate = "MaxErrorQueryLength = 10"
+			}
+			out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+var errBoom = errors.New("boom")
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &fakeClient{
+		respond: func(context.Context, *graphql.Request) (string, error) {
+			return "", errBoom
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	_, err = client.Container().From("alpine").Stdout(ctx)
+	fmt.Println(errors.Is(err, errBoom), err)
+
+	`+truncate+`
+	_, err = client.Container().From("alpine").Stdout(ctx)
+	fmt.Println(errors.Is(err, errBoom), err)
+}
+`)
+			require.Equal(t, tc.want, out)
+		})
+	}
+}
+
+func TestGenerateSchemaVersion(t *testing.T) {
+	_, schemaVersion := loadFixture(t, "basic.json")
+	require.NotEmpty(t, schemaVersion)
+
+	mfs := generateFixture(t, generator.Config{EmbedSchema: true, SkipServeDependencies: true}, "basic.json")
+	src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "const SchemaVersion = "+strconv.Quote(schemaVersion)+"\n")
+	require.Contains(t, src, "func Version() string {")
+
+	// it's deterministic, so that the overlay doesn't rewrite it
+	equal, paths, err := generator.OverlaysEqual(mfs, generateFixture(t, generator.Config{EmbedSchema: true, SkipServeDependencies: true}, "basic.json"))
+	require.NoError(t, err)
+	require.True(t, equal, paths)
+
+	// print the version with the embedded schema
+	out := testutil.RunPackage(t, testutil.WritePackage(t, map[string]string{
+		gogenerator.ClientGenFile: testutil.MainPackage(testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)),
+		"schema.gen.go":           testutil.MainPackage(testutil.ReadFile(t, mfs, "schema.gen.go")),
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Version(), Version() == SchemaVersion)
+}
+`,
+	}))
+	require.Equal(t, schemaVersion+" true\n", out)
+}
+
+func TestGenerateResponseValidation(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{EmbedSchema: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.NotContains(t, src, "validateResponses")
+
+	mfs := generateFixture(t, generator.Config{EmbedSchema: true, GenerateResponseValidation: true, GeneratePluggableTransport: true, SkipServeDependencies: true}, "basic.json")
+	src = testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "c.validateResponses()")
+	require.Contains(t, src, "type InvalidResponseError struct {")
+
+	// send malformed responses from a fake transport
+	out := testutil.RunPackage(t, testutil.WritePackage(t, map[string]string{
+		gogenerator.ClientGenFile: testutil.MainPackage(testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)),
+		"schema.gen.go":           testutil.MainPackage(testutil.ReadFile(t, mfs, "schema.gen.go")),
+		"main.go": `package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	var data string
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		return json.Unmarshal([]byte(data), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	for _, data = range []string{
+		` + "`" + `{"loadContainerFromID":{"exitCode":1}}` + "`" + `,
+		` + "`" + `{"loadContainerFromID":{"exitCode":"1"}}` + "`" + `,
+		` + "`" + `{"loadContainerFromID":{"exitCode":1.5}}` + "`" + `,
+		` + "`" + `{"loadContainerFromID":{"exitCode":1,"stdout":"hello"}}` + "`" + `,
+		` + "`" + `{"loadContainerFromID":{}}` + "`" + `,
+		` + "`" + `{"loadContainerFromID":null}` + "`" + `,
+	} {
+		code, err := client.LoadContainerFromID("ctr").ExitCode(ctx)
+		var invalid *InvalidResponseError
+		fmt.Println(code, errors.As(err, &invalid), err)
+	}
+}
+`,
+	}))
+	require.Equal(t, `1 false <nil>
+0 true invalid response at data.loadContainerFromID.exitCode: expected Int, got "1"
+0 true invalid response at data.loadContainerFromID.exitCode: expected Int, got 1.5
+0 true invalid response at data.loadContainerFromID: unexpected field "stdout"
+0 true invalid response at data.loadContainerFromID: missing field "exitCode"
+0 true invalid response at data.loadContainerFromID: unexpected null
+`, out)
+}
+
+func TestGenerateFieldCache(t *testing.T) {
+	t.Run("cache", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{
+			GenerateFieldCache:    true,
+			CacheableFields:       []string{"Tree.value"},
+			ReuseConnection:       true,
+			SkipServeDependencies: true,
+		}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "return response, q.Execute(withFieldCache(ctx))")
+		require.Contains(t, src, "func (c *Client) ClearFieldCache() {")
+
+		// read the cached and the uncached fields twice from a client
+		// recording the requests
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"loadTreeFromID":{"parent":{"id":"root","value":"root value"}}}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	for range 2 {
+		// the objects are new, so that they don't hold the values themselves
+		value, err := client.LoadTreeFromID("leaf").Parent().Value(ctx)
+		if err != nil {
+			panic(err)
+		}
+		id, err := client.LoadTreeFromID("leaf").Parent().ID(ctx)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(value, id, len(gql.queries))
+	}
+
+	client.ClearFieldCache()
+	if _, err := client.LoadTreeFromID("leaf").Parent().Value(ctx); err != nil {
+		panic(err)
+	}
+	fmt.Println(len(gql.queries))
+}
+`)
+		require.Equal(t, "root value root 2\nroot value root 3\n4\n", out)
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{CacheableFields: []string{"Tree.value"}, SkipServeDependencies: true}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "withFieldCache")
+		require.NotContains(t, src, "fieldCache")
+	})
+}
+
+func TestGenerateConnectionPool(t *testing.T) {
+	t.Run("pool", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{
+			GenerateConnectionPool: true,
+			ConnectionPoolSize:     2,
+			ReuseConnection:        true,
+			SkipServeDependencies:  true,
+		}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "func ConnectWithPool(ctx context.Context, pool PoolOpts, opts ...dagger.ClientOpt) (*Client, error) {")
+		require.Contains(t, src, "return ConnectWithPool(ctx, DefaultPoolOpts, opts...)")
+		require.Contains(t, src, "func NewClientWithPool(ctx context.Context, client graphql.Client, pool PoolOpts) (*Client, error) {")
+
+		// send concurrent requests through a client recording how many
+		// are in flight
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// concurrency records how many requests are in flight.
+type concurrency struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (c *concurrency) respond(ctx context.Context, req *graphql.Request) (string, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.max = max(c.max, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return `+"`"+`{"loadTreeFromID":{"value":"leaf"}}`+"`"+`, nil
+}
+
+func maxInFlight(client *Client, c *concurrency) int {
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.LoadTreeFromID("leaf").Value(context.Background()); err != nil {
+				panic(err)
+			}
+		}()
+	}
+	wg.Wait()
+	return c.max
+}
+
+func main() {
+	ctx := context.Background()
+
+	c := &concurrency{}
+	client, err := NewClient(ctx, &fakeClient{respond: c.respond})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(maxInFlight(client, c))
+
+	c = &concurrency{}
+	client, err = NewClientWithPool(ctx, &fakeClient{respond: c.respond}, PoolOpts{Size: 1})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(maxInFlight(client, c))
+}
+`)
+		require.Equal(t, "2\n1\n", out)
+	})
+
+	t.Run("no pool", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{ReuseConnection: true, SkipServeDependencies: true}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "PoolOpts")
+		require.NotContains(t, src, "WithPool")
+	})
+}
@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// cursorType returns the type of the cursors of the connection returned by a
+// field, with Config.TypedCursors, or an empty string if the field isn't
+// paginated.
+// Example: `users(after: String): UserConnection!` -> `UserConnectionCursor`
+func (funcs goTemplateFuncs) cursorType(f introspection.Field) string {
+	if !funcs.cfg.TypedCursors || funcs.paginatedNode(f) == nil {
+		return ""
+	}
+	conn := funcs.paginationConvention().PaginatedConnection(funcs.schema, f)
+	return funcs.formatName(conn.Name) + "Cursor"
+}
+
+// cursorArgType returns the type of an argument of a field if it's the cursor
+// of its connection with Config.TypedCursors, or an empty string otherwise.
+func (funcs goTemplateFuncs) cursorArgType(f introspection.Field, arg introspection.InputValue) string {
+	if arg.Name != funcs.paginationConvention().After {
+		return ""
+	}
+	return funcs.cursorType(f)
+}
+
+// connectionCursor returns the type of the cursors of a connection, with
+// Config.TypedCursors, or an empty string if no field of the schema pages
+// through it. It fails if the name of the type collides with a type of the
+// schema, or the name of its EndCursor method with a method of the
+// connection.
+func (funcs goTemplateFuncs) connectionCursor(t introspection.Type) (string, error) {
+	if !funcs.cfg.TypedCursors {
+		return "", nil
+	}
+
+	paginated := false
+	for _, typ := range funcs.schema.Types {
+		for _, f := range typ.Fields {
+			if conn := funcs.paginationConvention().PaginatedConnection(funcs.schema, *f); conn != nil && conn.Name == t.Name {
+				paginated = true
+			}
+		}
+	}
+	if !paginated {
+		return "", nil
+	}
+
+	name := funcs.formatName(t.Name) + "Cursor"
+	if funcs.schema.Types.Get(name) != nil {
+		return "", fmt.Errorf("cursor type %s of %s collides with a type of the schema", name, t.Name)
+	}
+	endCursor := funcs.formatName(funcs.paginationConvention().EndCursor)
+	for _, f := range t.Fields {
+		if funcs.formatName(f.Name) == endCursor {
+			return "", fmt.Errorf("method %s of the cursors of %s collides with field %s.%s", endCursor, t.Name, t.Name, f.Name)
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,101 @@
+package templates_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestGeneratePaginationHelpers(t *testing.T) {
+	t.Run("helpers", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GeneratePaginationHelpers: true}, "pagination.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "func (r *Client) UsersAll(ctx context.Context, opts ...UsersOpts) iter.Seq2[*User, error] {")
+		require.Contains(t, src, "q := r.Users(append([]UsersOpts{{After: after}}, opts...)...).query")
+		require.Contains(t, src, `q.Select("edges").Select("node").Select("id").Bind(&edges).Execute(ctx)`)
+		require.Contains(t, src, `query: q.Root().Select("loadUserFromID").Arg("id", edge.Node.ID),`)
+		require.Contains(t, src, `q.Select("pageInfo").SelectMultiple("hasNextPage", "endCursor").Bind(&pageInfo).Execute(ctx)`)
+	})
+
+	t.Run("convention", func(t *testing.T) {
+		schema, _ := loadFixture(t, "pagination.json")
+		users := schema.Query().Fields[1]
+		require.Equal(t, "users", users.Name)
+
+		node := generator.PaginationConvention{}.WithDefaults().PaginatedNode(schema, *users)
+		require.NotNil(t, node)
+		require.Equal(t, "User", node.Name)
+
+		node = generator.PaginationConvention{Edges: "items"}.WithDefaults().PaginatedNode(schema, *users)
+		require.Nil(t, node)
+
+		// the helpers load the nodes with loadUserFromID and the UserID scalar
+		query := schema.Query()
+		userID := schema.Types.Get("User").Fields[0].TypeRef.OfType
+		loaderID := query.Fields[0].Args[0].TypeRef.OfType
+		require.Equal(t, "loadUserFromID", query.Fields[0].Name)
+		userID.Name, loaderID.Name = "ID", "ID"
+		require.Nil(t, generator.PaginationConvention{}.WithDefaults().PaginatedNode(schema, *users))
+		userID.Name, loaderID.Name = "UserID", "UserID"
+		require.NotNil(t, generator.PaginationConvention{}.WithDefaults().PaginatedNode(schema, *users))
+
+		query.Fields = slices.DeleteFunc(query.Fields, func(f *introspection.Field) bool {
+			return f.Name == "loadUserFromID"
+		})
+		require.Nil(t, generator.PaginationConvention{}.WithDefaults().PaginatedNode(schema, *users))
+	})
+
+	t.Run("no helpers", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "pagination.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "UsersAll")
+	})
+}
+
+func TestGenerateTypedCursors(t *testing.T) {
+	cfg := generator.Config{GeneratePaginationHelpers: true, TypedCursors: true, ReuseConnection: true, SkipServeDependencies: true}
+	src := testutil.ReadFile(t, generateFixture(t, cfg, "pagination.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "type UserConnectionCursor string")
+	require.Contains(t, src, "type TeamConnectionCursor string")
+	require.Contains(t, src, "\tAfter UserConnectionCursor\n")
+	require.Contains(t, src, "\t\tvar after UserConnectionCursor\n")
+	require.Contains(t, src, "func (r *UserConnection) EndCursor(ctx context.Context) (UserConnectionCursor, error) {")
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{GeneratePaginationHelpers: true, SkipServeDependencies: true}, "pagination.json"), gogenerator.ClientGenFile), "ConnectionCursor")
+
+	// type check programs passing cursors
+	vet := func(opts string) (string, error) {
+		dir := testutil.WritePackage(t, map[string]string{gogenerator.ClientGenFile: testutil.MainPackage(src), "main.go": `package main
+
+import "context"
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+	cursor, err := client.Users().EndCursor(ctx)
+	if err != nil {
+		panic(err)
+	}
+	_ = ` + opts + `
+}
+`})
+		out, err := testutil.GoCommand(dir, "vet", ".").CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := vet("client.Users(UsersOpts{After: cursor})")
+	require.NoError(t, err, out)
+
+	// the cursor of the users can't be passed to the teams
+	out, err = vet("client.Teams(TeamsOpts{After: cursor})")
+	require.Error(t, err)
+	require.Contains(t, out, "cannot use cursor (variable of string type UserConnectionCursor) as TeamConnectionCursor value")
+}
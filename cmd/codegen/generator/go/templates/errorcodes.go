@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// errorCodeType is the type of the errors of the engine named after their
+// code, with Config.GenerateErrorCodes.
+const errorCodeType = "EngineError"
+
+// errorCode is an error of the engine named after its code.
+type errorCode struct {
+	// Name is the name of the error, e.g. ErrNotFound for NOT_FOUND.
+	Name string
+	Code string
+}
+
+// generateErrorCodes returns true if the standalone client should map the
+// codes of the errors of the engine to named errors
+func (funcs goTemplateFuncs) generateErrorCodes() bool {
+	return funcs.cfg.GenerateErrorCodes && funcs.cfg.ClientOnly
+}
+
+// errorCodes returns the named errors of the known error codes, sorted by
+// code, from Config.ErrorCodes and the values of Config.ErrorCodeEnum. It
+// fails if the enum isn't in the schema, or if the names collide.
+func (funcs goTemplateFuncs) errorCodes() ([]errorCode, error) {
+	codes := append([]string{}, funcs.cfg.ErrorCodes...)
+	if name := funcs.cfg.ErrorCodeEnum; name != "" {
+		enum := funcs.schema.Types.Get(name)
+		if enum == nil || enum.Kind != introspection.TypeKindEnum {
+			return nil, fmt.Errorf("error code enum %s isn't an enum of the schema", name)
+		}
+		for _, v := range enum.EnumValues {
+			codes = append(codes, v.Name)
+		}
+	}
+	if funcs.schema.Types.Get(errorCodeType) != nil {
+		return nil, fmt.Errorf("error code type %s collides with a type of the schema", errorCodeType)
+	}
+
+	sort.Strings(codes)
+	var errs []errorCode
+	names := map[string]string{}
+	for _, code := range codes {
+		if len(errs) > 0 && errs[len(errs)-1].Code == code {
+			continue
+		}
+		name := "Err" + strcase.ToCamel(code)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("error codes %s and %s are both named %s", other, code, name)
+		}
+		names[name] = code
+		errs = append(errs, errorCode{Name: name, Code: code})
+	}
+	return errs, nil
+}
@@ -0,0 +1,59 @@
+package templates_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+)
+
+func TestGenerateErrorCodes(t *testing.T) {
+	cfg := generator.Config{
+		GenerateErrorCodes:    true,
+		ErrorCodes:            []string{"NOT_FOUND", "TCP"},
+		ErrorCodeEnum:         "NetworkProtocol",
+		ReuseConnection:       true,
+		SkipServeDependencies: true,
+	}
+	src := testutil.ReadFile(t, generateFixture(t, cfg, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "\tErrNotFound EngineError = \"NOT_FOUND\"\n")
+	require.Contains(t, src, "\tErrUdp      EngineError = \"UDP\"\n")
+	// the codes of both the list and the enum are declared once
+	require.Equal(t, 1, strings.Count(src, "\tErrTcp "))
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "EngineError")
+
+	// match the errors of a client failing with a code
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func main() {
+	ctx := context.Background()
+	for _, code := range []string{"NOT_FOUND", "UNKNOWN"} {
+		client, err := NewClient(ctx, &fakeClient{
+			respond: func(context.Context, *graphql.Request) (string, error) {
+				return "", gqlerror.List{{Message: "failed", Extensions: map[string]any{"_type": code}}}
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+		_, err = client.Container().Stdout(ctx)
+		var gqlErr *gqlerror.Error
+		fmt.Println(code, errors.Is(err, ErrNotFound), errors.Is(err, ErrUdp), errors.As(err, &gqlErr))
+	}
+}
+`)
+	require.Equal(t, "NOT_FOUND true false true\nUNKNOWN false false true\n", out)
+}
@@ -9,7 +9,15 @@ import (
 // FormatTypeFunc is an implementation of generator.FormatTypeFuncs interface
 // to format GraphQL type into Golang.
 type FormatTypeFunc struct {
-	scope string
+	scope      string
+	formatName func(string) string
+}
+
+func (f *FormatTypeFunc) name(s string) string {
+	if f.formatName == nil {
+		return formatName(s)
+	}
+	return f.formatName(s)
 }
 
 func (f *FormatTypeFunc) WithScope(scope string) generator.FormatTypeFuncs {
@@ -47,22 +55,32 @@ func (f *FormatTypeFunc) FormatKindScalarBoolean(representation string) string {
 }
 
 func (f *FormatTypeFunc) FormatKindScalarDefault(representation string, refName string, input bool) string {
+	if refName == "ID" {
+		// the built-in ID scalar identifies no object in particular, and isn't
+		// declared like the other built-in scalars
+		return representation + "string"
+	}
 	if obj, ok := strings.CutSuffix(refName, "ID"); input && ok {
-		representation += "*" + f.scope + formatName(obj)
+		representation += "*" + f.scope + f.name(obj)
 	} else {
-		representation += f.scope + formatName(refName)
+		representation += f.scope + f.name(refName)
 	}
 
 	return representation
 }
 
 func (f *FormatTypeFunc) FormatKindObject(representation string, refName string, input bool) string {
-	representation += f.scope + formatName(refName)
+	representation += f.scope + f.name(refName)
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindInterface(representation string, refName string, input bool) string {
+	representation += f.scope + f.name(refName)
 	return representation
 }
 
 func (f *FormatTypeFunc) FormatKindInputObject(representation string, refName string, input bool) string {
-	representation += f.scope + formatName(refName)
+	representation += f.scope + f.name(refName)
 	return representation
 }
 
@@ -7,8 +7,10 @@ import (
 	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"golang.org/x/tools/go/packages"
@@ -17,6 +19,27 @@ import (
 	"github.com/dagger/dagger/cmd/codegen/introspection"
 )
 
+const (
+	defaultClientRetryMaxAttempts = 3
+	defaultClientRetryBackoff     = 100 * time.Millisecond
+)
+
+// defaultClientRetryExcludedFields are the fields of the engine with side
+// effects, see Config.ClientRetryExcludedFields.
+var defaultClientRetryExcludedFields = []string{
+	"export",
+	"prune",
+	"publish",
+	"returnError",
+	"returnValue",
+	"serve",
+	"start",
+	"stop",
+	"sync",
+	"terminal",
+	"up",
+}
+
 func GoTemplateFuncs(
 	ctx context.Context,
 	schema *introspection.Schema,
@@ -26,8 +49,10 @@ func GoTemplateFuncs(
 	fset *token.FileSet,
 	pass int,
 ) template.FuncMap {
+	formatName := NameFormatter(cfg)
 	return goTemplateFuncs{
-		CommonFunctions: generator.NewCommonFunctions(schemaVersion, &FormatTypeFunc{}),
+		CommonFunctions: generator.NewCommonFunctions(schemaVersion, &FormatTypeFunc{formatName: formatName}),
+		formatName:      formatName,
 		ctx:             ctx,
 		cfg:             cfg,
 		modulePkg:       pkg,
@@ -40,6 +65,7 @@ func GoTemplateFuncs(
 
 type goTemplateFuncs struct {
 	*generator.CommonFunctions
+	formatName    func(string) string
 	ctx           context.Context
 	cfg           generator.Config
 	modulePkg     *packages.Package
@@ -67,38 +93,149 @@ func (funcs goTemplateFuncs) FuncMap() template.FuncMap {
 		"CheckVersionCompatibility": funcs.CheckVersionCompatibility,
 
 		// go specific
-		"Comment":                 funcs.comment,
-		"FormatDeprecation":       funcs.formatDeprecation,
-		"FormatExperimental":      funcs.formatExperimental,
-		"FormatName":              formatName,
-		"FormatEnum":              funcs.formatEnum,
-		"SortEnumFields":          funcs.sortEnumFields,
-		"FieldOptionsStructName":  funcs.fieldOptionsStructName,
-		"FieldFunction":           funcs.fieldFunction,
-		"IsArgOptional":           funcs.isArgOptional,
-		"HasOptionals":            funcs.hasOptionals,
-		"IsEnum":                  funcs.isEnum,
-		"IsPointer":               funcs.isPointer,
-		"FormatArrayField":        funcs.formatArrayField,
-		"FormatArrayToSingleType": funcs.formatArrayToSingleType,
-		"IsPartial":               funcs.isPartial,
-		"IsModuleCode":            funcs.isModuleCode,
-		"IsStandaloneClient":      funcs.isStandaloneClient,
-		"ModuleMainSrc":           funcs.moduleMainSrc,
-		"ModuleRelPath":           funcs.moduleRelPath,
-		"Dependencies":            funcs.Dependencies,
-		"HasLocalDependencies":    funcs.HasLocalDependencies,
+		"Comment":                    funcs.comment,
+		"IndentedComment":            funcs.indentedComment,
+		"FormatDeprecation":          funcs.formatDeprecation,
+		"FormatExperimental":         funcs.formatExperimental,
+		"FormatNullability":          funcs.formatNullability,
+		"FormatName":                 funcs.formatName,
+		"FormatArgName":              formatArgName,
+		"FormatEnum":                 funcs.formatEnum,
+		"SortEnumFields":             funcs.sortEnumFields,
+		"FieldOptionsStructName":     funcs.fieldOptionsStructName,
+		"FieldFunction":              funcs.fieldFunction,
+		"InterfaceMethod":            funcs.interfaceMethod,
+		"FieldCallArgs":              funcs.fieldCallArgs,
+		"MockMethod":                 funcs.mockMethod,
+		"MockedObject":               funcs.mockedObject,
+		"ArgGuards":                  funcs.argGuards,
+		"PaginatedNode":              funcs.paginatedNode,
+		"PaginatedFunction":          funcs.paginatedFunction,
+		"CursorType":                 funcs.cursorType,
+		"CursorArgType":              funcs.cursorArgType,
+		"ConnectionCursor":           funcs.connectionCursor,
+		"LogStreamEntry":             funcs.logStreamEntry,
+		"LogStreamFunction":          funcs.logStreamFunction,
+		"LogEvent":                   funcs.logEvent,
+		"LogEntryFields":             generator.LogEntryFields,
+		"PaginationConvention":       funcs.paginationConvention,
+		"TrimPrefix":                 strings.TrimPrefix,
+		"GenerateMocks":              funcs.generateMocks,
+		"GenerateInputConstructors":  funcs.generateInputConstructors,
+		"SelectableFields":           funcs.selectableFields,
+		"GeneratePathAccessors":      funcs.generatePathAccessors,
+		"PathFields":                 funcs.pathFields,
+		"ModuleNamespaces":           funcs.moduleNamespaces,
+		"PreviewType":                funcs.previewType,
+		"NamespaceMethod":            funcs.namespaceMethod,
+		"VariablesStruct":            funcs.variablesStruct,
+		"VariablesMethod":            funcs.variablesMethod,
+		"ContextlessShim":            funcs.contextlessShim,
+		"RequiredInputFields":        funcs.requiredInputFields,
+		"InputFieldType":             funcs.inputFieldType,
+		"ValidationTag":              funcs.validationTag,
+		"TypesOnly":                  funcs.typesOnly,
+		"SplitByType":                funcs.splitByType,
+		"ObjectStructName":           funcs.objectStructName,
+		"FormatIfaceImplName":        formatIfaceImplName,
+		"IsArgOptional":              funcs.isArgOptional,
+		"HasOptionals":               funcs.hasOptionals,
+		"IsEnum":                     funcs.isEnum,
+		"IsPointer":                  funcs.isPointer,
+		"FormatArrayField":           funcs.formatArrayField,
+		"FormatFieldType":            funcs.formatFieldType,
+		"OptionalType":               funcs.optionalType,
+		"FormatArrayToSingleType":    funcs.formatArrayToSingleType,
+		"IsPartial":                  funcs.isPartial,
+		"IsModuleCode":               funcs.isModuleCode,
+		"IsStandaloneClient":         funcs.isStandaloneClient,
+		"ModuleMainSrc":              funcs.moduleMainSrc,
+		"ModuleRelPath":              funcs.moduleRelPath,
+		"Dependencies":               funcs.Dependencies,
+		"HasLocalDependencies":       funcs.HasLocalDependencies,
+		"ServeDependencies":          funcs.ServeDependencies,
+		"ReuseConnection":            funcs.reuseConnection,
+		"LazyConnect":                funcs.lazyConnect,
+		"GeneratePluggableTransport": funcs.generatePluggableTransport,
+		"GenerateDryRunTransport":    funcs.generateDryRunTransport,
+		"GenerateRequestHooks":       funcs.generateRequestHooks,
+		"GenerateClientRetry":        funcs.generateClientRetry,
+		"GenerateMetrics":            funcs.generateMetrics,
+		"GenerateOperationNames":     funcs.generateOperationNames,
+		"GenerateContextLabels":      funcs.generateContextLabels,
+		"GenerateResponseValidation": funcs.generateResponseValidation,
+		"OperationName":              generator.OperationName,
+		"GenerateErrorCodes":         funcs.generateErrorCodes,
+		"ErrorCodes":                 funcs.errorCodes,
+		"GenerateConnectionPool":     funcs.generateConnectionPool,
+		"ConnectionPoolSize":         funcs.connectionPoolSize,
+		"GenerateFieldCache":         funcs.generateFieldCache,
+		"IDLoader":                   funcs.idLoader,
+		"IsCachedField":              funcs.isCachedField,
+		"ClientRetryMaxAttempts":     funcs.clientRetryMaxAttempts,
+		"ClientRetryBackoff":         funcs.clientRetryBackoff,
+		"ClientRetryExcludedFields":  funcs.clientRetryExcludedFields,
+		"DefaultOperationTimeout":    funcs.defaultOperationTimeout,
+		"MaxQueryDepth":              funcs.maxQueryDepth,
+		"IncludeQueryInErrors":       funcs.includeQueryInErrors,
+		"GenerateSession":            funcs.generateSession,
+		"GeneratePing":               funcs.generatePing,
+		"GenerateRawQuery":           funcs.generateRawQuery,
+		"GenerateIntrospectMethod":   funcs.generateIntrospectMethod,
+		"IntrospectionQuery":         funcs.introspectionQuery,
+		"GenerateBatching":           funcs.generateBatching,
+		"GenerateTracing":            funcs.generateTracing,
+		"JSONImport":                 funcs.jsonImport,
+		"ScalarSerializer":           funcs.scalarSerializer,
+		"ScalarImports":              funcs.scalarImports,
+		"GoTarget":                   funcs.goTarget,
+		"GuardRequiredArgs":          funcs.guardRequiredArgs,
+		"GoPlatformSplit":            funcs.goPlatformSplit,
+		"EmbedSchema":                funcs.embedSchema,
+		"IntrospectionJSON":          funcs.introspectionJSON,
 	}
 }
 
 // comments out a string
 // Example: `hello\nworld` -> `// hello\n// world\n`
 func (funcs goTemplateFuncs) comment(s string) string {
+	return funcs.indentedComment(0, s)
+}
+
+// goTabWidth is the width of the tabs indenting the generated code, as
+// counted by gofmt, when wrapping the doc comments.
+const goTabWidth = 8
+
+// indentedComment comments out a string emitted at the given depth of
+// indentation, e.g. 1 for the doc of a struct field, so that the indentation
+// counts in Config.DocCommentWrap.
+func (funcs goTemplateFuncs) indentedComment(depth int, s string) string {
 	if s == "" {
 		return ""
 	}
 
-	lines := strings.Split(s, "\n")
+	width := funcs.cfg.DocCommentWrap
+	if width > 0 {
+		width = max(width-depth*goTabWidth-len("// "), 1)
+	}
+
+	// markdown links are rendered as doc links, defined at the end of the
+	// comment, while bare URLs are already links in doc comments
+	s, links := generator.ReplaceMarkdownLinks(s, func(link generator.DocLink) string {
+		return "[" + link.Text + "]"
+	})
+
+	lines := strings.Split(generator.WrapText(s, width), "\n")
+	if len(links) > 0 {
+		lines = append(lines, "")
+		defined := map[string]bool{}
+		for _, link := range links {
+			if !defined[link.Text] {
+				defined[link.Text] = true
+				lines = append(lines, "["+link.Text+"]: "+link.URL)
+			}
+		}
+	}
 
 	for i, l := range lines {
 		lines[i] = "// " + l
@@ -112,6 +249,13 @@ func (funcs goTemplateFuncs) formatDeprecation(s string) string {
 	return funcs.formatHelper("Deprecated", s)
 }
 
+// formatNullability comments out the nullability annotation of a field, with
+// Config.AnnotateNullability.
+// Example: `String!` -> `// (non-null)`
+func (funcs goTemplateFuncs) formatNullability(ref *introspection.TypeRef) string {
+	return funcs.comment(generator.NullabilityAnnotation(funcs.cfg, ref))
+}
+
 func (funcs goTemplateFuncs) formatExperimental(s string) string {
 	return funcs.formatHelper("Experimental", s)
 }
@@ -122,7 +266,7 @@ func (funcs goTemplateFuncs) formatHelper(name string, s string) string {
 	for _, match := range matches {
 		replacement := strings.TrimPrefix(match, "`")
 		replacement = strings.TrimSuffix(replacement, "`")
-		replacement = formatName(replacement)
+		replacement = funcs.formatName(replacement)
 		s = strings.ReplaceAll(s, match, replacement)
 	}
 	return funcs.comment(name + ": " + s)
@@ -146,6 +290,22 @@ func (funcs goTemplateFuncs) isPointer(t introspection.InputValue) (bool, error)
 	return strings.Index(representation, "*") == 0, err
 }
 
+// NameFormatter returns the function formatting GraphQL names into Go
+// identifiers, applying the name transform of the config to the standalone
+// client if set. Module code keeps the default formatting, since the runtime
+// glue refers to the client by its default names.
+func NameFormatter(cfg generator.Config) func(string) string {
+	if cfg.NameTransform == nil || cfg.ModuleName != "" {
+		return formatName
+	}
+	return func(s string) string {
+		if s == generator.QueryStructName {
+			return generator.QueryStructClientName
+		}
+		return cfg.NameTransform(s)
+	}
+}
+
 // formatName formats a GraphQL name (e.g. object, field, arg) into a Go equivalent
 // Example: `fooId` -> `FooID`
 func formatName(s string) string {
@@ -158,6 +318,17 @@ func formatName(s string) string {
 	return lintName(s)
 }
 
+// formatArgName formats a GraphQL name into an unexported Go identifier, e.g.
+// a parameter, suffixed with `_` if it's a Go keyword. The GraphQL name is
+// still used in the queries.
+// Example: `address` -> `address`, `type` -> `type_`
+func formatArgName(s string) string {
+	if token.IsKeyword(s) {
+		return s + "_"
+	}
+	return s
+}
+
 // formatEnum formats a GraphQL Enum value into a Go equivalent
 // Example: `FOO_VALUE` -> `FooValue`, `FooValue` -> `FooValue`
 func (funcs goTemplateFuncs) formatEnum(parent string, s string) string {
@@ -180,7 +351,7 @@ func (funcs goTemplateFuncs) formatArrayField(fields []*introspection.Field) str
 	result := []string{}
 
 	for _, f := range fields {
-		result = append(result, fmt.Sprintf("%s: &fields[i].%s", f.Name, funcs.ToUpperCase(f.Name)))
+		result = append(result, fmt.Sprintf("%s: &fields[i].%s", formatArgName(f.Name), funcs.ToUpperCase(f.Name)))
 	}
 
 	return strings.Join(result, ", ")
@@ -202,9 +373,9 @@ func (funcs goTemplateFuncs) fieldOptionsStructName(f introspection.Field, scope
 		scope += "."
 	}
 	if f.ParentObject.Name == generator.QueryStructName {
-		return scope + formatName(f.Name) + "Opts"
+		return scope + funcs.formatName(f.Name) + "Opts"
 	}
-	return scope + formatName(f.ParentObject.Name) + formatName(f.Name) + "Opts"
+	return scope + funcs.formatName(f.ParentObject.Name) + funcs.formatName(f.Name) + "Opts"
 }
 
 // hasOptionals returns true if a field has optional arguments
@@ -241,18 +412,66 @@ func (funcs goTemplateFuncs) fieldFunction(f introspection.Field, topLevel bool,
 	// 	}
 	// }
 
-	structName := formatName(f.ParentObject.Name)
+	structName := funcs.objectStructName(*f.ParentObject)
 	signature := "func "
 	if !topLevel {
 		signature += `(r *` + structName + `) `
 	}
-	signature += formatName(f.Name)
+	signature += funcs.formatName(f.Name)
 
 	// Generate arguments
 	args := []string{}
 	if f.TypeRef.IsScalar() || f.TypeRef.IsList() {
 		args = append(args, "ctx context.Context")
 	}
+	fieldArgs, err := funcs.fieldArgs(f, scopes...)
+	if err != nil {
+		return "", err
+	}
+	args = append(args, fieldArgs...)
+	signature += "(" + strings.Join(args, ", ") + ")"
+
+	retType, err := funcs.fieldReturnType(f, supportsVoid, scopes...)
+	if err != nil {
+		return "", err
+	}
+	signature += " " + retType
+
+	return signature, nil
+}
+
+// fieldReturnType returns the return type of the function generated for a
+// field.
+// Example: `contents: String!` -> `(string, error)`
+func (funcs goTemplateFuncs) fieldReturnType(f introspection.Field, supportsVoid bool, scopes ...string) (string, error) {
+	retType, err := funcs.FormatReturnType(f, scopes...)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case supportsVoid && f.TypeRef.IsVoid():
+		retType = "error"
+	case isOptionalValue(f.TypeRef) && !funcs.ConvertID(f):
+		retType, err = funcs.optionalValueType(retType, scopes...)
+		if err != nil {
+			return "", err
+		}
+		retType = fmt.Sprintf("(%s, error)", retType)
+	case f.TypeRef.IsScalar() || f.TypeRef.IsList():
+		retType = fmt.Sprintf("(%s, error)", retType)
+	case f.TypeRef.IsInterface():
+		// the concrete implementation behind an interface is already a pointer
+	default:
+		retType = "*" + retType
+	}
+	return retType, nil
+}
+
+// fieldArgs returns the arguments of the function generated for a field,
+// with the required arguments followed by the options
+// Example: `withExec(args: [String!]!, expand: Boolean)` -> `args []string, opts ...ContainerWithExecOpts`
+func (funcs goTemplateFuncs) fieldArgs(f introspection.Field, scopes ...string) ([]string, error) {
+	args := []string{}
 	for _, arg := range f.Args {
 		if funcs.isArgOptional(arg) {
 			continue
@@ -263,15 +482,15 @@ func (funcs goTemplateFuncs) fieldFunction(f introspection.Field, topLevel bool,
 		if f.ParentObject.Name == generator.QueryStructName && arg.Name == "id" {
 			outType, err := funcs.FormatOutputType(arg.TypeRef, scopes...)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			args = append(args, fmt.Sprintf("%s %s", arg.Name, outType))
+			args = append(args, fmt.Sprintf("%s %s", formatArgName(arg.Name), outType))
 		} else {
 			inType, err := funcs.FormatInputType(arg.TypeRef, scopes...)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			args = append(args, fmt.Sprintf("%s %s", arg.Name, inType))
+			args = append(args, fmt.Sprintf("%s %s", formatArgName(arg.Name), inType))
 		}
 	}
 
@@ -282,23 +501,672 @@ func (funcs goTemplateFuncs) fieldFunction(f introspection.Field, topLevel bool,
 			fmt.Sprintf("opts ...%s", funcs.fieldOptionsStructName(f, scopes...)),
 		)
 	}
-	signature += "(" + strings.Join(args, ", ") + ")"
+	return args, nil
+}
 
-	retType, err := funcs.FormatReturnType(f, scopes...)
+// paginatedNode returns the type of the nodes of the connection returned by
+// a field, if pagination helpers are enabled
+func (funcs goTemplateFuncs) paginatedNode(f introspection.Field) *introspection.Type {
+	if !funcs.cfg.GeneratePaginationHelpers {
+		return nil
+	}
+	return funcs.paginationConvention().PaginatedNode(funcs.schema, f)
+}
+
+func (funcs goTemplateFuncs) paginationConvention() generator.PaginationConvention {
+	return funcs.cfg.PaginationConvention.WithDefaults()
+}
+
+// paginatedFunction returns the signature of the helper paging through the
+// connection returned by a field
+// Example: `users(after: String): UserConnection!` -> `func (r *Client) UsersAll(ctx context.Context, opts ...UsersOpts) iter.Seq2[*User, error]`
+func (funcs goTemplateFuncs) paginatedFunction(f introspection.Field, node introspection.Type) (string, error) {
+	args, err := funcs.fieldArgs(f)
 	if err != nil {
 		return "", err
 	}
+	args = append([]string{"ctx context.Context"}, args...)
+
+	return fmt.Sprintf("func (r *%s) %sAll(%s) iter.Seq2[*%s, error]",
+		funcs.objectStructName(*f.ParentObject),
+		funcs.formatName(f.Name),
+		strings.Join(args, ", "),
+		funcs.formatName(node.Name),
+	), nil
+}
+
+// argGuards returns the checks of the required arguments of a field, failing
+// before the request is sent, with Config.GenerateArgValidation and
+// Config.GuardRequiredArgs. A single check is emitted per argument, the
+// RequiredArgError one if both are enabled.
+// Example: `from(address: String!)` -> `if address == "" { q = q.WithError(...) }`
+func (funcs goTemplateFuncs) argGuards(f introspection.Field, supportsVoid bool) (string, error) {
+	if !funcs.cfg.GenerateArgValidation && !funcs.cfg.GuardRequiredArgs {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for _, arg := range f.Args {
+		if funcs.isArgOptional(arg) {
+			continue
+		}
+
+		cond, guardErr, err := funcs.argGuard(f, arg)
+		if err != nil {
+			return "", err
+		}
+		if cond == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\nif %s {\n%s\n}", cond, funcs.argFailure(f, supportsVoid, guardErr))
+	}
+	return b.String(), nil
+}
+
+// argGuard returns the condition under which a required argument of a field
+// is rejected, and the error it is rejected with, or an empty condition if
+// it isn't checked.
+//
+// With Config.GuardRequiredArgs, the arguments whose zero value would be sent
+// as is, the strings, enums, custom scalars and lists, are rejected with a
+// RequiredArgError if zero. The numbers and booleans are not checked, their
+// zero value being a valid one, nor are the pointers, already checked not to
+// be nil. Otherwise, with Config.GenerateArgValidation, the string arguments
+// are rejected if empty.
+func (funcs goTemplateFuncs) argGuard(f introspection.Field, arg introspection.InputValue) (string, string, error) {
+	name := formatArgName(arg.Name)
+	fieldName := funcs.formatName(f.ParentObject.Name) + "." + funcs.formatName(f.Name)
+
+	if !funcs.cfg.GuardRequiredArgs {
+		if !generator.IsStringArg(arg) {
+			return "", "", nil
+		}
+		msg := strconv.Quote(fmt.Sprintf("%s: required argument %q is empty", fieldName, arg.Name))
+		return name + ` == ""`, "errors.New(" + msg + ")", nil
+	}
+
+	pointer, err := funcs.isPointer(arg)
+	if err != nil {
+		return "", "", err
+	}
+	guardErr := fmt.Sprintf("&RequiredArgError{Field: %q, Arg: %q}", fieldName, arg.Name)
+	switch {
+	case pointer:
+		return "", "", nil
+	case arg.TypeRef.IsList():
+		return name + " == nil", guardErr, nil
+	case generator.IsStringArg(arg):
+		return "querybuilder.IsZeroValue(" + name + ")", guardErr, nil
+	default:
+		return "", "", nil
+	}
+}
+
+// argFailure returns the statement failing a call of the function generated
+// for a field before its request, returning err, or recording it on the
+// selection q of the lazy fields, which have no error to return, so that it
+// is returned by the first request chained from them.
+func (funcs goTemplateFuncs) argFailure(f introspection.Field, supportsVoid bool, err string) string {
 	switch {
 	case supportsVoid && f.TypeRef.IsVoid():
-		retType = "error"
-	case f.TypeRef.IsScalar() || f.TypeRef.IsList():
-		retType = fmt.Sprintf("(%s, error)", retType)
+		return "return " + err
+	case funcs.ConvertID(f) || f.TypeRef.IsList():
+		return "return nil, " + err
+	case f.TypeRef.IsScalar():
+		return "return " + zeroValue(f.TypeRef) + ", " + err
 	default:
-		retType = "*" + retType
+		return "q = q.WithError(" + err + ")"
 	}
-	signature += " " + retType
+}
 
-	return signature, nil
+// zeroValue returns the zero value of a scalar
+func zeroValue(r *introspection.TypeRef) string {
+	ref := r
+	if ref.Kind == introspection.TypeKindNonNull {
+		ref = ref.OfType
+	}
+	switch introspection.Scalar(ref.Name) {
+	case introspection.ScalarInt, introspection.ScalarFloat:
+		return "0"
+	case introspection.ScalarBoolean:
+		return "false"
+	default:
+		return `""`
+	}
+}
+
+// interfaceMethod converts a field into a method of a Go interface
+// Example: `name: String!` -> `Name(ctx context.Context) (string, error)`
+func (funcs goTemplateFuncs) interfaceMethod(f introspection.Field, supportsVoid bool, scopes ...string) (string, error) {
+	signature, err := funcs.fieldFunction(f, true, supportsVoid, scopes...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(signature, "func "), nil
+}
+
+// fieldCallArgs returns the arguments to forward a call to the function
+// generated for a field, matching the signature from fieldFunction.
+// Example: `withExec(args: [String!]!, expand: Boolean)` -> `args, opts...`
+func (funcs goTemplateFuncs) fieldCallArgs(f introspection.Field) string {
+	args := []string{}
+	if f.TypeRef.IsScalar() || f.TypeRef.IsList() {
+		args = append(args, "ctx")
+	}
+	for _, arg := range f.Args {
+		if funcs.isArgOptional(arg) {
+			continue
+		}
+		args = append(args, formatArgName(arg.Name))
+	}
+	if funcs.hasOptionals(f.Args) {
+		args = append(args, "opts...")
+	}
+	return strings.Join(args, ", ")
+}
+
+// mockMethod converts a field into a method of the interface of its object in
+// the mock package, returning the objects as the interfaces of that package so
+// that mocks can be chained, and all the other types as the ones of the client.
+// Example: `withExec(args: [String!]!): Container!` -> `WithExec(args []string, opts ...dagger.ContainerWithExecOpts) Container`
+func (funcs goTemplateFuncs) mockMethod(f introspection.Field, supportsVoid bool) (string, error) {
+	signature, err := funcs.interfaceMethod(f, supportsVoid, "dagger")
+	if err != nil || funcs.mockedObject(f) == "" {
+		return signature, err
+	}
+	retType, err := funcs.fieldReturnType(f, supportsVoid, "dagger")
+	if err != nil {
+		return "", err
+	}
+	mockType, err := funcs.fieldReturnType(f, supportsVoid)
+	if err != nil {
+		return "", err
+	}
+	// the interfaces are returned as is, rather than through a pointer
+	mockType = strings.Replace(mockType, "*", "", 1)
+	return strings.TrimSuffix(signature, retType) + mockType, nil
+}
+
+// mockedObject returns the name of the object a field returns, directly, in a
+// list or as its converted ID, which has an interface in the mock package, or
+// an empty string if it doesn't return one.
+// Example: `sync: ContainerID!` -> `Container`
+func (funcs goTemplateFuncs) mockedObject(f introspection.Field) string {
+	if funcs.ConvertID(f) {
+		return funcs.formatName(f.ParentObject.Name)
+	}
+	ref := f.TypeRef
+	for ref.OfType != nil {
+		ref = ref.OfType
+	}
+	if ref.Kind != introspection.TypeKindObject {
+		return ""
+	}
+	return funcs.formatName(ref.Name)
+}
+
+// objectStructName returns the name of the struct generated for an object.
+// GraphQL interfaces are generated as a Go interface, so the struct backing
+// them is named after the interface but kept private.
+// Example: `Container` -> `Container`, `Node` (interface) -> `nodeImpl`
+func (funcs goTemplateFuncs) objectStructName(t introspection.Type) string {
+	if t.Kind == introspection.TypeKindInterface {
+		return formatIfaceImplName(t.Name)
+	}
+	return funcs.formatName(t.Name)
+}
+
+// generateMocks returns true if mocks of the client should be generated
+func (funcs goTemplateFuncs) generateMocks() bool {
+	return funcs.cfg.GenerateMocks
+}
+
+// reuseConnection returns true if the standalone client should have a
+// constructor reusing an existing connection, which is its only one with a Go
+// target
+func (funcs goTemplateFuncs) reuseConnection() bool {
+	return funcs.cfg.ReuseConnection || funcs.cfg.GoTarget != ""
+}
+
+// lazyConnect returns true if Connect of the standalone client should defer
+// connecting to the engine to the first request
+func (funcs goTemplateFuncs) lazyConnect() bool {
+	return funcs.cfg.LazyConnect && funcs.cfg.GoTarget == ""
+}
+
+func (funcs goTemplateFuncs) generatePluggableTransport() bool {
+	return funcs.cfg.GeneratePluggableTransport
+}
+
+func (funcs goTemplateFuncs) generateDryRunTransport() bool {
+	return funcs.cfg.GenerateDryRunTransport
+}
+
+// goTarget returns the platform the client is generated for, empty for the
+// native ones
+func (funcs goTemplateFuncs) goTarget() string {
+	return funcs.cfg.GoTarget
+}
+
+// goPlatformSplit returns true if the platform-specific helpers of the
+// standalone client should be generated in their own files
+func (funcs goTemplateFuncs) goPlatformSplit() bool {
+	return funcs.cfg.GoPlatformSplit && funcs.cfg.ClientOnly && !funcs.cfg.TypesOnly
+}
+
+// guardRequiredArgs returns true if the generated code should check that the
+// required arguments are not zero
+func (funcs goTemplateFuncs) guardRequiredArgs() bool {
+	return funcs.cfg.GuardRequiredArgs
+}
+
+// embedSchema returns true if the introspection of the schema should be
+// embedded in the generated code
+func (funcs goTemplateFuncs) embedSchema() bool {
+	return funcs.cfg.EmbedSchema
+}
+
+// introspectionJSON returns the introspection of the schema as a Go string
+// literal
+func (funcs goTemplateFuncs) introspectionJSON() (string, error) {
+	dt, err := generator.IntrospectionJSON(funcs.schema, funcs.schemaVersion)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Quote(string(dt)), nil
+}
+
+// selectableFields returns the fields of an object that can be fetched
+// together by its SelectFields helper, if selectors are enabled: the scalar
+// fields and lists of scalars without arguments
+func (funcs goTemplateFuncs) selectableFields(t introspection.Type) []*introspection.Field {
+	if !funcs.cfg.GenerateSelectors || t.Name == generator.QueryStructName {
+		return nil
+	}
+	var fields []*introspection.Field
+	for _, f := range t.Fields {
+		if len(f.Args) > 0 || f.TypeRef.IsVoid() {
+			continue
+		}
+		if f.TypeRef.IsScalar() || (f.TypeRef.IsList() && !funcs.IsListOfObject(f.TypeRef)) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// generatePathAccessors returns true if the objects should have a typed path
+// to their nested fields
+func (funcs goTemplateFuncs) generatePathAccessors() bool {
+	return funcs.cfg.GeneratePathAccessors
+}
+
+// pathFields returns the fields of an object that can be selected from a path
+// to it: the ones without required arguments, returning an object, scalars or
+// enums
+func (funcs goTemplateFuncs) pathFields(t introspection.Type) []*introspection.Field {
+	var fields []*introspection.Field
+	for _, f := range t.Fields {
+		if f.TypeRef.IsVoid() || slices.ContainsFunc(f.Args, func(arg introspection.InputValue) bool {
+			return !funcs.isArgOptional(arg)
+		}) {
+			continue
+		}
+		if f.TypeRef.IsObject() || f.TypeRef.IsScalar() || (f.TypeRef.IsList() && funcs.InnerType(f.TypeRef).IsScalar()) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// generateTracing returns true if the methods making a request should start a
+// span
+func (funcs goTemplateFuncs) generateTracing() bool {
+	return funcs.cfg.GenerateTracing
+}
+
+// generateBatching returns true if a batch of calls of the client should be
+// generated
+func (funcs goTemplateFuncs) generateBatching() bool {
+	return funcs.cfg.GenerateBatching
+}
+
+// generatePing returns true if a Ping method should be generated on the client
+func (funcs goTemplateFuncs) generatePing() bool {
+	return funcs.cfg.GeneratePing
+}
+
+// previewType returns the type of the preview fields of the object with
+// SeparatePreview, or nil if it has none
+func (funcs goTemplateFuncs) previewType(t introspection.Type) *introspection.Type {
+	if !funcs.cfg.SeparatePreview {
+		return nil
+	}
+	return generator.PreviewTypeOf(funcs.schema, &t)
+}
+
+// generateRawQuery returns true if a Raw method sending a query as is should
+// be generated on the client
+func (funcs goTemplateFuncs) generateRawQuery() bool {
+	return funcs.cfg.GenerateRawQuery
+}
+
+// introspectTypes are the types of the schema returned by the Introspect
+// method of the client.
+var introspectTypes = []string{
+	"Schema",
+	"SchemaType",
+	"SchemaTypeRef",
+	"SchemaField",
+	"SchemaInputValue",
+	"SchemaEnumValue",
+	"SchemaDirective",
+	"SchemaDirectiveApplication",
+}
+
+// generateIntrospectMethod returns true if an Introspect method returning the
+// live schema should be generated on the client. It fails if its types
+// collide with the types of the schema.
+func (funcs goTemplateFuncs) generateIntrospectMethod() (bool, error) {
+	if !funcs.cfg.GenerateIntrospectMethod {
+		return false, nil
+	}
+	for _, name := range introspectTypes {
+		if funcs.schema.Types.Get(name) != nil {
+			return false, fmt.Errorf("introspected type %s collides with a type of the schema", name)
+		}
+	}
+	return true, nil
+}
+
+// introspectionQuery returns the introspection query as a Go string literal.
+func (funcs goTemplateFuncs) introspectionQuery() string {
+	if strings.Contains(introspection.Query, "`") {
+		return strconv.Quote(introspection.Query)
+	}
+	return "`" + introspection.Query + "`"
+}
+
+// generateSession returns true if a session wrapping the client should be
+// generated
+func (funcs goTemplateFuncs) generateSession() bool {
+	return funcs.cfg.GenerateSession
+}
+
+// jsonImport returns the import spec of the JSON package used by the
+// generated code, see Config.GoJSONPackage
+func (funcs goTemplateFuncs) jsonImport() string {
+	if funcs.cfg.GoJSONPackage == "" || funcs.cfg.GoJSONPackage == "encoding/json" {
+		return strconv.Quote("encoding/json")
+	}
+	return "json " + strconv.Quote(funcs.cfg.GoJSONPackage)
+}
+
+// scalarSerializer returns the serializer of a custom scalar from
+// Config.ScalarSerializers, or nil if it's a string.
+func (funcs goTemplateFuncs) scalarSerializer(t introspection.Type) *generator.ScalarSerializer {
+	serializer, ok := funcs.cfg.ScalarSerializers[t.Name]
+	if !ok {
+		return nil
+	}
+	return &serializer
+}
+
+// scalarImports returns the sorted packages of the scalar serializers
+func (funcs goTemplateFuncs) scalarImports() []string {
+	var imports []string
+	for _, serializer := range funcs.cfg.ScalarSerializers {
+		if serializer.Import != "" && !slices.Contains(imports, serializer.Import) {
+			imports = append(imports, serializer.Import)
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
+
+// generateRequestHooks returns true if the standalone client should call a
+// hook before each request
+func (funcs goTemplateFuncs) generateRequestHooks() bool {
+	return funcs.cfg.GenerateRequestHooks
+}
+
+// generateMetrics returns true if the standalone client should count the
+// requests of its methods and their errors
+func (funcs goTemplateFuncs) generateMetrics() bool {
+	return funcs.cfg.GenerateMetrics && funcs.cfg.ClientOnly
+}
+
+// generateOperationNames returns true if the standalone client should name
+// the operations of the requests of its methods
+func (funcs goTemplateFuncs) generateOperationNames() bool {
+	return funcs.cfg.GenerateOperationNames && funcs.cfg.ClientOnly
+}
+
+// generateContextLabels returns true if the standalone client should attach
+// the labels of the context of its requests to them
+func (funcs goTemplateFuncs) generateContextLabels() bool {
+	return funcs.cfg.GenerateContextLabels && funcs.cfg.ClientOnly
+}
+
+// generateResponseValidation returns true if the standalone client should
+// check the data of its responses against the embedded schema
+func (funcs goTemplateFuncs) generateResponseValidation() bool {
+	return funcs.cfg.GenerateResponseValidation && funcs.cfg.ClientOnly
+}
+
+// generateClientRetry returns true if the standalone client should retry the
+// requests failing with a transient error
+func (funcs goTemplateFuncs) generateClientRetry() bool {
+	return funcs.cfg.GenerateClientRetry
+}
+
+// generateConnectionPool returns true if the standalone client should have
+// constructors taking the options of its connection pool
+func (funcs goTemplateFuncs) generateConnectionPool() bool {
+	return funcs.cfg.GenerateConnectionPool
+}
+
+// connectionPoolSize returns the default maximum number of concurrent
+// requests of the standalone client, 0 for no limit
+func (funcs goTemplateFuncs) connectionPoolSize() int {
+	return funcs.cfg.ConnectionPoolSize
+}
+
+// idLoader returns the field of the query loading an object from its ID, if
+// the standalone client should generate its TFromID function, or nil
+// otherwise. It fails if the name of the function collides with a type of the
+// schema.
+func (funcs goTemplateFuncs) idLoader(t *introspection.Type) (*introspection.Field, error) {
+	if !funcs.cfg.GenerateIDLoaders || !funcs.cfg.ClientOnly || funcs.cfg.TypesOnly {
+		return nil, nil
+	}
+	loader := generator.IDLoader(funcs.schema, t)
+	if loader == nil {
+		return nil, nil
+	}
+	if name := funcs.formatName(t.Name) + "FromID"; funcs.schema.Types.Get(name) != nil {
+		return nil, fmt.Errorf("id loader %s collides with a type of the schema", name)
+	}
+	return loader, nil
+}
+
+// generateFieldCache returns true if the standalone client should fetch the
+// cacheable fields once per query
+func (funcs goTemplateFuncs) generateFieldCache() bool {
+	return funcs.cfg.GenerateFieldCache && funcs.cfg.ClientOnly
+}
+
+// isCachedField returns true if the value of the field should be fetched
+// through the field cache of the standalone client
+func (funcs goTemplateFuncs) isCachedField(f introspection.Field) bool {
+	return funcs.generateFieldCache() && generator.IsCacheableField(f.ParentObject.Name, &f, funcs.cfg.CacheableFields)
+}
+
+// clientRetryMaxAttempts returns the default maximum number of attempts of a
+// request of the standalone client
+func (funcs goTemplateFuncs) clientRetryMaxAttempts() int {
+	if funcs.cfg.ClientRetryMaxAttempts == 0 {
+		return defaultClientRetryMaxAttempts
+	}
+	return funcs.cfg.ClientRetryMaxAttempts
+}
+
+// clientRetryExcludedFields returns the sorted names of the fields whose
+// requests the standalone client doesn't retry by default
+func (funcs goTemplateFuncs) clientRetryExcludedFields() []string {
+	if len(funcs.cfg.ClientRetryExcludedFields) == 0 {
+		return defaultClientRetryExcludedFields
+	}
+	names := slices.Clone(funcs.cfg.ClientRetryExcludedFields)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// clientRetryBackoff returns the Go expression of the default wait before the
+// first retry of a request of the standalone client, e.g.
+// `100 * time.Millisecond`
+func (funcs goTemplateFuncs) clientRetryBackoff() string {
+	backoff := funcs.cfg.ClientRetryBackoff
+	if backoff == 0 {
+		backoff = defaultClientRetryBackoff
+	}
+	return formatDuration(backoff)
+}
+
+// defaultOperationTimeout returns the Go expression of the default timeout of
+// the requests of the standalone client, or an empty string if there is none
+func (funcs goTemplateFuncs) defaultOperationTimeout() string {
+	if funcs.cfg.DefaultOperationTimeout <= 0 {
+		return ""
+	}
+	return formatDuration(funcs.cfg.DefaultOperationTimeout)
+}
+
+// maxQueryDepth returns the maximum depth of the selections of the queries of
+// the standalone client, 0 for no limit
+func (funcs goTemplateFuncs) maxQueryDepth() int {
+	if !funcs.cfg.ClientOnly {
+		return 0
+	}
+	return funcs.cfg.MaxQueryDepth
+}
+
+// includeQueryInErrors returns true if the standalone client should add the
+// query and the variables of its requests to their errors
+func (funcs goTemplateFuncs) includeQueryInErrors() bool {
+	return funcs.cfg.IncludeQueryInErrors && funcs.cfg.ClientOnly
+}
+
+// formatDuration returns the Go expression of a duration, e.g.
+// `100 * time.Millisecond`
+func formatDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"time.Hour", time.Hour},
+		{"time.Minute", time.Minute},
+		{"time.Second", time.Second},
+		{"time.Millisecond", time.Millisecond},
+		{"time.Microsecond", time.Microsecond},
+	} {
+		if d%unit.duration != 0 {
+			continue
+		}
+		if d == unit.duration {
+			return unit.name
+		}
+		return fmt.Sprintf("%d * %s", d/unit.duration, unit.name)
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
+// generateInputConstructors returns true if constructors of the input types
+// should be generated
+func (funcs goTemplateFuncs) generateInputConstructors() bool {
+	return funcs.cfg.GenerateInputConstructors
+}
+
+// requiredInputFields returns the fields of an input type without default
+// value, that are the arguments of its constructor
+func (funcs goTemplateFuncs) requiredInputFields(t introspection.Type) []introspection.InputValue {
+	var fields []introspection.InputValue
+	for _, f := range t.InputFields {
+		if !funcs.isArgOptional(f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// inputFieldType formats the type of a field of an input type, as a pointer
+// if the field makes the type recursive
+func (funcs goTemplateFuncs) inputFieldType(t introspection.Type, f introspection.InputValue) (string, error) {
+	representation, err := funcs.FormatInputType(f.TypeRef)
+	if err != nil {
+		return "", err
+	}
+	if generator.IsRecursiveInputField(funcs.schema, t.Name, f) {
+		representation = "*" + representation
+	}
+	return representation, nil
+}
+
+// validationTag returns the validation tag of a field of an input type, after
+// a space to follow its other tags, or nothing if the field has no constraint
+// or GenerateValidationTags is disabled
+// Example: ` validate:"required,oneof=TCP UDP"` for a required enum field
+func (funcs goTemplateFuncs) validationTag(f introspection.InputValue) string {
+	if !funcs.cfg.GenerateValidationTags {
+		return ""
+	}
+	key := funcs.cfg.ValidationTagKey
+	if key == "" {
+		key = generator.DefaultValidationTagKey
+	}
+
+	var rules []string
+	ref := f.TypeRef
+	if ref.Kind == introspection.TypeKindNonNull {
+		ref = ref.OfType
+	}
+	if !funcs.isArgOptional(f) {
+		// the zero value of a Boolean, Int or Float can't tell whether it's set
+		switch introspection.Scalar(ref.Name) {
+		case introspection.ScalarBoolean, introspection.ScalarInt, introspection.ScalarFloat:
+		default:
+			rules = append(rules, "required")
+		}
+	}
+	if ref.Kind == introspection.TypeKindEnum {
+		if t := funcs.schema.Types.Get(ref.Name); t != nil && len(t.EnumValues) > 0 {
+			if len(rules) == 0 {
+				rules = append(rules, "omitempty")
+			}
+			values := make([]string, len(t.EnumValues))
+			for i, v := range t.EnumValues {
+				values[i] = v.Name
+			}
+			rules = append(rules, "oneof="+strings.Join(values, " "))
+		}
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %s:%q", key, strings.Join(rules, ","))
+}
+
+// splitByType returns true if each object type should be generated in its
+// own file
+func (funcs goTemplateFuncs) splitByType() bool {
+	return funcs.cfg.SplitByType
+}
+
+// typesOnly returns true if only the data types of the standalone client
+// should be generated, without the query builder, which standalone code
+// implies
+func (funcs goTemplateFuncs) typesOnly() bool {
+	return (funcs.cfg.TypesOnly || funcs.cfg.Standalone) && !funcs.isModuleCode()
 }
 
 // isPartial determines if we are in a first-pass or not
@@ -0,0 +1,209 @@
+package templates_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+)
+
+func TestGenerateRequiredArgGuards(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GuardRequiredArgs: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, `	q := r.query.Select("from")
+	if querybuilder.IsZeroValue(address) {
+		q = q.WithError(&RequiredArgError{Field: "Container.From", Arg: "address"})
+	}
+`)
+	require.Contains(t, src, `	if args == nil {
+		q = q.WithError(&RequiredArgError{Field: "Container.WithExec", Arg: "args"})
+	}
+`)
+	// the zero value of a number is a valid one
+	require.NotContains(t, src, `Arg: "port"`)
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{ReuseConnection: true, SkipServeDependencies: true}, "basic.json"), gogenerator.ClientGenFile), "RequiredArgError")
+
+	// a single check is emitted with the argument validation
+	both := testutil.ReadFile(t, generateFixture(t, generator.Config{GuardRequiredArgs: true, GenerateArgValidation: true}, "basic.json"), gogenerator.ClientGenFile)
+	require.Equal(t, 1, strings.Count(both, `Field: "Container.From", Arg: "address"`))
+	require.NotContains(t, both, "is empty")
+
+	// call the client with zero values
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith("")
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = client.Container().EnvVariable(ctx, "")
+	var argErr *RequiredArgError
+	fmt.Println(errors.As(err, &argErr), err)
+
+	// the lazy fields fail at the first request chained from them
+	_, err = client.Container().From("").WithExec([]string{"true"}).ID(ctx)
+	fmt.Println(errors.As(err, &argErr), err)
+
+	fmt.Println(gql.queries)
+}
+`)
+	// nothing is sent
+	require.Equal(t, `true Container.EnvVariable: required argument "name" must not be the zero value
+true Container.From: required argument "address" must not be the zero value
+[]
+`, out)
+}
+
+func TestGenerateSelectors(t *testing.T) {
+	t.Run("selectors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateSelectors: true, SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, `ContainerFieldStdout   ContainerField = "stdout"`)
+		require.Regexp(t, "ExitCode +int +`json:\"exitCode\"`", src)
+		require.Contains(t, src, "func (r *Container) SelectFields(ctx context.Context, fields ...ContainerField) (*ContainerSelection, error) {")
+		require.Contains(t, src, "if err := r.query.SelectMultiple(names...).Bind(&sel).Execute(ctx); err != nil {")
+
+		// fields with arguments or returning objects are not selectable
+		require.NotContains(t, src, "ContainerFieldEnvVariable")
+		require.NotContains(t, src, "ContainerFieldFrom")
+		require.NotContains(t, src, "ClientField")
+	})
+
+	t.Run("duplicate fields", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateSelectors: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+
+		// select fields twice with a client recording the requests
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"loadContainerFromID":{"stdout":"hello","exitCode":0}}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	sel, err := client.LoadContainerFromID("ctr").SelectFields(ctx, ContainerFieldStdout, ContainerFieldExitCode, ContainerFieldStdout)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(gql.queries, sel.Stdout)
+}
+`)
+		require.Equal(t, "[query{loadContainerFromID(id:\"ctr\"){stdout exitCode}}] hello\n", out)
+	})
+
+	t.Run("no selectors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "SelectFields")
+	})
+}
+
+func TestGeneratePathAccessors(t *testing.T) {
+	t.Run("accessors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GeneratePathAccessors: true, ReuseConnection: true, SkipServeDependencies: true}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "func (r *Tree) SelectPath() TreePath {")
+		require.Contains(t, src, "func (p TreePath) Parent() TreePath {")
+		require.Contains(t, src, "func (p TreePath) Value() FieldPath[string] {")
+		// lists of objects have no path
+		require.NotContains(t, src, "func (p TreePath) Children(")
+
+		// fetch a field two levels down from a client recording the
+		// request
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"loadTreeFromID":{"parent":{"parent":{"value":"root"}}}}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	value, err := client.LoadTreeFromID("leaf").SelectPath().Parent().Parent().Value().Get(ctx)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(gql.queries, value)
+}
+`)
+		require.Equal(t, "[query{loadTreeFromID(id:\"leaf\"){parent{parent{value}}}}] root\n", out)
+	})
+
+	t.Run("no accessors", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "recursive.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "SelectPath()")
+		require.NotContains(t, src, "FieldPath")
+	})
+}
+
+func TestSeparatePreview(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{SeparatePreview: true, ReuseConnection: true, SkipServeDependencies: true}, "preview.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *Container) Preview() *ContainerPreview {")
+	require.Contains(t, src, "func (r *ContainerPreview) WithGPU(devices []string) *Container {")
+	require.Contains(t, src, "func (r *QueryPreview) GpuDevices(ctx context.Context) ([]string, error) {")
+	require.Contains(t, src, "// WARNING: they are unstable, and may change or be removed in any release.")
+	require.NotContains(t, src, "func (r *Container) WithGPU(")
+	require.NotContains(t, src, "func (r *Client) GpuDevices(")
+
+	require.Contains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "preview.json"), gogenerator.ClientGenFile), "func (r *Container) WithGPU(devices []string) *Container {")
+
+	// call a preview field with a client answering with the query
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			dt, err := json.Marshal(map[string]any{"container": map[string]any{"from": map[string]any{"withGPU": map[string]any{"stdout": req.Query}}}})
+			return string(dt), err
+		},
+	}
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	out, err := client.Container().From("alpine").Preview().WithGPU([]string{"0"}).Stdout(ctx)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
+}
+`)
+	require.Equal(t, "query{container{from(address:\"alpine\"){withGPU(devices:[\"0\"]){stdout}}}}\n", out)
+}
@@ -0,0 +1,44 @@
+package templates_test
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// loadFixture reads an introspection response from the testdata of the
+// generator.
+func loadFixture(t *testing.T, name string) (*introspection.Schema, string) {
+	t.Helper()
+	return testutil.LoadFixture(t, filepath.Join("..", "testdata", name))
+}
+
+// generateFixture renders the standalone client for the given fixture and
+// returns the generated files.
+func generateFixture(t *testing.T, cfg generator.Config, name string) fs.FS {
+	t.Helper()
+	schema, schemaVersion := loadFixture(t, name)
+	return generateSchema(t, cfg, schema, schemaVersion)
+}
+
+// generateSchema renders the standalone client for the given schema, loaded
+// by testutil.LoadSchema, and returns the generated files. The client is
+// generated as the dagger package of the modules of testutil.WritePackage.
+func generateSchema(t *testing.T, cfg generator.Config, schema *introspection.Schema, schemaVersion string) fs.FS {
+	t.Helper()
+	cfg, schema, schemaVersion = testutil.LoadSchema(t, cfg, schema, schemaVersion)
+	fsys, err := gogenerator.GenerateClientFS(context.Background(), cfg, schema, schemaVersion, gogenerator.PackageInfo{
+		PackageName:   "dagger",
+		PackageImport: testutil.ModulePath + "/dagger",
+	})
+	require.NoError(t, err)
+	return fsys
+}
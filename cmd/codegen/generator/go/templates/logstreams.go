@@ -0,0 +1,62 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// logStreamEntry returns the type of the entries of the log stream returned
+// by a field, with Config.GenerateLogStreams, or nil if the field doesn't
+// return one.
+func (funcs goTemplateFuncs) logStreamEntry(f introspection.Field) *introspection.Type {
+	if !funcs.cfg.GenerateLogStreams {
+		return nil
+	}
+	return generator.LogStreamEntry(funcs.schema, f)
+}
+
+// logStreamFunction returns the signature of the typed consumer of the log
+// stream returned by a field
+// Example: `logs: [ContainerLogEntry!]!` -> `func (r *Container) LogsStream(ctx context.Context) (<-chan ContainerLogEntryEvent, <-chan error)`
+func (funcs goTemplateFuncs) logStreamFunction(f introspection.Field, entry introspection.Type) (string, error) {
+	args, err := funcs.fieldArgs(f)
+	if err != nil {
+		return "", err
+	}
+	args = append([]string{"ctx context.Context"}, args...)
+
+	event := funcs.formatName(entry.Name) + "Event"
+	return fmt.Sprintf("func (r *%s) %sStream(%s) (<-chan %s, <-chan error)",
+		funcs.objectStructName(*f.ParentObject),
+		funcs.formatName(f.Name),
+		strings.Join(args, ", "),
+		event,
+	), nil
+}
+
+// logEvent returns the type of the events decoding the entries of a log
+// stream, with Config.GenerateLogStreams, or an empty string if no field of
+// the schema streams entries of the type. It fails if the name of the type
+// collides with a type of the schema.
+func (funcs goTemplateFuncs) logEvent(t introspection.Type) (string, error) {
+	streamed := false
+	for _, typ := range funcs.schema.Types {
+		for _, f := range typ.Fields {
+			if entry := funcs.logStreamEntry(*f); entry != nil && entry.Name == t.Name {
+				streamed = true
+			}
+		}
+	}
+	if !streamed {
+		return "", nil
+	}
+
+	name := funcs.formatName(t.Name) + "Event"
+	if funcs.schema.Types.Get(name) != nil {
+		return "", fmt.Errorf("log event type %s of %s collides with a type of the schema", name, t.Name)
+	}
+	return name, nil
+}
@@ -0,0 +1,75 @@
+package templates_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestGenerateLogStreams(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{GeneratePluggableTransport: true, SkipServeDependencies: true}, "logstreams.json"), gogenerator.ClientGenFile)
+	require.NotContains(t, src, "LogsStream")
+	require.NotContains(t, src, "ContainerLogEntryEvent")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{GenerateLogStreams: true, GeneratePluggableTransport: true, SkipServeDependencies: true}, "logstreams.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "type ContainerLogEntryEvent struct {")
+	require.Contains(t, src, "\tStream    LogStream `json:\"stream\"`\n")
+	require.Contains(t, src, "func (r *Container) LogsStream(ctx context.Context, opts ...ContainerLogsOpts) (<-chan ContainerLogEntryEvent, <-chan error) {")
+	// lists of strings aren't log streams
+	require.NotContains(t, src, "LinesStream")
+
+	t.Run("collision", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "logstreams.json")
+		schema.Types = append(schema.Types, &introspection.Type{Kind: introspection.TypeKindScalar, Name: "ContainerLogEntryEvent"})
+		generator.SetSchemaParents(schema)
+		generator.SetSchema(schema)
+
+		cfg := generator.Config{GenerateLogStreams: true, ClientOnly: true, OutputDir: t.TempDir(), SkipServeDependencies: true}
+		_, err := gogenerator.GenerateClientFS(context.Background(), cfg, schema, schemaVersion, gogenerator.PackageInfo{
+			PackageName:   "dagger",
+			PackageImport: testutil.ModulePath + "/dagger",
+		})
+		require.ErrorContains(t, err, "log event type ContainerLogEntryEvent of ContainerLogEntry collides with a type of the schema")
+	})
+
+	// consume a stream from a fake transport
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		fmt.Println(req.Query)
+		return json.Unmarshal([]byte(`+"`"+`{"container":{"logs":[
+			{"message":"hello","stream":"STDOUT","timestamp":1},
+			{"message":"oops","stream":"STDERR","timestamp":2}
+		]}}`+"`"+`), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	events, errs := client.Container().LogsStream(ctx, ContainerLogsOpts{Since: 1})
+	for event := range events {
+		fmt.Println(event.Timestamp, event.Stream == LogStreamStderr, event.Message)
+	}
+	fmt.Println(<-errs)
+}
+`)
+	require.Equal(t, "query{container{logs(since:1){message stream timestamp}}}\n1 false hello\n2 true oops\n<nil>\n", out)
+}
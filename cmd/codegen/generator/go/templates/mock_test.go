@@ -0,0 +1,118 @@
+package templates_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+)
+
+func TestGenerateMocks(t *testing.T) {
+	t.Run("mocks", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateMocks: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, "mock/mock.gen.go")
+		require.Contains(t, src, "package mock")
+		require.Contains(t, src, "func WrapContainer(v *dagger.Container) Container {")
+		require.Contains(t, src, "WithExecFunc        func(args []string, opts ...dagger.ContainerWithExecOpts) Container")
+		require.Contains(t, src, "SyncFunc            func(ctx context.Context) (Container, error)")
+		require.Contains(t, src, "EnvVariablesFunc    func(ctx context.Context) ([]EnvVariable, error)")
+		require.Contains(t, src, "return m.WithExecFunc(args, opts...)")
+	})
+
+	t.Run("chained", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateMocks: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json")
+
+		// the same code runs against chained mocks, and the client through
+		// its wrapper
+		out := testutil.RunPackage(t, testutil.WritePackage(t, map[string]string{
+			"dagger/" + gogenerator.ClientGenFile: testutil.ReadFile(t, mfs, gogenerator.ClientGenFile),
+			"mock/mock.gen.go":                    testutil.ReadFile(t, mfs, "mock/mock.gen.go"),
+			"fakeclient.go":                       testutil.FakeClient,
+			"main.go": `package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+
+	"example.com/test/dagger"
+	"example.com/test/mock"
+)
+
+func hello(ctx context.Context, client mock.Client) (string, error) {
+	ctr, err := client.Container().From("alpine").WithExec([]string{"echo", "hello"}).Sync(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ctr.Stdout(ctx)
+}
+
+func main() {
+	ctx := context.Background()
+
+	ctr := &mock.MockContainer{}
+	ctr.FromFunc = func(address string) mock.Container {
+		fmt.Println("from", address)
+		return ctr
+	}
+	ctr.WithExecFunc = func(args []string, opts ...dagger.ContainerWithExecOpts) mock.Container {
+		fmt.Println("exec", args)
+		return ctr
+	}
+	ctr.SyncFunc = func(ctx context.Context) (mock.Container, error) {
+		return ctr, nil
+	}
+	ctr.StdoutFunc = func(ctx context.Context) (string, error) {
+		return "hello from the mock", nil
+	}
+	fmt.Println(hello(ctx, &mock.MockClient{
+		ContainerFunc: func() mock.Container { return ctr },
+	}))
+
+	gql := &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			if strings.HasPrefix(req.Query, "query{loadContainerFromID(") {
+				return ` + "`" + `{"loadContainerFromID":{"stdout":"hello from the engine"}}` + "`" + `, nil
+			}
+			return ` + "`" + `{"container":{"from":{"withExec":{"sync":"ctr"}}}}` + "`" + `, nil
+		},
+	}
+	client, err := dagger.NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(hello(ctx, mock.WrapClient(client)))
+	for _, query := range gql.queries {
+		fmt.Println("sent", query)
+	}
+
+	unwrapped, ok := mock.UnwrapClient(mock.WrapClient(client))
+	fmt.Println(unwrapped == client, ok)
+	_, ok = mock.UnwrapContainer(ctr)
+	fmt.Println(ok)
+}
+`,
+		}))
+		require.Equal(t, `from alpine
+exec [echo hello]
+hello from the mock <nil>
+hello from the engine <nil>
+sent query{container{from(address:"alpine"){withExec(args:["echo","hello"]){sync}}}}
+sent query{loadContainerFromID(id:"ctr"){stdout}}
+true true
+false
+`, out)
+	})
+
+	t.Run("no mocks", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{}, "basic.json")
+		_, err := fs.Stat(mfs, "mock/mock.gen.go")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
@@ -39,6 +39,10 @@ func (funcs goTemplateFuncs) Dependencies() []generator.ModuleSourceDependencies
 	return funcs.cfg.ModuleDependencies
 }
 
+func (funcs goTemplateFuncs) ServeDependencies() bool {
+	return !funcs.cfg.SkipServeDependencies
+}
+
 func (funcs goTemplateFuncs) HasLocalDependencies() bool {
 	for _, dep := range funcs.cfg.ModuleDependencies {
 		if dep.Kind == "LOCAL_SOURCE" {
@@ -98,6 +102,7 @@ func (funcs goTemplateFuncs) moduleMainSrc() (string, error) { //nolint: gocyclo
 		pkg:        funcs.modulePkg,
 		fset:       funcs.moduleFset,
 		moduleName: funcs.cfg.ModuleName,
+		genFile:    funcs.cfg.GeneratedFileName(daggerGenFilename),
 
 		methods: make(map[string][]method),
 	}
@@ -798,6 +803,7 @@ type parseState struct {
 	pkg        *packages.Package
 	fset       *token.FileSet
 	moduleName string
+	genFile    string
 	objs       []types.Object
 
 	methods map[string][]method
@@ -1092,7 +1098,7 @@ func (ps *parseState) commentForFuncField(fnDecl *ast.FuncDecl, unpackedParams [
 
 func (ps *parseState) isDaggerGenerated(obj types.Object) bool {
 	tokenFile := ps.fset.File(obj.Pos())
-	return filepath.Base(tokenFile.Name()) == daggerGenFilename
+	return filepath.Base(tokenFile.Name()) == ps.genFile
 }
 
 /*
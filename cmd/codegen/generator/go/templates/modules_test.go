@@ -1,9 +1,12 @@
 package templates
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
 )
 
 func TestParsePragmaComment(t *testing.T) {
@@ -107,3 +110,37 @@ func TestParsePragmaComment(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentWrap(t *testing.T) {
+	funcs := goTemplateFuncs{cfg: generator.Config{DocCommentWrap: 40}}
+	desc := "Retrieves this container after executing the specified command inside it, see https://docs.dagger.io/api/ for details."
+
+	comment := funcs.comment(desc)
+	for _, line := range strings.Split(comment, "\n") {
+		require.True(t, strings.HasPrefix(line, "// "), line)
+		if !strings.Contains(line, "https://") {
+			require.LessOrEqual(t, len(line), 40, line)
+		}
+	}
+	require.Contains(t, comment, "\n// https://docs.dagger.io/api/ for\n")
+
+	comment = funcs.indentedComment(1, desc)
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.Contains(line, "https://") {
+			require.LessOrEqual(t, goTabWidth+len(line), 40, line)
+		}
+	}
+	require.Contains(t, comment, "\n// https://docs.dagger.io/api/\n")
+
+	funcs = goTemplateFuncs{}
+	require.Equal(t, "// "+desc, funcs.comment(desc))
+}
+
+func TestCommentLinks(t *testing.T) {
+	funcs := goTemplateFuncs{}
+	desc := "Mounts a cache, see [cache volumes](https://docs.dagger.io/api/cache-volumes) and https://docs.dagger.io/api/ for details. `[not](https://a.link)` is code."
+
+	require.Equal(t, `// Mounts a cache, see [cache volumes] and https://docs.dagger.io/api/ for details. `+"`[not](https://a.link)`"+` is code.
+// 
+// [cache volumes]: https://docs.dagger.io/api/cache-volumes`, funcs.comment(desc))
+}
@@ -0,0 +1,130 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// moduleNamespace groups the fields of the query coming from a module
+// dependency, with Config.NamespaceByModule.
+type moduleNamespace struct {
+	// Module is the name of the module dependency.
+	Module string
+
+	// Name is the name of the method of the client returning the namespace,
+	// e.g. ModuleA for the module-a module.
+	Name string
+
+	// StructName is the name of the type of the namespace.
+	StructName string
+
+	Methods []namespaceMethod
+}
+
+// namespaceMethod is the method of a namespace calling the method of the
+// client generated for a field.
+type namespaceMethod struct {
+	// Name is the name of the field without the name of its module, e.g.
+	// Build for the moduleABuild field of the module-a module.
+	Name string
+
+	Field *introspection.Field
+}
+
+// moduleNamespaces returns the namespaces of the module dependencies owning
+// fields of the query, known from their source map, in the order of the
+// dependencies. It fails if a name of the namespaces collides with a type or
+// a field of the query, or if two fields of a module have the same name in
+// its namespace.
+func (funcs goTemplateFuncs) moduleNamespaces(t introspection.Type) ([]moduleNamespace, error) {
+	if !funcs.cfg.NamespaceByModule || t.Name != generator.QueryStructName {
+		return nil, nil
+	}
+
+	var namespaces []moduleNamespace
+	for _, dep := range funcs.cfg.ModuleDependencies {
+		ns := moduleNamespace{
+			Module: dep.Name,
+			Name:   strcase.ToCamel(dep.Name),
+		}
+		ns.StructName = ns.Name + "Namespace"
+
+		names := map[string]string{}
+		for _, f := range t.Fields {
+			sourceMap := f.Directives.SourceMap()
+			if sourceMap == nil || strcase.ToCamel(sourceMap.Module) != ns.Name {
+				continue
+			}
+			name := namespaceMethodName(funcs.formatName(f.Name), ns.Name)
+			if other, ok := names[name]; ok {
+				return nil, fmt.Errorf("fields %s and %s of module %s are both %s in its namespace", other, f.Name, dep.Name, name)
+			}
+			names[name] = f.Name
+			ns.Methods = append(ns.Methods, namespaceMethod{Name: name, Field: f})
+		}
+		if len(ns.Methods) == 0 {
+			continue
+		}
+
+		for _, f := range t.Fields {
+			if funcs.formatName(f.Name) == ns.Name {
+				return nil, fmt.Errorf("namespace %s of module %s collides with field %s.%s", ns.Name, dep.Name, t.Name, f.Name)
+			}
+		}
+		if funcs.schema.Types.Get(ns.StructName) != nil {
+			return nil, fmt.Errorf("namespace type %s of module %s collides with a type of the schema", ns.StructName, dep.Name)
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, nil
+}
+
+// namespaceMethodName returns the name of a method without the name of its
+// namespace, unless nothing would be left of it, e.g. Build for
+// ModuleABuild in the ModuleA namespace.
+func namespaceMethodName(name, namespace string) string {
+	rest, ok := strings.CutPrefix(name, namespace)
+	if !ok || rest == "" || !unicode.IsUpper(rune(rest[0])) {
+		return name
+	}
+	return rest
+}
+
+// namespaceMethod returns the method of the namespace calling the method of
+// the client generated for the field, with the same arguments.
+// Example: `func (r *ModuleANamespace) Build(ctx context.Context, src *Directory) (string, error) {
+// return r.client.ModuleABuild(ctx, src) }`
+func (funcs goTemplateFuncs) namespaceMethod(ns moduleNamespace, m namespaceMethod, supportsVoid bool) (string, error) {
+	signature, err := funcs.fieldFunction(*m.Field, false, supportsVoid)
+	if err != nil {
+		return "", err
+	}
+	clientMethod := funcs.formatName(m.Field.Name)
+	clientPrefix := "func (r *" + funcs.objectStructName(*m.Field.ParentObject) + ") " + clientMethod + "("
+	signature, ok := strings.CutPrefix(signature, clientPrefix)
+	if !ok {
+		return "", fmt.Errorf("unexpected signature of %s.%s", m.Field.ParentObject.Name, m.Field.Name)
+	}
+	signature = "func (r *" + ns.StructName + ") " + m.Name + "(" + signature
+
+	args := []string{}
+	if m.Field.TypeRef.IsScalar() || m.Field.TypeRef.IsList() {
+		args = append(args, "ctx")
+	}
+	for _, arg := range m.Field.Args {
+		if !funcs.isArgOptional(arg) {
+			args = append(args, formatArgName(arg.Name))
+		}
+	}
+	if funcs.hasOptionals(m.Field.Args) {
+		args = append(args, "opts...")
+	}
+
+	return fmt.Sprintf("%s {\n\treturn r.client.%s(%s)\n}", signature, clientMethod, strings.Join(args, ", ")), nil
+}
@@ -0,0 +1,95 @@
+package templates_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestNamespaceByModule(t *testing.T) {
+	cfg := generator.Config{
+		NamespaceByModule: true,
+		ReuseConnection:   true,
+		ModuleDependencies: []generator.ModuleSourceDependencies{
+			{Kind: "LOCAL_SOURCE", Name: "module-a", Source: "./module-a"},
+			{Kind: "LOCAL_SOURCE", Name: "module-b", Source: "./module-b"},
+		},
+		SkipServeDependencies: true,
+	}
+	src := testutil.ReadFile(t, generateFixture(t, cfg, "namespaces.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *Client) ModuleA() *ModuleANamespace {")
+	require.Contains(t, src, "func (r *ModuleANamespace) Build(ctx context.Context, src string) (string, error) {\n\treturn r.client.ModuleABuild(ctx, src)\n}")
+	require.Contains(t, src, "func (r *ModuleBNamespace) Build(ctx context.Context, src string, opts ...ModuleBBuildOpts) (string, error) {\n\treturn r.client.ModuleBBuild(ctx, src, opts...)\n}")
+	require.Contains(t, src, "func (r *ModuleBNamespace) Version(ctx context.Context) (string, error) {")
+	// the functions of the engine aren't namespaced
+	require.Contains(t, src, "func (r *Client) Version(ctx context.Context) (string, error) {")
+
+	require.NotContains(t, testutil.ReadFile(t, generateFixture(t, generator.Config{ModuleDependencies: cfg.ModuleDependencies, SkipServeDependencies: true}, "namespaces.json"), gogenerator.ClientGenFile), "Namespace")
+
+	// call the same-named functions of both modules with a client answering
+	// with the query
+	out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &fakeClient{
+		respond: func(ctx context.Context, req *graphql.Request) (string, error) {
+			field, _, _ := strings.Cut(strings.TrimPrefix(req.Query, "query{"), "(")
+			dt, err := json.Marshal(map[string]any{field: req.Query})
+			return string(dt), err
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	a, err := client.ModuleA().Build(ctx, "a")
+	if err != nil {
+		panic(err)
+	}
+	b, err := client.ModuleB().Build(ctx, "b", ModuleBBuildOpts{Tag: "latest"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(a)
+	fmt.Println(b)
+}
+`)
+	// the order of the arguments isn't deterministic
+	require.Regexp(t, `^query\{moduleABuild\(src:"a"\)\}\nquery\{moduleBBuild\((tag:"latest", src:"b"|src:"b", tag:"latest")\)\}\n$`, out)
+
+	t.Run("collision", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "namespaces.json")
+		query := schema.Types.Get("Query")
+		query.Fields = append(query.Fields, &introspection.Field{
+			Name:    "moduleA",
+			TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"},
+		})
+		generator.SetSchemaParents(schema)
+		generator.SetSchema(schema)
+
+		cfg := cfg
+		cfg.ClientOnly = true
+		cfg.OutputDir = t.TempDir()
+		_, err := gogenerator.GenerateClientFS(context.Background(), cfg, schema, schemaVersion, gogenerator.PackageInfo{
+			PackageName:   "dagger",
+			PackageImport: testutil.ModulePath + "/dagger",
+		})
+		require.ErrorContains(t, err, "namespace ModuleA of module module-a collides with field Query.moduleA")
+	})
+}
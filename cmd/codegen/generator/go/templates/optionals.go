@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// optionalTypeName is the generic type of the values of the nullable output
+// fields, with the GoOptionalOptional style.
+const optionalTypeName = "Optional"
+
+// optionalStyle returns the style of Config.GoOptionalStyle, GoOptionalValue
+// by default.
+func (funcs goTemplateFuncs) optionalStyle() string {
+	if funcs.cfg.GoOptionalStyle == "" {
+		return generator.GoOptionalValue
+	}
+	return funcs.cfg.GoOptionalStyle
+}
+
+// optionalType returns the name of the generic type of the values of the
+// nullable output fields, with the GoOptionalOptional style, or an empty
+// string otherwise. It fails if the name collides with a type of the schema.
+func (funcs goTemplateFuncs) optionalType() (string, error) {
+	if funcs.optionalStyle() != generator.GoOptionalOptional {
+		return "", nil
+	}
+	if funcs.schema.Types.Get(optionalTypeName) != nil {
+		return "", fmt.Errorf("optional type %s collides with a type of the schema", optionalTypeName)
+	}
+	return optionalTypeName, nil
+}
+
+// isOptionalValue returns true if a type is rendered with
+// Config.GoOptionalStyle: a nullable scalar or enum, but Void.
+func isOptionalValue(r *introspection.TypeRef) bool {
+	return r.IsOptional() && r.IsScalar() && !r.IsVoid()
+}
+
+// formatFieldType returns the type of the values of an output field, with
+// Config.GoOptionalStyle for the nullable ones
+// Example: `envVariable: String` -> `*string` with GoOptionalPointer
+func (funcs goTemplateFuncs) formatFieldType(r *introspection.TypeRef, scopes ...string) (string, error) {
+	t, err := funcs.FormatOutputType(r, scopes...)
+	if err != nil {
+		return "", err
+	}
+	if !isOptionalValue(r) {
+		return t, nil
+	}
+	return funcs.optionalValueType(t, scopes...)
+}
+
+// optionalValueType returns the type of the value of a nullable output field
+// of the given type, with Config.GoOptionalStyle.
+func (funcs goTemplateFuncs) optionalValueType(t string, scopes ...string) (string, error) {
+	switch funcs.optionalStyle() {
+	case generator.GoOptionalPointer:
+		return "*" + t, nil
+	case generator.GoOptionalOptional:
+		optional, err := funcs.optionalType()
+		if err != nil {
+			return "", err
+		}
+		if scope := strings.Join(scopes, ""); scope != "" {
+			optional = scope + "." + optional
+		}
+		return optional + "[" + t + "]", nil
+	default:
+		return t, nil
+	}
+}
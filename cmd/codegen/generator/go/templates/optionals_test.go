@@ -0,0 +1,150 @@
+package templates_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestGenerateOptionalStyle(t *testing.T) {
+	// the values of the environment variables are nullable, and cached from
+	// their list without their ID
+	generate := func(t *testing.T, style string) string {
+		schema, schemaVersion := loadFixture(t, "basic.json")
+		envVariable := schema.Types.Get("EnvVariable")
+		envVariable.Fields = slices.DeleteFunc(envVariable.Fields, func(f *introspection.Field) bool {
+			return f.Name == "id"
+		})
+		for _, f := range envVariable.Fields {
+			if f.Name == "value" {
+				f.TypeRef = f.TypeRef.OfType
+			}
+		}
+		mfs := generateSchema(t, generator.Config{GoOptionalStyle: style, GeneratePluggableTransport: true, SkipServeDependencies: true}, schema, schemaVersion)
+		return testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	}
+
+	// print the values of the nullable fields, set or null, from the
+	// responses of a fake transport
+	run := func(t *testing.T, src string) string {
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func main() {
+	ctx := context.Background()
+	transport := TransportFunc(func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+		var data string
+		switch {
+		case strings.Contains(req.Query, "envVariables"):
+			fmt.Println(req.Query)
+			data = `+"`"+`{"container":{"envVariables":[{"name":"PATH","value":"/bin"},{"name":"UNSET","value":null}]}}`+"`"+`
+		case strings.Contains(req.Query, "PATH"):
+			data = `+"`"+`{"container":{"envVariable":"/bin"}}`+"`"+`
+		case strings.Contains(req.Query, "UNSET"):
+			data = `+"`"+`{"container":{"envVariable":null}}`+"`"+`
+		}
+		return json.Unmarshal([]byte(data), resp.Data)
+	})
+	client, err := NewClientWithTransport(ctx, transport)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	print := func(v any, err error) {
+		if err != nil {
+			panic(err)
+		}
+		dt, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(dt))
+	}
+	print(client.Container().EnvVariable(ctx, "PATH"))
+	print(client.Container().EnvVariable(ctx, "UNSET"))
+
+	vars, err := client.Container().EnvVariables(ctx)
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range vars {
+		print(v.Value(ctx))
+	}
+}
+`)
+		return out
+	}
+
+	t.Run("value", func(t *testing.T) {
+		src := generate(t, "")
+		require.Equal(t, src, generate(t, generator.GoOptionalValue))
+		require.Contains(t, src, "func (r *Container) EnvVariable(ctx context.Context, name string) (string, error) {")
+		require.NotContains(t, src, "Optional[")
+
+		// null is the zero value
+		require.Equal(t, `"/bin"
+""
+query{container{envVariables{name value}}}
+"/bin"
+""
+`, run(t, src))
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		src := generate(t, generator.GoOptionalPointer)
+		require.Contains(t, src, "func (r *Container) EnvVariable(ctx context.Context, name string) (*string, error) {")
+		require.Contains(t, src, "func (r *EnvVariable) Value(ctx context.Context) (*string, error) {")
+		// the non-null fields are unchanged
+		require.Contains(t, src, "func (r *EnvVariable) Name(ctx context.Context) (string, error) {")
+
+		require.Equal(t, `"/bin"
+null
+query{container{envVariables{name value}}}
+"/bin"
+null
+`, run(t, src))
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		src := generate(t, generator.GoOptionalOptional)
+		require.Contains(t, src, "type Optional[T any] struct {")
+		require.Contains(t, src, "func (r *Container) EnvVariable(ctx context.Context, name string) (Optional[string], error) {")
+		require.Contains(t, src, "func (r *EnvVariable) Value(ctx context.Context) (Optional[string], error) {")
+		require.Contains(t, src, "func (r *EnvVariable) Name(ctx context.Context) (string, error) {")
+
+		require.Equal(t, `"/bin"
+null
+query{container{envVariables{name value}}}
+"/bin"
+null
+`, run(t, src))
+	})
+
+	t.Run("optional collision", func(t *testing.T) {
+		schema, schemaVersion := loadFixture(t, "basic.json")
+		schema.Types = append(schema.Types, &introspection.Type{Kind: introspection.TypeKindObject, Name: "Optional"})
+		generator.SetSchemaParents(schema)
+		generator.SetSchema(schema)
+		_, err := gogenerator.GenerateClientFS(context.Background(), generator.Config{GoOptionalStyle: generator.GoOptionalOptional, ClientOnly: true, OutputDir: t.TempDir(), SkipServeDependencies: true}, schema, schemaVersion, gogenerator.PackageInfo{
+			PackageName:   "dagger",
+			PackageImport: testutil.ModulePath + "/dagger",
+		})
+		require.ErrorContains(t, err, "optional type Optional collides with a type of the schema")
+	})
+}
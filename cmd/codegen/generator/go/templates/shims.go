@@ -0,0 +1,53 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// contextlessShim returns the method calling the function generated for the
+// field with context.Background(), with Config.GenerateContextlessShims, or
+// an empty string if the function takes no context. It fails if the name of
+// the method collides with a method of the object.
+// Example: `func (r *Container) StdoutNoContext() (string, error) {
+// return r.Stdout(context.Background()) }`
+func (funcs goTemplateFuncs) contextlessShim(f introspection.Field, supportsVoid bool) (string, error) {
+	if !funcs.cfg.GenerateContextlessShims || !(f.TypeRef.IsScalar() || f.TypeRef.IsList()) {
+		return "", nil
+	}
+
+	name := funcs.formatName(f.Name)
+	shim := name + "NoContext"
+	for _, other := range f.ParentObject.Fields {
+		if funcs.formatName(other.Name) == shim {
+			return "", fmt.Errorf("context-less method %s of %s.%s collides with field %s.%s", shim, f.ParentObject.Name, f.Name, f.ParentObject.Name, other.Name)
+		}
+	}
+
+	params, err := funcs.fieldArgs(f)
+	if err != nil {
+		return "", err
+	}
+	retType, err := funcs.fieldReturnType(f, supportsVoid)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"context.Background()"}
+	for _, param := range params {
+		// e.g. `opts ...ContainerWithExecOpts` -> `opts...`
+		argName, argType, _ := strings.Cut(param, " ")
+		if strings.HasPrefix(argType, "...") {
+			argName += "..."
+		}
+		args = append(args, argName)
+	}
+
+	doc := funcs.comment(fmt.Sprintf("%s calls %s with context.Background(), for the call sites without a context.\n\n"+
+		"Deprecated: pass a context to %s instead.", shim, name, name))
+	return fmt.Sprintf("%s\nfunc (r *%s) %s(%s) %s {\n\treturn r.%s(%s)\n}",
+		doc, funcs.objectStructName(*f.ParentObject), shim, strings.Join(params, ", "), retType,
+		name, strings.Join(args, ", ")), nil
+}
@@ -0,0 +1,189 @@
+package templates_test
+
+import (
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestGenerateEnumParse(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{}, "basic.json")
+	src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+	require.Contains(t, src, "func ParseNetworkProtocol(s string) (NetworkProtocol, error) {")
+	require.Contains(t, src, "func (v NetworkProtocol) String() string {")
+
+	// run the generated enum declarations on their own
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gogenerator.ClientGenFile, src, 0)
+	require.NoError(t, err)
+	var prog strings.Builder
+	prog.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\n")
+	for _, decl := range f.Decls {
+		var b strings.Builder
+		require.NoError(t, printer.Fprint(&b, fset, decl))
+		if strings.Contains(b.String(), "NetworkProtocol") && !strings.Contains(b.String(), "Client") {
+			prog.WriteString(b.String() + "\n\n")
+		}
+	}
+	prog.WriteString(`func main() {
+	for _, s := range os.Args[1:] {
+		v, err := ParseNetworkProtocol(s)
+		fmt.Printf("%s %v\n", v.String(), err)
+	}
+}
+`)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module enums\n\ngo 1.23\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(prog.String()), 0o600))
+	cmd := exec.Command("go", "run", ".", "TCP", "UDP", "tcp", "")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, `TCP <nil>
+UDP <nil>
+ invalid NetworkProtocol value "tcp"
+ invalid NetworkProtocol value ""
+`, string(out))
+}
+
+func TestGenerateTypedIDs(t *testing.T) {
+	src := testutil.ReadFile(t, generateFixture(t, generator.Config{SkipServeDependencies: true}, "typedids.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "func (r *File) ID(ctx context.Context) (string, error) {")
+	require.Contains(t, src, "func (r *Directory) WithFile(path string, fileID string) *Directory {")
+
+	src = testutil.ReadFile(t, generateFixture(t, generator.Config{TypedIDs: true, ReuseConnection: true, SkipServeDependencies: true}, "typedids.json"), gogenerator.ClientGenFile)
+	require.Contains(t, src, "type FileID string")
+	require.Contains(t, src, "func (r *File) ID(ctx context.Context) (FileID, error) {")
+	require.Contains(t, src, "func (r *Client) LoadFileFromID(id FileID) *File {")
+	// the arguments named after an object take it, like the ones of its scalar
+	require.Contains(t, src, "func (r *Directory) WithFile(path string, fileID *File) *Directory {")
+	require.Contains(t, src, "func (r *Directory) WithDirectory(path string, directoryId *Directory) *Directory {")
+
+	t.Run("collision", func(t *testing.T) {
+		schema, _ := loadFixture(t, "typedids.json")
+		schema.Types = append(schema.Types, &introspection.Type{Kind: introspection.TypeKindObject, Name: "FileID"})
+		require.ErrorContains(t, generator.TypeIDs(schema), "id scalar FileID of File collides with a type of the schema")
+	})
+
+	// type check programs passing IDs
+	vet := func(call string) (string, error) {
+		dir := testutil.WritePackage(t, map[string]string{gogenerator.ClientGenFile: testutil.MainPackage(src), "main.go": `package main
+
+import "context"
+
+func main() {
+	ctx := context.Background()
+	client, err := NewClient(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+	file := client.File("hello")
+	id, err := file.ID(ctx)
+	if err != nil {
+		panic(err)
+	}
+	_ = ` + call + `
+}
+`})
+		out, err := testutil.GoCommand(dir, "vet", ".").CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := vet("client.Directory().WithFile(\"hello.txt\", client.LoadFileFromID(id))")
+	require.NoError(t, err, out)
+
+	// a file can't be passed for a directory, nor its ID for the one of a
+	// directory
+	out, err = vet("client.Directory().WithDirectory(\"hello\", file)")
+	require.Error(t, err)
+	require.Contains(t, out, "cannot use file (variable of type *File) as *Directory value")
+	out, err = vet("client.LoadDirectoryFromID(id)")
+	require.Error(t, err)
+	require.Contains(t, out, "cannot use id (variable of string type FileID) as DirectoryID value")
+}
+
+func TestGenerateScalarSerializers(t *testing.T) {
+	cfg := generator.Config{
+		ReuseConnection: true,
+		ScalarSerializers: map[string]generator.ScalarSerializer{
+			"DateTime": {
+				GoType:    "time.Time",
+				Import:    "time",
+				Marshal:   "v.Format(time.RFC3339Nano)",
+				Unmarshal: "time.Parse(time.RFC3339Nano, s)",
+			},
+		},
+		SkipServeDependencies: true,
+	}
+
+	t.Run("serializers", func(t *testing.T) {
+		mfs := generateFixture(t, cfg, "scalars.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "// A timestamp in the RFC 3339 format.\ntype DateTime time.Time\n")
+		require.Contains(t, src, "func (r *Client) AddDays(ctx context.Context, at DateTime, opts ...AddDaysOpts) (DateTime, error) {")
+
+		// round-trip a timestamp through a client recording the request
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"addDays":"2024-01-03T03:04:05.5+01:00"}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	at := DateTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	value, err := client.AddDays(ctx, at)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(gql.queries, time.Time(value).UTC())
+}
+`)
+		require.Equal(t, "[query{addDays(at:\"2024-01-02T03:04:05Z\")}] 2024-01-03 02:04:05.5 +0000 UTC\n", out)
+	})
+
+	t.Run("no serializers", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "scalars.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "type DateTime")
+	})
+}
+
+func TestGenerateRecursiveInputs(t *testing.T) {
+	mfs := generateFixture(t, generator.Config{GenerateInputConstructors: true, SkipServeDependencies: true}, "recursive.json")
+	src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+
+	// the fields closing a cycle are pointers, the ones in lists or out of a
+	// cycle stay values
+	require.Regexp(t, `Parent +\*TreeInput `, src)
+	require.Regexp(t, `Children +\[\]TreeInput `, src)
+	require.Regexp(t, `Next +\*Odd `, src)
+	require.Regexp(t, `Next +\*Even `, src)
+	require.Regexp(t, `Leaf +Leaf `, src)
+
+	// the client must build
+	dir := testutil.WritePackage(t, map[string]string{gogenerator.ClientGenFile: src})
+	out, err := testutil.GoCommand(dir, "build", ".").CombinedOutput()
+	require.NoError(t, err, string(out))
+}
@@ -0,0 +1,114 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// variablesStruct is the struct of the arguments of a field, with
+// Config.GenerateVariableStructs, so that they can be built once and reused
+// across calls.
+type variablesStruct struct {
+	// Name is the name of the struct, e.g. ContainerWithExecVars for the
+	// arguments of Container.withExec.
+	Name string
+
+	// Method is the name of the method calling the field with the struct,
+	// e.g. WithExecVars.
+	Method string
+
+	Field *introspection.Field
+
+	Fields []variablesField
+}
+
+// variablesField is the field of a variables struct for an argument.
+type variablesField struct {
+	Name string
+	Type string
+	Arg  introspection.InputValue
+}
+
+// variablesStruct returns the variables struct of a field, or nil if it has
+// no arguments or Config.GenerateVariableStructs isn't set. It fails if the
+// names of the struct or its method collide with a type of the schema or a
+// method of the object.
+func (funcs goTemplateFuncs) variablesStruct(f introspection.Field) (*variablesStruct, error) {
+	if !funcs.cfg.GenerateVariableStructs || len(f.Args) == 0 {
+		return nil, nil
+	}
+
+	vars := &variablesStruct{
+		Name:   strings.TrimSuffix(funcs.fieldOptionsStructName(f), "Opts") + "Vars",
+		Method: funcs.formatName(f.Name) + "Vars",
+		Field:  &f,
+	}
+	if funcs.schema.Types.Get(vars.Name) != nil {
+		return nil, fmt.Errorf("variables struct %s of %s.%s collides with a type of the schema", vars.Name, f.ParentObject.Name, f.Name)
+	}
+	for _, other := range f.ParentObject.Fields {
+		if funcs.formatName(other.Name) == vars.Method {
+			return nil, fmt.Errorf("method %s of the variables of %s.%s collides with field %s.%s", vars.Method, f.ParentObject.Name, f.Name, f.ParentObject.Name, other.Name)
+		}
+	}
+
+	for _, arg := range f.Args {
+		// same as in the function of the field, see fieldArgs
+		formatType := funcs.FormatInputType
+		if f.ParentObject.Name == generator.QueryStructName && arg.Name == "id" {
+			formatType = funcs.FormatOutputType
+		}
+		typ, err := formatType(arg.TypeRef)
+		if err != nil {
+			return nil, err
+		}
+		if cursor := funcs.cursorArgType(f, arg); cursor != "" {
+			typ = cursor
+		}
+		vars.Fields = append(vars.Fields, variablesField{
+			Name: funcs.formatName(arg.Name),
+			Type: typ,
+			Arg:  arg,
+		})
+	}
+	return vars, nil
+}
+
+// variablesMethod returns the method calling the function generated for the
+// field with the arguments of its variables struct.
+// Example: `func (r *Container) WithExecVars(vars ContainerWithExecVars) *Container {
+// return r.WithExec(vars.Args, ContainerWithExecOpts{Expand: vars.Expand}) }`
+func (funcs goTemplateFuncs) variablesMethod(vars *variablesStruct, supportsVoid bool) (string, error) {
+	f := *vars.Field
+	retType, err := funcs.fieldReturnType(f, supportsVoid)
+	if err != nil {
+		return "", err
+	}
+
+	params := []string{}
+	args := []string{}
+	if f.TypeRef.IsScalar() || f.TypeRef.IsList() {
+		params = append(params, "ctx context.Context")
+		args = append(args, "ctx")
+	}
+	params = append(params, "vars "+vars.Name)
+
+	var opts []string
+	for _, field := range vars.Fields {
+		if funcs.isArgOptional(field.Arg) {
+			opts = append(opts, fmt.Sprintf("\t\t%s: vars.%s,\n", field.Name, field.Name))
+		} else {
+			args = append(args, "vars."+field.Name)
+		}
+	}
+	if funcs.hasOptionals(f.Args) {
+		args = append(args, funcs.fieldOptionsStructName(f)+"{\n"+strings.Join(opts, "")+"\t}")
+	}
+
+	return fmt.Sprintf("func (r *%s) %s(%s) %s {\n\treturn r.%s(%s)\n}",
+		funcs.objectStructName(*f.ParentObject), vars.Method, strings.Join(params, ", "), retType,
+		funcs.formatName(f.Name), strings.Join(args, ", ")), nil
+}
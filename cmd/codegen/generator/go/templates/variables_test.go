@@ -0,0 +1,66 @@
+package templates_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	gogenerator "github.com/dagger/dagger/cmd/codegen/generator/go"
+	"github.com/dagger/dagger/cmd/codegen/generator/go/internal/testutil"
+)
+
+func TestGenerateVariableStructs(t *testing.T) {
+	t.Run("structs", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{GenerateVariableStructs: true, ReuseConnection: true, SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.Contains(t, src, "type ContainerWithExposedPortVars struct {\n"+
+			"\t// Port number to expose.\n"+
+			"\tPort int\n"+
+			"\t// Transport layer network protocol.\n"+
+			"\tProtocol NetworkProtocol\n"+
+			"}")
+		require.Contains(t, src, "func (r *Container) WithExposedPortVars(vars ContainerWithExposedPortVars) *Container {\n"+
+			"\treturn r.WithExposedPort(vars.Port, ContainerWithExposedPortOpts{\n"+
+			"\t\tProtocol: vars.Protocol,\n"+
+			"\t})\n"+
+			"}")
+
+		// reuse the variables across calls from a client recording the
+		// request
+		out := testutil.RunGenerated(t, src, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	gql := respondWith(`+"`"+`{"loadContainerFromID":{"withExposedPort":{"withExposedPort":{"envVariable":"/bin"}}}}`+"`"+`)
+	client, err := NewClient(ctx, gql)
+	if err != nil {
+		panic(err)
+	}
+
+	vars := ContainerWithExposedPortVars{Port: 80, Protocol: NetworkProtocolUdp}
+	value, err := client.LoadContainerFromID("ctr").
+		WithExposedPortVars(vars).
+		WithExposedPortVars(vars).
+		EnvVariableVars(ctx, ContainerEnvVariableVars{Name: "PATH"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(gql.queries, value)
+}
+`)
+		// the order of the arguments isn't deterministic
+		require.Regexp(t, `^\[query\{loadContainerFromID\(id:"ctr"\)\{(withExposedPort\((protocol:UDP, port:80|port:80, protocol:UDP)\)\{){2}envVariable\(name:"PATH"\)\}\}\}\}\] /bin\n$`, out)
+	})
+
+	t.Run("no structs", func(t *testing.T) {
+		mfs := generateFixture(t, generator.Config{SkipServeDependencies: true}, "basic.json")
+		src := testutil.ReadFile(t, mfs, gogenerator.ClientGenFile)
+		require.NotContains(t, src, "Vars struct")
+	})
+}
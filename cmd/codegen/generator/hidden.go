@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// HideTypes removes from the schema the types whose name starts with one of
+// the prefixes, along with the fields returning them and their occurrences in
+// the possible types of interfaces and unions.
+// A visible type still depending on a hidden type by an argument, an input
+// field or an interface it implements is an error, since removing it would
+// change the meaning of the visible type.
+func HideTypes(schema *introspection.Schema, prefixes []string) error {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	hidden := func(name string) bool {
+		for _, prefix := range prefixes {
+			if prefix != "" && strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	if hidden(schema.QueryType.Name) {
+		return fmt.Errorf("cannot hide the query type %q", schema.QueryType.Name)
+	}
+
+	visible := make(introspection.Types, 0, len(schema.Types))
+	for _, t := range schema.Types {
+		if hidden(t.Name) {
+			continue
+		}
+		visible = append(visible, t)
+	}
+
+	for _, t := range visible {
+		fields := make([]*introspection.Field, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if hidden(typeRefName(f.TypeRef)) {
+				continue
+			}
+			for _, arg := range f.Args {
+				if name := typeRefName(arg.TypeRef); hidden(name) {
+					return fmt.Errorf("argument %s.%s.%s depends on hidden type %q", t.Name, f.Name, arg.Name, name)
+				}
+			}
+			fields = append(fields, f)
+		}
+		t.Fields = fields
+
+		for _, f := range t.InputFields {
+			if name := typeRefName(f.TypeRef); hidden(name) {
+				return fmt.Errorf("input field %s.%s depends on hidden type %q", t.Name, f.Name, name)
+			}
+		}
+
+		for _, iface := range t.Interfaces {
+			if hidden(iface.Name) {
+				return fmt.Errorf("type %s implements hidden interface %q", t.Name, iface.Name)
+			}
+		}
+
+		possibleTypes := make([]*introspection.Type, 0, len(t.PossibleTypes))
+		for _, possible := range t.PossibleTypes {
+			if hidden(possible.Name) {
+				continue
+			}
+			possibleTypes = append(possibleTypes, possible)
+		}
+		t.PossibleTypes = possibleTypes
+	}
+
+	schema.Types = visible
+	return nil
+}
+
+// typeRefName returns the name of the named type wrapped by a type ref.
+func typeRefName(r *introspection.TypeRef) string {
+	for r != nil && r.OfType != nil {
+		r = r.OfType
+	}
+	if r == nil {
+		return ""
+	}
+	return r.Name
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// maxHTTPErrorBody is the number of bytes of the body of an unsuccessful
+// response included in the error.
+const maxHTTPErrorBody = 1024
+
+// IntrospectHTTP gets the schema of a GraphQL endpoint served over HTTP by
+// POSTing the introspection query to it, e.g. to generate a client for an API
+// other than Dagger. The headers are sent with the request, e.g. for
+// authentication, and the request is canceled with the context.
+//
+// The `__schemaVersion` field is specific to Dagger so it isn't queried: the
+// returned schema version is always empty.
+//
+// The servers only list the deprecated arguments and input fields if asked
+// to with `includeDeprecated`, which includeDeprecated does, e.g. to exclude
+// them with Config.ExcludeDeprecated. It isn't sent otherwise, since the
+// servers predating its addition to the specification reject it.
+func IntrospectHTTP(ctx context.Context, endpoint string, headers http.Header, includeDeprecated bool) (*introspection.Schema, string, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":         httpIntrospectionQuery(includeDeprecated),
+		"operationName": "IntrospectionQuery",
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal introspection query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("introspection request: %w", err)
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("introspection query: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxHTTPErrorBody))
+		return nil, "", fmt.Errorf("introspection query: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var introspectionResp struct {
+		Data   *introspection.Response `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&introspectionResp); err != nil {
+		return nil, "", fmt.Errorf("decode introspection response: %w", err)
+	}
+	if len(introspectionResp.Errors) > 0 {
+		errs := make([]error, len(introspectionResp.Errors))
+		for i, e := range introspectionResp.Errors {
+			errs[i] = errors.New(e.Message)
+		}
+		return nil, "", fmt.Errorf("introspection query: %w", errors.Join(errs...))
+	}
+	if introspectionResp.Data == nil || introspectionResp.Data.Schema == nil {
+		return nil, "", errors.New("introspection query: no schema in response")
+	}
+
+	return introspectionResp.Data.Schema, "", nil
+}
+
+// httpIntrospectionQuery returns the introspection query without its Dagger
+// specific parts, which other servers would reject: the `__schemaVersion`
+// field, and the `directives` applied to the types, fields, arguments and enum
+// values along with their `DirectiveApplication` fragment, so that it only
+// queries the fields of the GraphQL specification. With includeDeprecated, the
+// `args` and `inputFields` are queried with `includeDeprecated: true`.
+func httpIntrospectionQuery(includeDeprecated bool) string {
+	lines := strings.Split(introspection.Query, "\n")
+	query := lines[:0]
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "__schemaVersion":
+		case line == "directives {" && i+2 < len(lines) &&
+			strings.TrimSpace(lines[i+1]) == "...DirectiveApplication" &&
+			strings.TrimSpace(lines[i+2]) == "}":
+			i += 2
+		case strings.HasPrefix(line, "fragment DirectiveApplication "):
+			for i < len(lines) && lines[i] != "}" {
+				i++
+			}
+		case includeDeprecated && (line == "args {" || line == "inputFields {"):
+			// the args of the directive applications are stripped above, so
+			// these are the ones of the fields and directives
+			query = append(query, strings.Replace(lines[i], " {", "(includeDeprecated: true) {", 1))
+		default:
+			query = append(query, lines[i])
+		}
+	}
+	return strings.TrimSpace(strings.Join(query, "\n")) + "\n"
+}
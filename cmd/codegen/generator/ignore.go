@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// DaggerIgnoreFile is the file of the output directory listing the patterns
+// of the generated paths Overlay doesn't write, with the syntax of the ignore
+// files of the build contexts.
+const DaggerIgnoreFile = ".daggerignore"
+
+// loadIgnore returns the matcher of the patterns of the ignore file of the
+// output directory, or nil if it has none.
+func loadIgnore(outputDir string) (*patternmatcher.PatternMatcher, error) {
+	f, err := os.Open(filepath.Join(outputDir, DaggerIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", DaggerIgnoreFile, err)
+	}
+	defer f.Close()
+
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", DaggerIgnoreFile, err)
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	matcher, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", DaggerIgnoreFile, err)
+	}
+	return matcher, nil
+}
+
+// isIgnored returns true if the path of the overlay matches the patterns of
+// the ignore file, if any.
+func isIgnored(ignore *patternmatcher.PatternMatcher, path string) (bool, error) {
+	if ignore == nil {
+		return false, nil
+	}
+	ignored, err := ignore.MatchesOrParentMatches(filepath.FromSlash(path))
+	if err != nil {
+		return false, fmt.Errorf("match %s against %s: %w", path, DaggerIgnoreFile, err)
+	}
+	return ignored, nil
+}
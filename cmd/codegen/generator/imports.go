@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/psanford/memfs"
+)
+
+// RewriteImports returns a copy of the overlay where the imports of the
+// generated Go and TypeScript files are rewritten according to rewrites.
+//
+// Each key of rewrites is an import path to replace by its value. Imports of
+// sub-packages are also rewritten, e.g. with `dagger.io/dagger` ->
+// `example.com/mirror/dagger`, `dagger.io/dagger/querybuilder` becomes
+// `example.com/mirror/dagger/querybuilder`.
+// Only import declarations are rewritten, any other occurrence of the path in
+// the generated code is left untouched.
+func RewriteImports(overlay fs.FS, rewrites map[string]string) (fs.FS, error) {
+	mfs := memfs.New()
+
+	err := walkOverlay(overlay, func(name string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return mfs.MkdirAll(name, 0o755)
+		}
+
+		content, err := fs.ReadFile(overlay, name)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+
+		switch path.Ext(name) {
+		case ".go":
+			content, err = rewriteGoImports(name, content, rewrites)
+		case ".ts":
+			content = rewriteTypeScriptImports(content, rewrites)
+		}
+		if err != nil {
+			return fmt.Errorf("rewrite imports of %s: %w", name, err)
+		}
+
+		return mfs.WriteFile(name, content, 0o600)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mfs, nil
+}
+
+// rewriteImportPath returns the rewritten import path, preferring the longest
+// matching rewrite.
+func rewriteImportPath(importPath string, rewrites map[string]string) (string, bool) {
+	olds := make([]string, 0, len(rewrites))
+	for old := range rewrites {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool {
+		return len(olds[i]) > len(olds[j])
+	})
+
+	for _, old := range olds {
+		if importPath == old {
+			return rewrites[old], true
+		}
+		if rest, ok := strings.CutPrefix(importPath, old+"/"); ok {
+			return rewrites[old] + "/" + rest, true
+		}
+	}
+	return importPath, false
+}
+
+func rewriteGoImports(filename string, content []byte, rewrites map[string]string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, content, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	// rewrite from the end, so the offsets of the remaining imports stay valid
+	for i := len(f.Imports) - 1; i >= 0; i-- {
+		spec := f.Imports[i]
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		newPath, ok := rewriteImportPath(importPath, rewrites)
+		if !ok {
+			continue
+		}
+
+		replacement := strconv.Quote(newPath)
+		// keep the package name that the generated code refers to
+		if spec.Name == nil && path.Base(newPath) != path.Base(importPath) {
+			replacement = path.Base(importPath) + " " + replacement
+		}
+
+		start := fset.Position(spec.Path.Pos()).Offset
+		end := fset.Position(spec.Path.End()).Offset
+		content = append(content[:start:start], append([]byte(replacement), content[end:]...)...)
+	}
+
+	return content, nil
+}
+
+// tsImportRe matches the module specifier of TypeScript import and export
+// declarations, e.g. `import { a } from "x"`, `export * from "x"` or
+// `import "x"`.
+var tsImportRe = regexp.MustCompile(`(?m)^(\s*(?:import|export)\b(?:[^;"']*?\bfrom)?\s*)(["'])([^"']+)(["'])`)
+
+func rewriteTypeScriptImports(content []byte, rewrites map[string]string) []byte {
+	return tsImportRe.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := tsImportRe.FindSubmatch(match)
+		newPath, ok := rewriteImportPath(string(groups[3]), rewrites)
+		if !ok {
+			return match
+		}
+		return []byte(string(groups[1]) + string(groups[2]) + newPath + string(groups[4]))
+	})
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// JSONSchemaDialect is the version of JSON Schema emitted by EmitJSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchemaScalars are the JSON Schema types of the built-in scalars.
+var jsonSchemaScalars = map[string]string{
+	string(introspection.ScalarInt):     "integer",
+	string(introspection.ScalarFloat):   "number",
+	string(introspection.ScalarString):  "string",
+	string(introspection.ScalarBoolean): "boolean",
+	"ID":                                "string",
+	string(introspection.ScalarVoid):    "null",
+}
+
+// EmitJSONSchema returns a JSON Schema document describing the data types of
+// the schema, e.g. for tools in other languages to validate the payloads of
+// the generated clients. Each type but the introspection ones is a definition
+// of `$defs`, referenced by the others:
+//
+//   - a built-in scalar is inlined as its JSON type, a custom scalar (e.g. an
+//     ID) accepts any value;
+//   - an enum is a string among its values;
+//   - an object or an interface is a JSON object of its fields, without
+//     required ones since a response only has the selected fields;
+//   - an input object is a JSON object of its input fields, the non-null ones
+//     without default value being required;
+//   - a union is any of its possible types.
+//
+// A nullable type also accepts null. The output is deterministic.
+func EmitJSONSchema(schema *introspection.Schema) ([]byte, error) {
+	defs := map[string]any{}
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		if _, ok := jsonSchemaScalars[t.Name]; ok && t.Kind == introspection.TypeKindScalar {
+			continue
+		}
+
+		def := map[string]any{}
+		if t.Description != "" {
+			def["description"] = t.Description
+		}
+		switch t.Kind {
+		case introspection.TypeKindScalar:
+		case introspection.TypeKindEnum:
+			values := make([]string, len(t.EnumValues))
+			for i, v := range t.EnumValues {
+				values[i] = v.Name
+			}
+			def["type"] = "string"
+			def["enum"] = values
+		case introspection.TypeKindObject, introspection.TypeKindInterface:
+			properties := map[string]any{}
+			for _, f := range t.Fields {
+				properties[f.Name] = jsonSchemaProperty(jsonSchemaTypeRef(f.TypeRef), f.Description, f.IsDeprecated)
+			}
+			def["type"] = "object"
+			def["properties"] = properties
+		case introspection.TypeKindInputObject:
+			properties := map[string]any{}
+			required := []string{}
+			for _, f := range t.InputFields {
+				properties[f.Name] = jsonSchemaProperty(jsonSchemaTypeRef(f.TypeRef), f.Description, false)
+				if !f.IsOptional() {
+					required = append(required, f.Name)
+				}
+			}
+			def["type"] = "object"
+			def["properties"] = properties
+			if len(required) > 0 {
+				def["required"] = required
+			}
+			def["additionalProperties"] = false
+		case introspection.TypeKindUnion:
+			possibleTypes := make([]any, len(t.PossibleTypes))
+			for i, possible := range t.PossibleTypes {
+				possibleTypes[i] = jsonSchemaRef(possible.Name)
+			}
+			def["anyOf"] = possibleTypes
+		default:
+			return nil, fmt.Errorf("type %s: unsupported kind %s", t.Name, t.Kind)
+		}
+		defs[t.Name] = def
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"$schema": JSONSchemaDialect,
+		"$defs":   defs,
+	}, "", "  ")
+}
+
+// jsonSchemaTypeRef returns the JSON Schema of a type reference.
+func jsonSchemaTypeRef(ref *introspection.TypeRef) map[string]any {
+	if ref.Kind == introspection.TypeKindNonNull {
+		return jsonSchemaNonNull(ref.OfType)
+	}
+	return map[string]any{
+		"anyOf": []any{jsonSchemaNonNull(ref), map[string]any{"type": "null"}},
+	}
+}
+
+// jsonSchemaNonNull returns the JSON Schema of a type reference without null.
+func jsonSchemaNonNull(ref *introspection.TypeRef) map[string]any {
+	if ref.Kind == introspection.TypeKindList {
+		return map[string]any{
+			"type":  "array",
+			"items": jsonSchemaTypeRef(ref.OfType),
+		}
+	}
+	if typ, ok := jsonSchemaScalars[ref.Name]; ok && ref.Kind == introspection.TypeKindScalar {
+		return map[string]any{"type": typ}
+	}
+	return jsonSchemaRef(ref.Name)
+}
+
+func jsonSchemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+// jsonSchemaProperty returns the JSON Schema of a field, with its
+// description.
+func jsonSchemaProperty(typ map[string]any, description string, deprecated bool) map[string]any {
+	if description != "" {
+		typ["description"] = description
+	}
+	if deprecated {
+		typ["deprecated"] = true
+	}
+	return typ
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Layout is where the files of a module land in the output directory, following
+// the conventions of its SDK. The paths are relative to the output directory.
+type Layout struct {
+	// SourceDir is the directory of the module source, where the commands
+	// run after the generation run.
+	SourceDir string
+
+	// GeneratedDir is the directory of the generated client of the module.
+	GeneratedDir string
+
+	// Entrypoint is the file of the module code loaded by its SDK, scaffolded
+	// when initializing the module if the SDK generates it.
+	Entrypoint string
+}
+
+// ModuleLayouter can optionally be implemented by a Generator supporting
+// modules, to compute the canonical layout of a module, see
+// ResolveModuleLayout.
+type ModuleLayouter interface {
+	ModuleLayout(cfg Config) (Layout, error)
+}
+
+// ResolveModuleLayout returns the layout of the module of the config in the
+// output directory, computed by the generator of its language.
+func ResolveModuleLayout(cfg Config) (Layout, error) {
+	gen, err := New(cfg)
+	if err != nil {
+		return Layout{}, err
+	}
+	layouter, ok := gen.(ModuleLayouter)
+	if !ok {
+		return Layout{}, fmt.Errorf("%s modules have no layout", cfg.Lang)
+	}
+	return layouter.ModuleLayout(cfg)
+}
+
+// ModuleSourceDir returns the directory of the module source in the output
+// directory, for generators to compute their layout. It fails if the config
+// isn't for a module, or if the module source isn't in the output directory.
+func ModuleSourceDir(cfg Config) (string, error) {
+	if cfg.ModuleName == "" {
+		return "", errors.New("no module name configured")
+	}
+	dir := filepath.Clean(cfg.ModuleSourcePath)
+	if !filepath.IsLocal(dir) {
+		return "", fmt.Errorf("module source path %q must be in the output directory", cfg.ModuleSourcePath)
+	}
+	return dir, nil
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DocLink is a link of a description, with the text of a markdown link or
+// empty for a bare URL.
+type DocLink struct {
+	Text string
+	URL  string
+}
+
+var (
+	markdownLinkRe = regexp.MustCompile(`\[([^\[\]]+)\]\((https?://[^\s()]+)\)`)
+	urlRe          = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]*[^\s<>()\[\]"'` + "`" + `.,;:!?]`)
+)
+
+// ReplaceMarkdownLinks returns the description with its markdown links (e.g.
+// `[the docs](https://docs.dagger.io)`) replaced by format, and the links in
+// order of appearance. The code spans are left as is.
+func ReplaceMarkdownLinks(s string, format func(DocLink) string) (string, []DocLink) {
+	var links []DocLink
+	parts := strings.Split(s, "`")
+	for i, part := range parts {
+		if i%2 == 1 {
+			continue
+		}
+		parts[i] = markdownLinkRe.ReplaceAllStringFunc(part, func(match string) string {
+			m := markdownLinkRe.FindStringSubmatch(match)
+			link := DocLink{Text: m[1], URL: m[2]}
+			links = append(links, link)
+			return format(link)
+		})
+	}
+	return strings.Join(parts, "`"), links
+}
+
+// DocLinks returns the links of a description in order of appearance, the
+// markdown links and the bare URLs, once per URL. The URLs in code spans
+// aren't links.
+func DocLinks(s string) []DocLink {
+	seen := map[string]bool{}
+	var links []DocLink
+	add := func(link DocLink) {
+		if !seen[link.URL] {
+			seen[link.URL] = true
+			links = append(links, link)
+		}
+	}
+
+	for i, part := range strings.Split(s, "`") {
+		if i%2 == 1 {
+			// in a code span
+			continue
+		}
+		matches := markdownLinkRe.FindAllStringSubmatchIndex(part, -1)
+		last := 0
+		for _, m := range matches {
+			for _, url := range bareURLs(part[last:m[0]]) {
+				add(DocLink{URL: url})
+			}
+			add(DocLink{Text: part[m[2]:m[3]], URL: part[m[4]:m[5]]})
+			last = m[1]
+		}
+		for _, url := range bareURLs(part[last:]) {
+			add(DocLink{URL: url})
+		}
+	}
+	return links
+}
+
+// bareURLs returns the bare URLs of s. A URL followed by a parenthesis, e.g.
+// `http://example.com?token=$(cat token)`, would be truncated so it isn't one.
+func bareURLs(s string) []string {
+	var urls []string
+	for _, m := range urlRe.FindAllStringIndex(s, -1) {
+		if m[1] < len(s) && s[m[1]] == '(' {
+			continue
+		}
+		urls = append(urls, s[m[0]:m[1]])
+	}
+	return urls
+}
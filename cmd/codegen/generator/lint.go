@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+var (
+	typeNameRe      = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNameRe     = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+	enumValueNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// graphQLNameRe matches the names of the GraphQL specification
+	graphQLNameRe = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+)
+
+// builtinScalars are the scalars of the GraphQL specification, which have no
+// description in the schema.
+var builtinScalars = map[string]bool{
+	string(introspection.ScalarInt):     true,
+	string(introspection.ScalarFloat):   true,
+	string(introspection.ScalarString):  true,
+	string(introspection.ScalarBoolean): true,
+	"ID":                                true,
+}
+
+// SchemaLintError lists the violations of the rules of LintSchema.
+type SchemaLintError struct {
+	Violations []string
+}
+
+func (e *SchemaLintError) Error() string {
+	return fmt.Sprintf("schema has %d violation(s):\n- %s", len(e.Violations), strings.Join(e.Violations, "\n- "))
+}
+
+// LintSchema checks the hygiene of the public part of the schema, that is
+// every type but the introspection types (e.g. `__Type`) and the built-in
+// scalars, and returns a *SchemaLintError listing the violations, if any:
+//
+//   - every type has a name, in PascalCase (e.g. `Container`);
+//   - every type but the query type has a description;
+//   - every field, argument and input field has a description and a name in
+//     camelCase (e.g. `withExec`);
+//   - every enum value has a description and a name made of letters, digits
+//     and underscores, starting with a letter (e.g. `TCP`).
+//
+// It's run before the generation with Config.StrictSchema.
+func LintSchema(schema *introspection.Schema) error {
+	var violations []string
+	violate := func(format string, args ...any) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+	for _, t := range schema.Types {
+		if t.Name == "" {
+			violate("%s type has no name", strings.ToLower(string(t.Kind)))
+			continue
+		}
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == introspection.TypeKindScalar && builtinScalars[t.Name]) {
+			continue
+		}
+		if !typeNameRe.MatchString(t.Name) {
+			violate("type %s: name is not in PascalCase", t.Name)
+		}
+		if t.Description == "" && t.Name != schema.QueryType.Name {
+			violate("type %s: no description", t.Name)
+		}
+
+		for _, f := range t.Fields {
+			lintName(violate, "field "+t.Name+"."+f.Name, f.Name, f.Description)
+			for _, arg := range f.Args {
+				lintName(violate, "argument "+t.Name+"."+f.Name+"."+arg.Name, arg.Name, arg.Description)
+			}
+		}
+		for _, f := range t.InputFields {
+			lintName(violate, "input field "+t.Name+"."+f.Name, f.Name, f.Description)
+		}
+		for _, v := range t.EnumValues {
+			if !enumValueNameRe.MatchString(v.Name) {
+				violate("enum value %s.%s: name is not made of letters, digits and underscores", t.Name, v.Name)
+			}
+			if v.Description == "" {
+				violate("enum value %s.%s: no description", t.Name, v.Name)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaLintError{Violations: violations}
+	}
+	return nil
+}
+
+// lintName checks the name and the description of a field, an argument or an
+// input field.
+func lintName(violate func(string, ...any), what, name, description string) {
+	if !fieldNameRe.MatchString(name) {
+		violate("%s: name is not in camelCase", what)
+	}
+	if description == "" {
+		violate("%s: no description", what)
+	}
+}
@@ -0,0 +1,29 @@
+package generator
+
+import "github.com/dagger/dagger/cmd/codegen/introspection"
+
+// IDLoader returns the field of the query loading an object from its ID, or
+// nil if the object has none. It follows the convention of the engine: an
+// object T with an `id` field is loaded by `loadTFromID(id:)`, whose only
+// argument is required and of the type of the ID, returning T.
+func IDLoader(schema *introspection.Schema, t *introspection.Type) *introspection.Field {
+	if t == nil || t.Kind != introspection.TypeKindObject {
+		return nil
+	}
+	id := namedScalarRef(fieldTypeRef(fieldOf(t, "id")))
+	if id == nil {
+		return nil
+	}
+	loader := fieldOf(schema.Query(), "load"+t.Name+"FromID")
+	if loader == nil || len(loader.Args) != 1 || objectType(schema, loader.TypeRef) != t {
+		return nil
+	}
+	arg := loader.Args[0]
+	if arg.Name != "id" || arg.TypeRef.Kind != introspection.TypeKindNonNull {
+		return nil
+	}
+	if ref := namedScalarRef(arg.TypeRef); ref == nil || ref.Name != id.Name {
+		return nil
+	}
+	return loader
+}
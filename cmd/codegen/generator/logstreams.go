@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// LogEntrySuffix ends the names of the objects of the entries of log streams,
+// see LogStreamEntry.
+const LogEntrySuffix = "LogEntry"
+
+// LogStreamEntry returns the type of the entries of the log stream returned
+// by f, or nil if f doesn't return a log stream: a list of objects named with
+// LogEntrySuffix, with at least a field to decode, see LogEntryFields.
+//
+//	type Container { logs: [ContainerLogEntry!]! }
+//	type ContainerLogEntry { timestamp: Int!, message: String! }
+func LogStreamEntry(schema *introspection.Schema, f introspection.Field) *introspection.Type {
+	if f.TypeRef == nil || !f.TypeRef.IsList() {
+		return nil
+	}
+	entry := objectType(schema, listElem(f.TypeRef))
+	if entry == nil || !strings.HasSuffix(entry.Name, LogEntrySuffix) || len(LogEntryFields(entry)) == 0 {
+		return nil
+	}
+	return entry
+}
+
+// LogEntryFields returns the fields of a log entry decoded from the log
+// streams: its scalar fields without required arguments, but its ID.
+func LogEntryFields(entry *introspection.Type) []*introspection.Field {
+	var fields []*introspection.Field
+	for _, f := range entry.Fields {
+		if f.Name == "id" || !f.TypeRef.IsScalar() {
+			continue
+		}
+		required := false
+		for _, arg := range f.Args {
+			if !arg.IsOptional() {
+				required = true
+			}
+		}
+		if !required {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Manifest lists the generated files with the hash of their content.
+type Manifest struct {
+	// Files maps the path of each generated file, relative to the output
+	// directory, to the sha256 of its content.
+	Files map[string]string `json:"files"`
+
+	// Provenance records how the files were generated. It is kept apart from
+	// Files, which only depend on the generated content.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance records how generated files were produced.
+type Provenance struct {
+	// GeneratorVersion is the version of the codegen binary.
+	GeneratorVersion string `json:"generatorVersion"`
+
+	// EngineVersion is the version of the schema the files were generated from.
+	EngineVersion string `json:"engineVersion"`
+
+	// SourceCommit is the git commit of the module source, if any.
+	SourceCommit string `json:"sourceCommit,omitempty"`
+
+	// Timestamp is the time of the generation.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewManifest hashes the files of the overlays, as written in outputDir.
+func NewManifest(outputDir string, overlays ...fs.FS) (*Manifest, error) {
+	m := &Manifest{Files: map[string]string{}}
+	for _, overlay := range overlays {
+		if err := m.add(overlay, outputDir); err != nil {
+			return nil, fmt.Errorf("hash generated files: %w", err)
+		}
+	}
+	return m, nil
+}
+
+func (m *Manifest) add(overlay fs.FS, outputDir string) error {
+	return walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, path))
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		m.Files[filepath.ToSlash(path)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+}
+
+// NewProvenance returns the provenance of a generation from the schema of the
+// given version, reading the git commit of sourceDir if it is in a
+// repository.
+func NewProvenance(schemaVersion string, sourceDir string) *Provenance {
+	p := &Provenance{
+		GeneratorVersion: "(devel)",
+		EngineVersion:    schemaVersion,
+		Timestamp:        time.Now().UTC(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		p.GeneratorVersion = info.Main.Version
+	}
+	if out, err := exec.Command("git", "-C", sourceDir, "rev-parse", "HEAD").Output(); err == nil {
+		p.SourceCommit = strings.TrimSpace(string(out))
+	}
+	return p
+}
+
+// WriteManifest writes the manifest as JSON to path.
+func WriteManifest(path string, m *Manifest) error {
+	dt, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	return os.WriteFile(path, append(dt, '\n'), 0o600)
+}
+
+// ReadManifest reads a manifest written by WriteManifest.
+func ReadManifest(path string) (*Manifest, error) {
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(dt, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal manifest: %w", err)
+	}
+	return &m, nil
+}
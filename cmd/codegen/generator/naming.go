@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// CheckNameCollisions returns an error if two names of the same scope are
+// formatted to the same identifier, which would generate conflicting
+// declarations. The scopes are the types of the schema, the fields of each
+// type, and the arguments of each field.
+func CheckNameCollisions(schema *introspection.Schema, format func(string) string) error {
+	types := nameScope{}
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || t.Kind == introspection.TypeKindEnum || isBuiltinScalar(t) {
+			continue
+		}
+		if err := types.add(format, t.Name, "types"); err != nil {
+			return err
+		}
+
+		fields := nameScope{}
+		for _, f := range t.Fields {
+			if err := fields.add(format, f.Name, "fields of "+t.Name); err != nil {
+				return err
+			}
+
+			args := nameScope{}
+			for _, arg := range f.Args {
+				if err := args.add(format, arg.Name, fmt.Sprintf("arguments of %s.%s", t.Name, f.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		for _, f := range t.InputFields {
+			if err := fields.add(format, f.Name, "fields of "+t.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nameScope maps the identifiers of a scope to the schema name they were
+// formatted from.
+type nameScope map[string]string
+
+func (scope nameScope) add(format func(string) string, name string, desc string) error {
+	id := format(name)
+	if other, ok := scope[id]; ok && other != name {
+		return fmt.Errorf("name collision in %s: %q and %q are both generated as %q", desc, other, name, id)
+	}
+	scope[id] = name
+	return nil
+}
+
+func isBuiltinScalar(t *introspection.Type) bool {
+	if t.Kind != introspection.TypeKindScalar {
+		return false
+	}
+	switch introspection.Scalar(t.Name) {
+	case introspection.ScalarInt, introspection.ScalarFloat, introspection.ScalarString,
+		introspection.ScalarBoolean, introspection.ScalarVoid:
+		return true
+	}
+	return t.Name == "ID"
+}
@@ -0,0 +1,17 @@
+package generator
+
+import "github.com/dagger/dagger/cmd/codegen/introspection"
+
+// NullabilityAnnotation returns the annotation of the doc comment of a field
+// of the given type with Config.AnnotateNullability, "(non-null)" if its
+// value is always present and "(nullable)" otherwise, or an empty string if
+// the option isn't set.
+func NullabilityAnnotation(cfg Config, ref *introspection.TypeRef) string {
+	if !cfg.AnnotateNullability || ref == nil {
+		return ""
+	}
+	if ref.IsOptional() {
+		return "(nullable)"
+	}
+	return "(non-null)"
+}
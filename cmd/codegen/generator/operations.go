@@ -0,0 +1,14 @@
+package generator
+
+import (
+	"github.com/iancoleman/strcase"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// OperationName returns the name of the GraphQL operation of the request of
+// a field, with Config.GenerateOperationNames: the name of its type followed
+// by its own, e.g. `ContainerStdout` for Container.stdout.
+func OperationName(f introspection.Field) string {
+	return f.ParentObject.Name + strcase.ToCamel(f.Name)
+}
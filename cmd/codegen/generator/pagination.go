@@ -0,0 +1,153 @@
+package generator
+
+import "github.com/dagger/dagger/cmd/codegen/introspection"
+
+// PaginationConvention names the fields of connection types, used to detect
+// the fields that can be paged through. It follows the GraphQL cursor
+// connections convention by default:
+//
+//	type Query { users(after: String): UserConnection! }
+//	type UserConnection { edges: [UserEdge!]!, pageInfo: PageInfo! }
+//	type UserEdge { node: User! }
+//	type PageInfo { hasNextPage: Boolean!, endCursor: String }
+//
+// The nodes must be objects that can be loaded from their ID, following the
+// convention of the engine: a node `User` must have an `id` field of the
+// `UserID` scalar, and the query a `loadUserFromID(id: UserID!)` field
+// returning it, see IDLoader. The connections of other nodes aren't paged
+// through.
+type PaginationConvention struct {
+	// After is the optional argument of the field taking the cursor to start after.
+	After string
+	// Edges is the field of a connection listing the edges of a page.
+	Edges string
+	// Node is the field of an edge returning its node.
+	Node string
+	// PageInfo is the field of a connection returning the page info.
+	PageInfo string
+	// HasNextPage is the field of the page info telling if there are more pages.
+	HasNextPage string
+	// EndCursor is the field of the page info returning the cursor of the last edge.
+	EndCursor string
+}
+
+// DefaultPaginationConvention is the convention used for the fields that are
+// not set in Config.PaginationConvention.
+var DefaultPaginationConvention = PaginationConvention{
+	After:       "after",
+	Edges:       "edges",
+	Node:        "node",
+	PageInfo:    "pageInfo",
+	HasNextPage: "hasNextPage",
+	EndCursor:   "endCursor",
+}
+
+// WithDefaults returns the convention with its unset fields set from
+// DefaultPaginationConvention.
+func (c PaginationConvention) WithDefaults() PaginationConvention {
+	def := DefaultPaginationConvention
+	if c.After == "" {
+		c.After = def.After
+	}
+	if c.Edges == "" {
+		c.Edges = def.Edges
+	}
+	if c.Node == "" {
+		c.Node = def.Node
+	}
+	if c.PageInfo == "" {
+		c.PageInfo = def.PageInfo
+	}
+	if c.HasNextPage == "" {
+		c.HasNextPage = def.HasNextPage
+	}
+	if c.EndCursor == "" {
+		c.EndCursor = def.EndCursor
+	}
+	return c
+}
+
+// PaginatedNode returns the type of the nodes of the connection returned by
+// f, or nil if f doesn't return a connection following the convention.
+func (c PaginationConvention) PaginatedNode(schema *introspection.Schema, f introspection.Field) *introspection.Type {
+	hasAfter := false
+	for _, arg := range f.Args {
+		if arg.Name == c.After && arg.IsOptional() && arg.TypeRef.IsScalar() {
+			hasAfter = true
+		}
+	}
+	if !hasAfter {
+		return nil
+	}
+
+	conn := objectType(schema, f.TypeRef)
+	if conn == nil {
+		return nil
+	}
+
+	edges := fieldOf(conn, c.Edges)
+	pageInfo := objectType(schema, fieldTypeRef(fieldOf(conn, c.PageInfo)))
+	if edges == nil || !edges.TypeRef.IsList() || pageInfo == nil {
+		return nil
+	}
+	if fieldOf(pageInfo, c.HasNextPage) == nil || fieldOf(pageInfo, c.EndCursor) == nil {
+		return nil
+	}
+
+	edge := objectType(schema, listElem(edges.TypeRef))
+	node := objectType(schema, fieldTypeRef(fieldOf(edge, c.Node)))
+	if IDLoader(schema, node) == nil {
+		return nil
+	}
+	if id := namedScalarRef(fieldTypeRef(fieldOf(node, "id"))); id.Name != node.Name+"ID" {
+		return nil
+	}
+	return node
+}
+
+// PaginatedConnection returns the connection type returned by f, or nil if f
+// doesn't return a connection following the convention.
+func (c PaginationConvention) PaginatedConnection(schema *introspection.Schema, f introspection.Field) *introspection.Type {
+	if c.PaginatedNode(schema, f) == nil {
+		return nil
+	}
+	return objectType(schema, f.TypeRef)
+}
+
+func fieldOf(t *introspection.Type, name string) *introspection.Field {
+	if t == nil {
+		return nil
+	}
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func fieldTypeRef(f *introspection.Field) *introspection.TypeRef {
+	if f == nil {
+		return nil
+	}
+	return f.TypeRef
+}
+
+// listElem returns the type of the elements of a list.
+func listElem(r *introspection.TypeRef) *introspection.TypeRef {
+	if r.Kind == introspection.TypeKindNonNull {
+		r = r.OfType
+	}
+	return r.OfType
+}
+
+// objectType returns the object type referenced by r, if any.
+func objectType(schema *introspection.Schema, r *introspection.TypeRef) *introspection.Type {
+	if r == nil || !r.IsObject() {
+		return nil
+	}
+	if r.Kind == introspection.TypeKindNonNull {
+		r = r.OfType
+	}
+	return schema.Types.Get(r.Name)
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// DefaultPreviewDirective is the directive flagging the preview fields of
+// Config.SeparatePreview.
+const DefaultPreviewDirective = "experimental"
+
+// previewOfDirective marks the objects made of the preview fields of another
+// object, with its name as "type" argument. A directive of a schema can't
+// start with `__`, so it doesn't collide with them.
+const previewOfDirective = "__previewOf"
+
+// SeparatePreviewFields moves the preview fields of each object, flagged with
+// the directive or, if the prefix isn't empty, named with the prefix, to a new
+// object named after it with a Preview suffix, e.g. ContainerPreview for the
+// preview fields of Container.
+//
+// The new object isn't part of the API: the generated code must select its
+// fields on the selection of the original object, e.g. with a Preview method
+// returning it. Interfaces are left as is, since their implementations must
+// keep all their fields. It's a no-op on a schema already separated.
+func SeparatePreviewFields(schema *introspection.Schema, directive, prefix string) error {
+	isPreview := func(f *introspection.Field) bool {
+		return (directive != "" && f.Directives.Directive(directive) != nil) ||
+			(prefix != "" && strings.HasPrefix(f.Name, prefix))
+	}
+
+	var previewTypes introspection.Types
+	for _, t := range schema.Types {
+		if t.Kind != introspection.TypeKindObject || t.Directives.Directive(previewOfDirective) != nil {
+			continue
+		}
+		var fields, previewFields []*introspection.Field
+		for _, f := range t.Fields {
+			if isPreview(f) {
+				previewFields = append(previewFields, f)
+			} else {
+				fields = append(fields, f)
+			}
+		}
+		if len(previewFields) == 0 {
+			continue
+		}
+
+		name := t.Name + "Preview"
+		if schema.Types.Get(name) != nil {
+			return fmt.Errorf("type %s of the preview fields of %s collides with a type of the schema", name, t.Name)
+		}
+		for _, f := range fields {
+			if f.Name == "preview" {
+				return fmt.Errorf("field %s.preview collides with the preview fields of %s", t.Name, t.Name)
+			}
+		}
+
+		goName := t.Name
+		if goName == QueryStructName {
+			goName = QueryStructClientName
+		}
+		typeName := strconv.Quote(t.Name)
+		t.Fields = fields
+		previewTypes = append(previewTypes, &introspection.Type{
+			Kind: introspection.TypeKindObject,
+			Name: name,
+			Description: fmt.Sprintf("The preview fields of %s.\n\n"+
+				"WARNING: they are unstable, and may change or be removed in any release.", goName),
+			Fields:     previewFields,
+			Directives: introspection.Directives{{Name: previewOfDirective, Args: []*introspection.DirectiveArg{{Name: "type", Value: &typeName}}}},
+		})
+	}
+	schema.Types = append(schema.Types, previewTypes...)
+	SetSchemaParents(schema)
+	return nil
+}
+
+// PreviewTypeOf returns the object made of the preview fields of the object
+// by SeparatePreviewFields, or nil if it has none.
+func PreviewTypeOf(schema *introspection.Schema, t *introspection.Type) *introspection.Type {
+	previewType := schema.Types.Get(t.Name + "Preview")
+	if previewType == nil {
+		return nil
+	}
+	d := previewType.Directives.Directive(previewOfDirective)
+	if d == nil || d.Arg("type") == nil || *d.Arg("type").Value != strconv.Quote(t.Name) {
+		return nil
+	}
+	return previewType
+}
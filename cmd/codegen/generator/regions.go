@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserRegionMarkers are the markers delimiting a user-editable region of a
+// generated file. Lines containing Start and End delimit the region, and the
+// content between them is preserved across regeneration.
+type UserRegionMarkers struct {
+	Start string
+	End   string
+}
+
+// Enabled returns true if both markers are configured.
+func (m UserRegionMarkers) Enabled() bool {
+	return m.Start != "" && m.End != ""
+}
+
+type userRegion struct {
+	// key identifies the region, from its start line
+	key string
+	// start and end are the indexes of the marker lines
+	start, end int
+}
+
+// SpliceUserRegions copies the user regions of the existing content into the
+// regions of the generated content.
+//
+// Regions are matched by their start line, e.g. `// dagger:user-region:start
+// imports`, so a file can have multiple regions. If several regions have the
+// same start line they are matched in order. Regions of the generated content
+// that don't exist in the existing content are kept as generated.
+func SpliceUserRegions(existing, generated []byte, markers UserRegionMarkers) ([]byte, error) {
+	if !markers.Enabled() {
+		return generated, nil
+	}
+
+	oldLines := strings.SplitAfter(string(existing), "\n")
+	oldRegions, err := findUserRegions(oldLines, markers)
+	if err != nil {
+		return nil, fmt.Errorf("existing content: %w", err)
+	}
+	if len(oldRegions) == 0 {
+		return generated, nil
+	}
+
+	preserved := map[string][][]string{}
+	for _, r := range oldRegions {
+		preserved[r.key] = append(preserved[r.key], oldLines[r.start+1:r.end])
+	}
+
+	newLines := strings.SplitAfter(string(generated), "\n")
+	newRegions, err := findUserRegions(newLines, markers)
+	if err != nil {
+		return nil, fmt.Errorf("generated content: %w", err)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, r := range newRegions {
+		content := preserved[r.key]
+		if len(content) == 0 {
+			continue
+		}
+		preserved[r.key] = content[1:]
+
+		b.WriteString(strings.Join(newLines[last:r.start+1], ""))
+		b.WriteString(strings.Join(content[0], ""))
+		last = r.end
+	}
+	b.WriteString(strings.Join(newLines[last:], ""))
+	return []byte(b.String()), nil
+}
+
+func findUserRegions(lines []string, markers UserRegionMarkers) ([]userRegion, error) {
+	var regions []userRegion
+	for i := 0; i < len(lines); i++ {
+		if !strings.Contains(lines[i], markers.Start) {
+			continue
+		}
+
+		region := userRegion{key: strings.TrimSpace(lines[i]), start: i, end: -1}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.Contains(lines[j], markers.Start) {
+				return nil, fmt.Errorf("nested user region at line %d", j+1)
+			}
+			if strings.Contains(lines[j], markers.End) {
+				region.end = j
+				break
+			}
+		}
+		if region.end == -1 {
+			return nil, fmt.Errorf("unterminated user region at line %d", i+1)
+		}
+
+		regions = append(regions, region)
+		i = region.end
+	}
+	return regions, nil
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// NewGeneratorFunc creates the Generator of a language for the given config.
+type NewGeneratorFunc func(cfg Config) Generator
+
+var (
+	registry   = map[SDKLang]NewGeneratorFunc{}
+	registryMu sync.RWMutex
+)
+
+// Register makes a Generator available for the given language.
+// It is meant to be called from the init function of the package
+// implementing the generator.
+func Register(lang SDKLang, fn NewGeneratorFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[lang]; ok {
+		panic(fmt.Sprintf("generator already registered for %s", lang))
+	}
+	registry[lang] = fn
+}
+
+// SupportedLangs returns all the languages a Generator has been registered
+// for, sorted by name.
+func SupportedLangs() []SDKLang {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	langs := make([]SDKLang, 0, len(registry))
+	for lang := range registry {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// New creates the Generator for the language of the given config.
+func New(cfg Config) (Generator, error) {
+	registryMu.RLock()
+	fn, ok := registry[cfg.Lang]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("use target SDK language: %s: %w", SupportedLangs(), ErrUnknownSDKLang)
+	}
+	return fn(cfg), nil
+}
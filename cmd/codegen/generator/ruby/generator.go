@@ -0,0 +1,102 @@
+package rubygenerator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/psanford/memfs"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/ruby/templates"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+const (
+	ClientGenFile = "dagger.gen.rb"
+
+	// GemfileFile and GemspecFile are the paths to write the gem of the client
+	// when initializing it.
+	GemfileFile = "Gemfile"
+	GemspecFile = "dagger.gemspec"
+)
+
+type RubyGenerator struct {
+	Config generator.Config
+}
+
+func init() {
+	generator.Register(generator.SDKLangRuby, func(cfg generator.Config) generator.Generator {
+		return &RubyGenerator{Config: cfg}
+	})
+}
+
+func (g *RubyGenerator) GenerateModule(_ context.Context, _ *introspection.Schema, _ string) (*generator.GeneratedState, error) {
+	return nil, errors.New("ruby modules are not supported, only clients")
+}
+
+// ModuleLayout fails since Ruby modules are not supported.
+func (g *RubyGenerator) ModuleLayout(_ generator.Config) (generator.Layout, error) {
+	return generator.Layout{}, errors.New("ruby modules are not supported, only clients")
+}
+
+func (g *RubyGenerator) GenerateClient(_ context.Context, schema *introspection.Schema, schemaVersion string) (*generator.GeneratedState, error) {
+	generator.SetSchema(schema)
+
+	tmpl := templates.New(schemaVersion, g.Config)
+	data := struct {
+		Schema        *introspection.Schema
+		SchemaVersion string
+		Types         []*introspection.Type
+	}{
+		Schema:        schema,
+		SchemaVersion: schemaVersion,
+		Types:         schema.Visit(),
+	}
+
+	files := map[string]string{ClientGenFile: "client"}
+	if g.Config.IsInit {
+		files[GemfileFile] = "gemfile"
+		files[GemspecFile] = "gemspec"
+	}
+
+	mfs := memfs.New()
+	for target, name := range files {
+		var b bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&b, name, data); err != nil {
+			return nil, err
+		}
+		if err := mfs.WriteFile(target, formatRuby(b.Bytes()), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+
+	return &generator.GeneratedState{
+		Overlay: mfs,
+		PostCommands: []*exec.Cmd{
+			// only fail on errors the formatting can't recover from, since the
+			// comments of the schema may not follow all the rules
+			exec.Command("rubocop", "-A", "--fail-level", "fatal", ClientGenFile),
+		},
+	}, nil
+}
+
+var (
+	trailingSpaces = regexp.MustCompile(`(?m)[ \t]+$`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+	blankAfterOpen = regexp.MustCompile(`(?m)^([ \t]*(?:class|module) [^;\n]*|[ \t]*[^#\s].* do(?: \|[^|\n]*\|)?)\n\n`)
+	blankBeforeEnd = regexp.MustCompile(`(?m)\n\n([ \t]*end)$`)
+)
+
+// formatRuby removes the whitespace left by the templates: trailing spaces,
+// consecutive blank lines and blank lines at the start or end of a block.
+func formatRuby(src []byte) []byte {
+	src = trailingSpaces.ReplaceAll(src, nil)
+	src = blankLines.ReplaceAll(src, []byte("\n\n"))
+	src = blankAfterOpen.ReplaceAll(src, []byte("$1\n"))
+	src = blankBeforeEnd.ReplaceAll(src, []byte("\n$1"))
+	return append(bytes.TrimSpace(src), '\n')
+}
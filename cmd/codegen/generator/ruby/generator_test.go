@@ -0,0 +1,76 @@
+package rubygenerator
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+var updateFixtures = flag.Bool("test.update-fixtures", false, "update the test fixtures")
+
+func generateFixture(t *testing.T, cfg generator.Config, name string) *generator.GeneratedState {
+	t.Helper()
+	dt, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	cfg.ClientOnly = true
+	generated, err := (&RubyGenerator{Config: cfg}).GenerateClient(context.Background(), resp.Schema, resp.SchemaVersion)
+	require.NoError(t, err)
+	return generated
+}
+
+func TestGenerateClient(t *testing.T) {
+	generated := generateFixture(t, generator.Config{}, "basic.json")
+
+	got, err := fs.ReadFile(generated.Overlay, ClientGenFile)
+	require.NoError(t, err)
+
+	wantPath := filepath.Join("testdata", "basic_want.rb")
+	if *updateFixtures {
+		require.NoError(t, os.WriteFile(wantPath, got, 0o600))
+	}
+	want, err := os.ReadFile(wantPath)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+
+	_, err = fs.Stat(generated.Overlay, GemfileFile)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.Len(t, generated.PostCommands, 1)
+	require.Equal(t, []string{"rubocop", "-A", "--fail-level", "fatal", ClientGenFile}, generated.PostCommands[0].Args)
+}
+
+func TestGenerateInit(t *testing.T) {
+	generated := generateFixture(t, generator.Config{IsInit: true}, "basic.json")
+
+	gemfile, err := fs.ReadFile(generated.Overlay, GemfileFile)
+	require.NoError(t, err)
+	require.Contains(t, string(gemfile), "gemspec\n")
+
+	gemspec, err := fs.ReadFile(generated.Overlay, GemspecFile)
+	require.NoError(t, err)
+	require.Contains(t, string(gemspec), `spec.files = ["dagger.gen.rb"]`)
+}
+
+func TestGenerateModule(t *testing.T) {
+	_, err := (&RubyGenerator{}).GenerateModule(context.Background(), &introspection.Schema{}, "")
+	require.ErrorContains(t, err, "ruby modules are not supported")
+}
+
+func TestModuleLayout(t *testing.T) {
+	_, err := (&RubyGenerator{}).ModuleLayout(generator.Config{ModuleName: "my-module", ModuleSourcePath: "."})
+	require.ErrorContains(t, err, "ruby modules are not supported")
+}
@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+)
+
+// FormatTypeFunc is an implementation of generator.FormatTypeFuncs interface
+// to format GraphQL type into the Ruby types of the documentation.
+type FormatTypeFunc struct {
+	scope          string
+	formatNameFunc func(s string) string
+}
+
+func (f *FormatTypeFunc) WithScope(scope string) generator.FormatTypeFuncs {
+	if scope != "" {
+		scope += "::"
+	}
+	clone := *f
+	clone.scope = scope
+	return &clone
+}
+
+func (f *FormatTypeFunc) FormatKindList(representation string) string {
+	return "Array<" + representation + ">"
+}
+
+func (f *FormatTypeFunc) FormatKindScalarString(representation string) string {
+	representation += "String"
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindScalarInt(representation string) string {
+	representation += "Integer"
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindScalarFloat(representation string) string {
+	representation += "Float"
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindScalarBoolean(representation string) string {
+	representation += "Boolean"
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindScalarDefault(representation string, refName string, input bool) string {
+	if obj, rest, ok := strings.Cut(refName, "ID"); input && ok && obj != "" && rest == "" {
+		// objects are accepted for their ID, e.g. a Foo for a FooID
+		representation += f.scope + f.formatNameFunc(obj)
+	} else {
+		// custom scalars are sent and returned as strings
+		representation += "String"
+	}
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindObject(representation string, refName string, input bool) string {
+	representation += f.scope + f.formatNameFunc(refName)
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindInterface(representation string, refName string, input bool) string {
+	representation += f.scope + f.formatNameFunc(refName)
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindInputObject(representation string, refName string, input bool) string {
+	representation += f.scope + f.formatNameFunc(refName)
+	return representation
+}
+
+func (f *FormatTypeFunc) FormatKindEnum(representation string, refName string) string {
+	// enums values are symbols, see the constants of the enum module
+	representation += "Symbol"
+	return representation
+}
@@ -0,0 +1,234 @@
+package templates
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func RubyTemplateFuncs(
+	schemaVersion string,
+	cfg generator.Config,
+) template.FuncMap {
+	return rubyTemplateFuncs{
+		cfg:           cfg,
+		schemaVersion: schemaVersion,
+	}.FuncMap()
+}
+
+type rubyTemplateFuncs struct {
+	schemaVersion string
+	cfg           generator.Config
+}
+
+func (funcs rubyTemplateFuncs) FuncMap() template.FuncMap {
+	commonFunc := generator.NewCommonFunctions(funcs.schemaVersion, &FormatTypeFunc{
+		formatNameFunc: funcs.formatName,
+	})
+	return template.FuncMap{
+		"Comment":          funcs.comment,
+		"OneLine":          funcs.oneLine,
+		"FormatName":       funcs.formatName,
+		"FormatInputType":  commonFunc.FormatInputType,
+		"FormatOutputType": commonFunc.FormatOutputType,
+		"FormatEnum":       funcs.formatEnum,
+		"MethodName":       funcs.methodName,
+		"ArgName":          funcs.argName,
+		"MethodParams":     funcs.methodParams,
+		"RequiredArgs":     funcs.requiredArgs,
+		"OptionalArgs":     funcs.optionalArgs,
+		"FieldKind":        funcs.fieldKind,
+		"ClassName":        funcs.className,
+		"Loader":           funcs.loader,
+		"HasPrefix":        strings.HasPrefix,
+	}
+}
+
+// comment formats a description as a comment with the given indentation.
+// Example: `Foo.\nBar.` -> `  # Foo.\n  # Bar.`
+func (funcs rubyTemplateFuncs) comment(indent string, s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	lines := strings.Split(generator.WrapText(s, funcs.cfg.DocCommentWrap-len(indent+"# ")), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(indent+"# "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// oneLine joins the lines of a description, to be used in a documentation tag.
+func (funcs rubyTemplateFuncs) oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// formatName formats a GraphQL type name into a Ruby constant name.
+// Example: `Query` -> `Client`, `fooBar` -> `FooBar`
+func (funcs rubyTemplateFuncs) formatName(s string) string {
+	if s == generator.QueryStructName {
+		return generator.QueryStructClientName
+	}
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// formatEnum formats a GraphQL enum value into a Ruby constant name.
+// Example: `TCP` -> `TCP`, `fooBar` -> `FOO_BAR`
+func (funcs rubyTemplateFuncs) formatEnum(s string) string {
+	if s != "" && s[0] >= 'A' && s[0] <= 'Z' {
+		return s
+	}
+	return strcase.ToScreamingSnake(s)
+}
+
+// rubyKeywords are the reserved words of Ruby, which can't be used as local
+// variable names.
+var rubyKeywords = map[string]bool{
+	"__ENCODING__": true, "__FILE__": true, "__LINE__": true, "BEGIN": true, "END": true,
+	"alias": true, "and": true, "begin": true, "break": true, "case": true, "class": true,
+	"def": true, "defined?": true, "do": true, "else": true, "elsif": true, "end": true,
+	"ensure": true, "false": true, "for": true, "if": true, "in": true, "module": true,
+	"next": true, "nil": true, "not": true, "or": true, "redo": true, "rescue": true,
+	"retry": true, "return": true, "self": true, "super": true, "then": true, "true": true,
+	"undef": true, "unless": true, "until": true, "when": true, "while": true, "yield": true,
+}
+
+// reservedMethods are the methods of the generated objects that the fields
+// can't override.
+var reservedMethods = map[string]bool{
+	"class": true, "clone": true, "display": true, "dup": true, "freeze": true,
+	"hash": true, "initialize": true, "inspect": true, "method": true, "methods": true,
+	"object_id": true, "send": true, "to_s": true, "with": true,
+}
+
+// methodName formats a GraphQL field name into a Ruby method name, suffixed
+// with `_` if it's reserved.
+// Example: `withExec` -> `with_exec`, `hash` -> `hash_`
+func (funcs rubyTemplateFuncs) methodName(s string) string {
+	s = strcase.ToSnake(s)
+	if rubyKeywords[s] || reservedMethods[s] {
+		s += "_"
+	}
+	return s
+}
+
+// argName formats a GraphQL argument name into a Ruby argument name, suffixed
+// with `_` if it's reserved.
+// `query` is reserved for the query of the generated methods.
+// Example: `skipEntrypoint` -> `skip_entrypoint`, `end` -> `end_`
+func (funcs rubyTemplateFuncs) argName(s string) string {
+	s = strcase.ToSnake(s)
+	if rubyKeywords[s] || s == "query" {
+		s += "_"
+	}
+	return s
+}
+
+func (funcs rubyTemplateFuncs) requiredArgs(args introspection.InputValues) introspection.InputValues {
+	var required introspection.InputValues
+	for _, arg := range args {
+		if !arg.IsOptional() {
+			required = append(required, arg)
+		}
+	}
+	return required
+}
+
+func (funcs rubyTemplateFuncs) optionalArgs(args introspection.InputValues) introspection.InputValues {
+	var optional introspection.InputValues
+	for _, arg := range args {
+		if arg.IsOptional() {
+			optional = append(optional, arg)
+		}
+	}
+	return optional
+}
+
+// methodParams returns the parameters of the method of a field: the required
+// arguments are positional, and the optional ones are keyword arguments
+// defaulting to nil.
+// Example: `from(address: String!)` -> `address`,
+// `withExec(args: [String!]!, expand: Boolean)` -> `args, expand: nil`
+func (funcs rubyTemplateFuncs) methodParams(args introspection.InputValues) string {
+	params := make([]string, 0, len(args))
+	for _, arg := range funcs.requiredArgs(args) {
+		params = append(params, funcs.argName(arg.Name))
+	}
+	for _, arg := range funcs.optionalArgs(args) {
+		params = append(params, funcs.argName(arg.Name)+": nil")
+	}
+	return strings.Join(params, ", ")
+}
+
+// innerType returns the named type wrapped by a type ref, and whether it's a
+// list.
+func innerType(r *introspection.TypeRef) (*introspection.TypeRef, bool) {
+	var list bool
+	for r.OfType != nil {
+		if r.Kind == introspection.TypeKindList {
+			list = true
+		}
+		r = r.OfType
+	}
+	return r, list
+}
+
+// fieldKind returns how the method of a field returns its value:
+//   - `object`: an object, selected without making a request
+//   - `objects`: a list of objects, loaded from their ID
+//   - `self`: the object of the method, after making the request
+//   - `enum` or `enums`: a symbol or a list of symbols
+//   - `value`: the value returned by the API
+//   - `unsupported`: a list of objects that can't be loaded from their ID
+func (funcs rubyTemplateFuncs) fieldKind(f introspection.Field) string {
+	ref, list := innerType(f.TypeRef)
+	switch ref.Kind {
+	case introspection.TypeKindObject, introspection.TypeKindInterface:
+		if !list {
+			return "object"
+		}
+		if funcs.loader(f) == "" {
+			return "unsupported"
+		}
+		return "objects"
+	case introspection.TypeKindEnum:
+		if list {
+			return "enums"
+		}
+		return "enum"
+	}
+	if f.ParentObject != nil && generator.NewCommonFunctions(funcs.schemaVersion, nil).ConvertID(f) {
+		return "self"
+	}
+	return "value"
+}
+
+// className returns the name of the class of the objects returned by a field.
+func (funcs rubyTemplateFuncs) className(f introspection.Field) string {
+	ref, _ := innerType(f.TypeRef)
+	return funcs.formatName(ref.Name)
+}
+
+// loader returns the name of the field of the query type loading the objects
+// returned by a field from their ID, or an empty string if there's none.
+func (funcs rubyTemplateFuncs) loader(f introspection.Field) string {
+	ref, _ := innerType(f.TypeRef)
+	schema := generator.GetSchema()
+	if schema == nil || schema.Query() == nil {
+		return ""
+	}
+	name := "load" + ref.Name + "FromID"
+	for _, field := range schema.Query().Fields {
+		if field.Name == name {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"text/template"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+)
+
+//go:embed src
+var srcs embed.FS
+
+// New creates a new template with all the template dependencies set up.
+func New(
+	schemaVersion string,
+	cfg generator.Config,
+) *template.Template {
+	topLevelTemplate := "client"
+	templateDeps := []string{
+		topLevelTemplate, "runtime", "object", "method", "input", "enum", "gemfile", "gemspec",
+	}
+
+	fileNames := make([]string, 0, len(templateDeps))
+	for _, tmpl := range templateDeps {
+		fileNames = append(fileNames, fmt.Sprintf("src/%s.rb.gtpl", tmpl))
+	}
+
+	funcs := RubyTemplateFuncs(schemaVersion, cfg)
+	tmpl := template.Must(template.New(topLevelTemplate).Funcs(funcs).ParseFS(srcs, fileNames...))
+	return tmpl
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ScalarSerializer is how the generated client represents a custom scalar
+// and serializes it on the wire, where it's a string, see
+// Config.ScalarSerializers.
+//
+// Example for a DateTime scalar of RFC 3339 timestamps:
+//
+//	ScalarSerializer{
+//		GoType:    "time.Time",
+//		Import:    "time",
+//		Marshal:   "v.Format(time.RFC3339Nano)",
+//		Unmarshal: "time.Parse(time.RFC3339Nano, s)",
+//	}
+type ScalarSerializer struct {
+	// GoType is the type of the values of the scalar, e.g. `time.Time`. The
+	// scalar is generated as a type defined from it, so it must not be a
+	// pointer.
+	GoType string
+
+	// Import is the package of GoType, which Marshal and Unmarshal can use
+	// too, e.g. `time`. It's empty for a built-in type.
+	Import string
+
+	// Marshal is the expression of the string on the wire of the value `v` of
+	// GoType, e.g. `v.Format(time.RFC3339Nano)`.
+	Marshal string
+
+	// Unmarshal is the expression of the value of GoType, and of an error if
+	// it isn't valid, of the string `s` on the wire, e.g.
+	// `time.Parse(time.RFC3339Nano, s)`.
+	Unmarshal string
+}
+
+// validateScalarSerializers checks that the serializers have all their code.
+func validateScalarSerializers(serializers map[string]ScalarSerializer) error {
+	names := make([]string, 0, len(serializers))
+	for name := range serializers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		serializer := serializers[name]
+		if serializer.GoType == "" || serializer.Marshal == "" || serializer.Unmarshal == "" {
+			return fmt.Errorf("serializer of scalar %s requires a Go type, marshal and unmarshal code", name)
+		}
+		if strings.HasPrefix(serializer.GoType, "*") {
+			// the scalar type must have methods to serialize its values
+			return fmt.Errorf("go type %s of scalar %s must not be a pointer", serializer.GoType, name)
+		}
+	}
+	return nil
+}
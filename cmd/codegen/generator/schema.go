@@ -1,6 +1,14 @@
 package generator
 
-import "github.com/dagger/dagger/cmd/codegen/introspection"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
 
 var _schema *introspection.Schema
 
@@ -11,3 +19,43 @@ func SetSchema(schema *introspection.Schema) {
 func GetSchema() *introspection.Schema {
 	return _schema
 }
+
+// IntrospectionJSON returns the introspection response of the schema and its
+// version as minified JSON, embedded in the generated code with
+// Config.EmbedSchema. It only depends on the schema, in its order, so that
+// the generated code is unchanged for an unchanged schema.
+func IntrospectionJSON(schema *introspection.Schema, schemaVersion string) ([]byte, error) {
+	dt, err := json.Marshal(introspection.Response{
+		Schema:        schema,
+		SchemaVersion: schemaVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal introspection json: %w", err)
+	}
+	return dt, nil
+}
+
+// NormalizeIntrospection re-serializes an introspection document as minified
+// JSON with the keys of its objects sorted, so that equivalent documents from
+// different engine runs hash the same. The numbers and the order of the lists
+// are kept as is.
+func NormalizeIntrospection(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("normalize introspection json: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("normalize introspection json: unexpected data after the document")
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// maps are encoded with sorted keys
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("normalize introspection json: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
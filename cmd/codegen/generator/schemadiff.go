@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// SchemaChange is how a type or a field changed from a schema to another.
+type SchemaChange string
+
+const (
+	SchemaAdded      SchemaChange = "added"
+	SchemaRemoved    SchemaChange = "removed"
+	SchemaDeprecated SchemaChange = "deprecated"
+)
+
+// SchemaDiff is a type, or a field of a type, changed from a schema to
+// another.
+type SchemaDiff struct {
+	Type string
+
+	// Field is the name of the field, the input field or the enum value,
+	// empty for a change of the type itself.
+	Field string
+
+	Change SchemaChange
+
+	// Reason is the deprecation reason of a deprecated field or enum value.
+	Reason string
+}
+
+// DiffSchemas returns the types, fields, input fields and enum values added
+// to, removed from or deprecated in the new schema compared to the old one,
+// sorted by type and field. The fields of an added or removed type aren't
+// listed, and a type of another kind is removed then added. The introspection
+// types are ignored.
+func DiffSchemas(oldSchema, newSchema *introspection.Schema) []SchemaDiff {
+	var diffs []SchemaDiff
+	for _, oldType := range oldSchema.Types {
+		if strings.HasPrefix(oldType.Name, "__") {
+			continue
+		}
+		newType := newSchema.Types.Get(oldType.Name)
+		if newType == nil || newType.Kind != oldType.Kind {
+			diffs = append(diffs, SchemaDiff{Type: oldType.Name, Change: SchemaRemoved})
+			continue
+		}
+		diffs = append(diffs, diffMembers(oldType, newType)...)
+	}
+	for _, newType := range newSchema.Types {
+		if strings.HasPrefix(newType.Name, "__") {
+			continue
+		}
+		if oldType := oldSchema.Types.Get(newType.Name); oldType == nil || oldType.Kind != newType.Kind {
+			diffs = append(diffs, SchemaDiff{Type: newType.Name, Change: SchemaAdded})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Type != diffs[j].Type {
+			return diffs[i].Type < diffs[j].Type
+		}
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs
+}
+
+// schemaMember is a field, an input field or an enum value of a type.
+type schemaMember struct {
+	deprecated bool
+	reason     string
+}
+
+func typeMembers(t *introspection.Type) map[string]schemaMember {
+	members := map[string]schemaMember{}
+	for _, f := range t.Fields {
+		members[f.Name] = schemaMember{deprecated: f.IsDeprecated, reason: f.DeprecationReason}
+	}
+	for _, f := range t.InputFields {
+		members[f.Name] = schemaMember{}
+	}
+	for _, v := range t.EnumValues {
+		members[v.Name] = schemaMember{deprecated: v.IsDeprecated, reason: v.DeprecationReason}
+	}
+	return members
+}
+
+// diffMembers returns the members of the type added, removed or deprecated
+// from the old type to the new one.
+func diffMembers(oldType, newType *introspection.Type) []SchemaDiff {
+	oldMembers := typeMembers(oldType)
+	newMembers := typeMembers(newType)
+
+	var diffs []SchemaDiff
+	for name, oldMember := range oldMembers {
+		newMember, ok := newMembers[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, SchemaDiff{Type: oldType.Name, Field: name, Change: SchemaRemoved})
+		case newMember.deprecated && !oldMember.deprecated:
+			diffs = append(diffs, SchemaDiff{Type: oldType.Name, Field: name, Change: SchemaDeprecated, Reason: newMember.reason})
+		}
+	}
+	for name := range newMembers {
+		if _, ok := oldMembers[name]; !ok {
+			diffs = append(diffs, SchemaDiff{Type: newType.Name, Field: name, Change: SchemaAdded})
+		}
+	}
+	return diffs
+}
+
+// GenerateChangelog returns a markdown changelog of the changes of the API
+// from the old schema to the new one, e.g. for release notes: the added and
+// removed types, then the added, removed and deprecated fields, each sorted
+// by name.
+func GenerateChangelog(oldSchema, newSchema *introspection.Schema) (string, error) {
+	if oldSchema == nil || newSchema == nil {
+		return "", errors.New("changelog requires two schemas")
+	}
+	diffs := DiffSchemas(oldSchema, newSchema)
+
+	sections := []struct {
+		title  string
+		change SchemaChange
+		fields bool
+	}{
+		{"Added types", SchemaAdded, false},
+		{"Removed types", SchemaRemoved, false},
+		{"Added fields", SchemaAdded, true},
+		{"Removed fields", SchemaRemoved, true},
+		{"Deprecated fields", SchemaDeprecated, true},
+	}
+
+	var b strings.Builder
+	b.WriteString("# API changes\n")
+	if len(diffs) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String(), nil
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, diff := range diffs {
+			if diff.Change != section.change || (diff.Field != "") != section.fields {
+				continue
+			}
+			line := fmt.Sprintf("- `%s`", diff.Type)
+			if diff.Field != "" {
+				line = fmt.Sprintf("- `%s.%s`", diff.Type, diff.Field)
+			}
+			if diff.Reason != "" {
+				line += ": " + diff.Reason
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", section.title, strings.Join(lines, "\n"))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+const (
+	// SchemaSnapshotFile is the normalized introspection JSON of the schema
+	// in a schema snapshot directory, see NormalizeIntrospection.
+	SchemaSnapshotFile = "introspection.json"
+
+	// SchemaSnapshotVersionFile is the version of the schema in a schema
+	// snapshot directory.
+	SchemaSnapshotVersionFile = "schema-version"
+)
+
+// WriteSchemaSnapshot introspects the engine and writes its schema to dir,
+// creating it if needed, for Config.SchemaSnapshotDir to generate code from
+// it without an engine.
+func WriteSchemaSnapshot(ctx context.Context, dag *dagger.Client, dir string) error {
+	schema, schemaVersion, err := Introspect(ctx, dag, "")
+	if err != nil {
+		return err
+	}
+	return writeSchemaSnapshot(dir, schema, schemaVersion)
+}
+
+func writeSchemaSnapshot(dir string, schema *introspection.Schema, schemaVersion string) error {
+	// the version is only in its file
+	dt, err := json.Marshal(map[string]any{"__schema": schema})
+	if err != nil {
+		return fmt.Errorf("marshal introspection json: %w", err)
+	}
+	dt, err = NormalizeIntrospection(dt)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, SchemaSnapshotFile), append(dt, '\n'), 0o644); err != nil { //nolint: gosec
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SchemaSnapshotVersionFile), []byte(schemaVersion+"\n"), 0o644) //nolint: gosec
+}
+
+// ReadSchemaSnapshot reads the schema and its version from a directory
+// written by WriteSchemaSnapshot.
+func ReadSchemaSnapshot(dir string) (*introspection.Schema, string, error) {
+	dt, err := os.ReadFile(filepath.Join(dir, SchemaSnapshotFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("read schema snapshot: %w", err)
+	}
+	var resp introspection.Response
+	if err := json.Unmarshal(dt, &resp); err != nil {
+		return nil, "", fmt.Errorf("unmarshal schema snapshot %s: %w", SchemaSnapshotFile, err)
+	}
+	if resp.Schema == nil {
+		return nil, "", fmt.Errorf("schema snapshot %s has no schema", SchemaSnapshotFile)
+	}
+
+	version, err := os.ReadFile(filepath.Join(dir, SchemaSnapshotVersionFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("read schema snapshot: %w", err)
+	}
+	return resp.Schema, strings.TrimSpace(string(version)), nil
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// OverlayToTar writes the overlay as a tar archive to w.
+//
+// Entries are written in the same order Overlay applies them, with fixed
+// modes and timestamps, so the same overlay always produces the same archive.
+func OverlayToTar(overlay fs.FS, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := walkOverlay(overlay, func(path string, d fs.DirEntry) error {
+		if path == "." {
+			return nil
+		}
+
+		hdr := &tar.Header{
+			Name:    path,
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}
+
+		if d.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			hdr.Mode = 0o755
+			return tw.WriteHeader(hdr)
+		}
+
+		content, err := fs.ReadFile(overlay, path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+
+		hdr.Typeflag = tar.TypeReg
+		hdr.Mode = 0o644
+		hdr.Size = int64(len(content))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("write header %s: %w", path, err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// TypeIDs gives a scalar of its own to the ID of each object declared with the
+// built-in ID scalar, see Config.TypedIDs: the `id` field of an object T
+// returns a new TID scalar, and so do the ID arguments named after T, e.g.
+// `fileID: ID!` or `fileId: ID!` for File, and the `id` argument of its
+// `loadTFromID` field. It fails if the scalar of an object collides with
+// another type of the schema.
+func TypeIDs(schema *introspection.Schema) error {
+	// the scalars of the objects by lowercase name, for the ID arguments
+	scalars := map[string]string{}
+	for _, t := range schema.Types {
+		if t.Kind != introspection.TypeKindObject {
+			continue
+		}
+		ref := namedScalarRef(fieldTypeRef(fieldOf(t, "id")))
+		if ref == nil || ref.Name != "ID" {
+			continue
+		}
+		name := t.Name + "ID"
+		if other := schema.Types.Get(name); other != nil && other.Kind != introspection.TypeKindScalar {
+			return fmt.Errorf("id scalar %s of %s collides with a type of the schema", name, t.Name)
+		}
+		ref.Name = name
+		scalars[strings.ToLower(t.Name)] = name
+	}
+	if len(scalars) == 0 {
+		return nil
+	}
+
+	retype := func(parent *introspection.Field, arg introspection.InputValue) {
+		ref := namedScalarRef(arg.TypeRef)
+		if ref == nil || ref.Name != "ID" {
+			return
+		}
+		obj, ok := strings.CutSuffix(arg.Name, "ID")
+		if !ok {
+			obj, ok = strings.CutSuffix(arg.Name, "Id")
+		}
+		if arg.Name == "id" && parent != nil {
+			obj, ok = strings.CutPrefix(strings.TrimSuffix(parent.Name, "FromID"), "load")
+		}
+		if name, found := scalars[strings.ToLower(obj)]; ok && found {
+			ref.Name = name
+		}
+	}
+	for _, t := range schema.Types {
+		for _, f := range t.Fields {
+			for _, arg := range f.Args {
+				retype(f, arg)
+			}
+		}
+		for _, f := range t.InputFields {
+			retype(nil, f)
+		}
+	}
+
+	names := make([]string, 0, len(scalars))
+	for _, name := range scalars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if schema.Types.Get(name) != nil {
+			continue
+		}
+		schema.Types = append(schema.Types, &introspection.Type{
+			Kind:        introspection.TypeKindScalar,
+			Name:        name,
+			Description: fmt.Sprintf("The `%s` scalar type represents an identifier for an object of type %s.", name, strings.TrimSuffix(name, "ID")),
+		})
+	}
+	return nil
+}
+
+// namedScalarRef returns the scalar referenced by r, if it's not a list.
+func namedScalarRef(r *introspection.TypeRef) *introspection.TypeRef {
+	if r == nil {
+		return nil
+	}
+	if r.Kind == introspection.TypeKindNonNull {
+		r = r.OfType
+	}
+	if r.Kind != introspection.TypeKindScalar {
+		return nil
+	}
+	return r
+}
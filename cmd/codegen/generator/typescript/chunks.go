@@ -0,0 +1,149 @@
+package typescriptgenerator
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/psanford/memfs"
+
+	"github.com/dagger/dagger/cmd/codegen/generator/typescript/templates"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+var (
+	// the declarations exported by the client, and whether they're values
+	clientExportRe = regexp.MustCompile(`(?m)^export (?:async )?(type|enum|class|const|function) (\w+)`)
+	contextExport  = "export type { Context }"
+
+	chunkClassRe = regexp.MustCompile(`(?m)^export class (\w+) extends BaseClient`)
+	commentRe    = regexp.MustCompile("(?s)/\\*.*?\\*/|//[^\n]*")
+	stringRe     = regexp.MustCompile("\"(?:[^\"\\\\\n]|\\\\.)*\"|`[^`]*`")
+	// the methods of the classes and the members they access
+	memberRe     = regexp.MustCompile(`(?m)^\s+[\w$]+ = |\??\.[\w$]+`)
+	identifierRe = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+	// the values of the client every chunk imports
+	chunkValues = []string{"BaseClient", "registerClass"}
+)
+
+type chunkImport struct {
+	Name   string
+	Import string
+}
+
+// generateChunks writes the chunk of each object of the client next to it,
+// with Config.TypeScriptCodeSplit. Each chunk imports what it references from
+// the client, and the types of the other classes from their chunks, so that
+// only the chunks themselves import each other at runtime.
+func (g *TypeScriptGenerator) generateChunks(mfs *memfs.FS, tmpl *template.Template, types []*introspection.Type, dir string, client []byte) error {
+	exports := map[string]bool{}
+	for _, m := range clientExportRe.FindAllSubmatch(client, -1) {
+		exports[string(m[2])] = string(m[1]) != "type"
+	}
+	if bytes.Contains(client, []byte(contextExport)) {
+		exports["Context"] = false
+	}
+
+	classes := map[string]string{}
+	bodies := map[string]string{}
+	chunkTypes := []*introspection.Type{}
+	for _, t := range types {
+		if !templates.IsChunk(t) {
+			continue
+		}
+		var body bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&body, "object", t); err != nil {
+			return err
+		}
+		m := chunkClassRe.FindStringSubmatch(body.String())
+		if m == nil {
+			return fmt.Errorf("no class in the chunk of %s", t.Name)
+		}
+		classes[m[1]] = templates.ChunkFileName(g.Config, t.Name)
+		bodies[t.Name] = body.String()
+		chunkTypes = append(chunkTypes, t)
+	}
+
+	clientImport := "../" + strings.TrimSuffix(templates.GeneratedFileName(g.Config, ClientGenFile), ".ts") + ".js"
+	for _, t := range chunkTypes {
+		body := bodies[t.Name]
+		name := chunkClassRe.FindStringSubmatch(body)[1]
+		file := classes[name]
+
+		values := append([]string{}, chunkValues...)
+		var typeNames []string
+		var classImports []chunkImport
+		// only the identifiers of the code need imports, not the names in its
+		// comments, strings or members
+		code := commentRe.ReplaceAllString(body, "")
+		code = memberRe.ReplaceAllString(stringRe.ReplaceAllString(code, ""), "")
+		for _, id := range dedup(identifierRe.FindAllString(code, -1)) {
+			if id == name {
+				continue
+			}
+			if other, ok := classes[id]; ok {
+				rel := "./" + strings.TrimSuffix(path.Base(other), ".ts") + ".js"
+				classImports = append(classImports, chunkImport{Name: id, Import: rel})
+				continue
+			}
+			isValue, ok := exports[id]
+			switch {
+			case !ok || id == "BaseClient":
+			case isValue:
+				values = append(values, id)
+			default:
+				typeNames = append(typeNames, id)
+			}
+		}
+		sort.Strings(values)
+		sort.Strings(typeNames)
+		sort.Slice(classImports, func(i, j int) bool { return classImports[i].Name < classImports[j].Name })
+
+		var chunk bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&chunk, "chunk", struct {
+			Name         string
+			Body         string
+			ClientImport string
+			Values       string
+			Types        string
+			Classes      []chunkImport
+		}{
+			Name:         name,
+			Body:         body,
+			ClientImport: clientImport,
+			Values:       strings.Join(values, ", "),
+			Types:        strings.Join(typeNames, ", "),
+			Classes:      classImports,
+		}); err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(file))
+		if err := mfs.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("failed to create target directory %s: %w", filepath.Dir(target), err)
+		}
+		if err := mfs.WriteFile(target, chunk.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write chunk file at %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+func dedup(ids []string) []string {
+	seen := map[string]struct{}{}
+	out := ids[:0]
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
@@ -3,10 +3,14 @@ package typescriptgenerator
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"sort"
 
+	"github.com/iancoleman/strcase"
 	"github.com/psanford/memfs"
 
 	"github.com/dagger/dagger/cmd/codegen/generator"
@@ -14,14 +18,53 @@ import (
 	"github.com/dagger/dagger/cmd/codegen/introspection"
 )
 
-const ClientGenFile = "client.gen.ts"
+const (
+	ClientGenFile = "client.gen.ts"
+
+	// MockGenFile is the path to write the mocks of the client, next to ClientGenFile
+	MockGenFile = "client.gen.mock.ts"
+
+	// DeclarationGenFile is the path to write the declarations of the client,
+	// instead of ClientGenFile
+	DeclarationGenFile = "client.gen.d.ts"
+
+	// SchemaGenFile is the path to write the introspection of the schema, next
+	// to ClientGenFile, with Config.EmbedSchema
+	SchemaGenFile = "schema.gen.ts"
+
+	// StarterTestFile is the path to write the test of the default module
+	// code, relative to the module source, with Config.ScaffoldTests
+	StarterTestFile = "src/index.test.ts"
+)
 
 type TypeScriptGenerator struct {
 	Config generator.Config
 }
 
+func init() {
+	generator.Register(generator.SDKLangTypeScript, func(cfg generator.Config) generator.Generator {
+		return &TypeScriptGenerator{Config: cfg}
+	})
+}
+
 // Generate will generate the TypeScript SDK code and might modify the schema to reorder types in a alphanumeric fashion.
 func (g *TypeScriptGenerator) GenerateModule(_ context.Context, schema *introspection.Schema, schemaVersion string) (*generator.GeneratedState, error) {
+	return g.generate(schema, schemaVersion, "api")
+}
+
+func (g *TypeScriptGenerator) GenerateClient(_ context.Context, schema *introspection.Schema, schemaVersion string) (*generator.GeneratedState, error) {
+	// This is the same as the module generator for TypeScript, unless only
+	// the types or the declarations are requested
+	if g.Config.TypesOnly {
+		return g.generate(schema, schemaVersion, "types_only")
+	}
+	if g.Config.TypeScriptDeclarationOnly {
+		return g.generate(schema, schemaVersion, "declarations")
+	}
+	return g.generate(schema, schemaVersion, "api")
+}
+
+func (g *TypeScriptGenerator) generate(schema *introspection.Schema, schemaVersion string, topLevelTemplate string) (*generator.GeneratedState, error) {
 	generator.SetSchema(schema)
 
 	sort.SliceStable(schema.Types, func(i, j int) bool {
@@ -42,6 +85,12 @@ func (g *TypeScriptGenerator) GenerateModule(_ context.Context, schema *introspe
 				return in < jn
 			}
 		})
+		sort.SliceStable(v.Interfaces, func(i, j int) bool {
+			return v.Interfaces[i].Name < v.Interfaces[j].Name
+		})
+		sort.SliceStable(v.PossibleTypes, func(i, j int) bool {
+			return v.PossibleTypes[i].Name < v.PossibleTypes[j].Name
+		})
 	}
 
 	tmpl := templates.New(schemaVersion, g.Config)
@@ -55,16 +104,26 @@ func (g *TypeScriptGenerator) GenerateModule(_ context.Context, schema *introspe
 		Types:         schema.Types,
 	}
 	var b bytes.Buffer
-	err := tmpl.ExecuteTemplate(&b, "api", data)
+	err := tmpl.ExecuteTemplate(&b, topLevelTemplate, data)
 	if err != nil {
 		return nil, err
 	}
 
 	mfs := memfs.New()
 
-	target := ClientGenFile
+	genFile := ClientGenFile
+	if topLevelTemplate == "declarations" {
+		genFile = DeclarationGenFile
+	}
+
+	target := templates.GeneratedFileName(g.Config, genFile)
+	var layout generator.Layout
 	if g.Config.ModuleName != "" {
-		target = filepath.Join(g.Config.ModuleSourcePath, "sdk/src/api", ClientGenFile)
+		layout, err = g.ModuleLayout(g.Config)
+		if err != nil {
+			return nil, err
+		}
+		target = filepath.Join(layout.GeneratedDir, genFile)
 	}
 
 	if err := mfs.MkdirAll(filepath.Dir(target), 0700); err != nil {
@@ -74,12 +133,84 @@ func (g *TypeScriptGenerator) GenerateModule(_ context.Context, schema *introspe
 		return nil, fmt.Errorf("failed to write client file at %s: %w", target, err)
 	}
 
+	if g.Config.TypeScriptCodeSplit && topLevelTemplate == "api" {
+		if err := g.generateChunks(mfs, tmpl, schema.Types, filepath.Dir(target), b.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.Config.GenerateMocks && topLevelTemplate == "api" {
+		var mock bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&mock, "mock", data); err != nil {
+			return nil, err
+		}
+
+		mockTarget := filepath.Join(filepath.Dir(target), templates.GeneratedFileName(g.Config, MockGenFile))
+		if err := mfs.WriteFile(mockTarget, mock.Bytes(), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write mock file at %s: %w", mockTarget, err)
+		}
+	}
+
+	if g.Config.EmbedSchema {
+		var schemaFile bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&schemaFile, "schema", data); err != nil {
+			return nil, err
+		}
+
+		schemaTarget := filepath.Join(filepath.Dir(target), templates.GeneratedFileName(g.Config, SchemaGenFile))
+		if err := mfs.WriteFile(schemaTarget, schemaFile.Bytes(), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write schema file at %s: %w", schemaTarget, err)
+		}
+	}
+
+	if g.Config.ModuleName != "" && g.Config.IsInit && g.Config.ScaffoldTests && topLevelTemplate == "api" {
+		testTarget := filepath.Join(layout.SourceDir, StarterTestFile)
+		if _, err := os.Stat(filepath.Join(g.Config.OutputDir, testTarget)); errors.Is(err, fs.ErrNotExist) {
+			if err := mfs.MkdirAll(filepath.Dir(testTarget), 0700); err != nil {
+				return nil, fmt.Errorf("failed to create target directory %s: %w", filepath.Dir(testTarget), err)
+			}
+			if err := mfs.WriteFile(testTarget, []byte(g.baseModuleTest()), 0600); err != nil {
+				return nil, fmt.Errorf("failed to write test file at %s: %w", testTarget, err)
+			}
+		}
+	}
+
 	return &generator.GeneratedState{
 		Overlay: mfs,
 	}, nil
 }
 
-func (g *TypeScriptGenerator) GenerateClient(ctx context.Context, schema *introspection.Schema, schemaVersion string) (*generator.GeneratedState, error) {
-	// This is the same as the module generator for TypeScript
-	return g.GenerateModule(ctx, schema, schemaVersion)
+// ModuleLayout returns the layout of a TypeScript module: the generated
+// client is in the bundled SDK of the module source, next to the src
+// directory of the module code.
+func (g *TypeScriptGenerator) ModuleLayout(cfg generator.Config) (generator.Layout, error) {
+	dir, err := generator.ModuleSourceDir(cfg)
+	if err != nil {
+		return generator.Layout{}, err
+	}
+	return generator.Layout{
+		SourceDir:    dir,
+		GeneratedDir: filepath.Join(dir, "sdk", "src", "api"),
+		Entrypoint:   filepath.Join(dir, "src", "index.ts"),
+	}, nil
+}
+
+func (g *TypeScriptGenerator) baseModuleTest() string {
+	return fmt.Sprintf(`/**
+ * The tests of the module call the Dagger API, so they must run in a Dagger
+ * session, e.g. with:
+ *
+ *   dagger run npx tsx --test src/index.test.ts
+ */
+import assert from "node:assert"
+import { test } from "node:test"
+
+import { Container } from "@dagger.io/dagger"
+import { %[1]s } from "./index"
+
+test("containerEcho returns a container", () => {
+  const ctr = new %[1]s().containerEcho("hello")
+  assert.ok(ctr instanceof Container)
+})
+`, strcase.ToCamel(g.Config.ModuleName))
 }
@@ -0,0 +1,591 @@
+package typescriptgenerator
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestGenerateDeclarationOnly(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:    "version",
+						TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}},
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	g := &TypeScriptGenerator{Config: generator.Config{ClientOnly: true, TypeScriptDeclarationOnly: true}}
+	generated, err := g.GenerateClient(context.Background(), schema, "")
+	require.NoError(t, err)
+
+	_, err = fs.Stat(generated.Overlay, ClientGenFile)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	dt, err := fs.ReadFile(generated.Overlay, DeclarationGenFile)
+	require.NoError(t, err)
+	require.Contains(t, string(dt), "export declare class Client extends BaseClient {")
+	require.Contains(t, string(dt), "  version: () => Promise<string>\n")
+	require.NotContains(t, string(dt), "=> {")
+}
+
+func TestGenerateStableOutput(t *testing.T) {
+	load := func() *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "SCALAR", "name": "String"},
+					{"kind": "OBJECT", "name": "Query", "fields": [
+						{"name": "version", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+						{"name": "pet", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "INTERFACE", "name": "Pet"}}}
+					]},
+					{"kind": "INTERFACE", "name": "Pet", "fields": [
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "possibleTypes": [{"kind": "OBJECT", "name": "Cat"}, {"kind": "OBJECT", "name": "Dog"}]},
+					{"kind": "OBJECT", "name": "Cat", "fields": [
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "interfaces": [{"kind": "INTERFACE", "name": "Pet"}]},
+					{"kind": "OBJECT", "name": "Dog", "fields": [
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "interfaces": [{"kind": "INTERFACE", "name": "Pet"}]},
+					{"kind": "ENUM", "name": "Size", "enumValues": [{"name": "SMALL"}, {"name": "LARGE"}]}
+				]
+			}
+		}`), &resp))
+		generator.SetSchemaParents(resp.Schema)
+		return resp.Schema
+	}
+	generate := func(schema *introspection.Schema) string {
+		g := &TypeScriptGenerator{Config: generator.Config{ClientOnly: true}}
+		generated, err := g.GenerateClient(context.Background(), schema, "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, ClientGenFile)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	want := generate(load())
+
+	// the same schema listed in another order generates the same code
+	schema := load()
+	slices.Reverse(schema.Types)
+	for _, t := range schema.Types {
+		slices.Reverse(t.Fields)
+		slices.Reverse(t.EnumValues)
+		slices.Reverse(t.PossibleTypes)
+	}
+	require.Equal(t, want, generate(schema))
+}
+
+func TestGenerateKeywords(t *testing.T) {
+	dt, err := os.ReadFile("testdata/keywords.json")
+	require.NoError(t, err)
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	g := &TypeScriptGenerator{Config: generator.Config{ClientOnly: true}}
+	generated, err := g.GenerateClient(context.Background(), resp.Schema, "")
+	require.NoError(t, err)
+	dt, err = fs.ReadFile(generated.Overlay, ClientGenFile)
+	require.NoError(t, err)
+	src := string(dt)
+
+	// the identifiers are aliased, the queries keep the GraphQL names
+	require.Contains(t, src, "type_ = async (): Promise<string> => {")
+	require.Contains(t, src, "select = (case_: string, opts?: ItemSelectOpts): Item => {")
+	require.Contains(t, src, "case:case_,")
+	require.Contains(t, src, "import_ = async (package_: string,")
+	require.Contains(t, src, "package:package_,")
+	require.Contains(t, src, "\"type\",\n")
+}
+
+func TestGenerateRecursiveInputs(t *testing.T) {
+	dt, err := os.ReadFile("testdata/recursive.json")
+	require.NoError(t, err)
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	g := &TypeScriptGenerator{Config: generator.Config{ClientOnly: true}}
+	generated, err := g.GenerateClient(context.Background(), resp.Schema, "")
+	require.NoError(t, err)
+	dt, err = fs.ReadFile(generated.Overlay, ClientGenFile)
+	require.NoError(t, err)
+	src := string(dt)
+
+	// the types of TypeScript can reference themselves
+	require.Contains(t, src, "parent?: TreeInput")
+	require.Contains(t, src, "children?: TreeInput[]")
+	require.Contains(t, src, "next?: Odd")
+	require.Contains(t, src, "next?: Even")
+}
+
+func TestGenerateCodeSplit(t *testing.T) {
+	load := func() *introspection.Schema {
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "SCALAR", "name": "String"},
+					{"kind": "OBJECT", "name": "Query", "fields": [
+						{"name": "cat", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Cat"}}},
+						{"name": "pet", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "INTERFACE", "name": "Pet"}}}
+					]},
+					{"kind": "INTERFACE", "name": "Pet", "fields": [
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "possibleTypes": [{"kind": "OBJECT", "name": "Cat"}, {"kind": "OBJECT", "name": "Dog"}]},
+					{"kind": "OBJECT", "name": "Cat", "fields": [
+						{"name": "friend", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Dog"}}},
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "interfaces": [{"kind": "INTERFACE", "name": "Pet"}]},
+					{"kind": "OBJECT", "name": "Dog", "fields": [
+						{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+					], "interfaces": [{"kind": "INTERFACE", "name": "Pet"}]}
+				]
+			}
+		}`), &resp))
+		generator.SetSchemaParents(resp.Schema)
+		return resp.Schema
+	}
+
+	g := &TypeScriptGenerator{Config: generator.Config{ClientOnly: true, TypeScriptCodeSplit: true}}
+	generated, err := g.GenerateClient(context.Background(), load(), "")
+	require.NoError(t, err)
+	read := func(name string) string {
+		dt, err := fs.ReadFile(generated.Overlay, name)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	// the client only imports the types of the classes, and their chunks
+	// lazily
+	client := read(ClientGenFile)
+	require.NotContains(t, client, "export class Cat ")
+	require.Contains(t, client, "export class Client extends BaseClient {")
+	require.Contains(t, client, `import type { Cat } from "./chunks/cat.gen.js"`)
+	require.Contains(t, client, `  Cat: () => import("./chunks/cat.gen.js"),`)
+	require.Contains(t, client, `  Dog: () => import("./chunks/dog.gen.js"),`)
+	require.Contains(t, client, `  Pet: () => import("./chunks/pet.gen.js"),`)
+	require.Contains(t, client, `return newObject<Cat>("Cat", ctx)`)
+	require.NotRegexp(t, `import \{[^}]*\} from "\./chunks/`, client)
+
+	// each chunk registers its class, importing the client and the types of
+	// the classes of the other chunks
+	cat := read("chunks/cat.gen.ts")
+	require.Contains(t, cat, `import { BaseClient, newObject, registerClass } from "../client.gen.js"`)
+	require.Contains(t, cat, `import type { Context } from "../client.gen.js"`)
+	require.Contains(t, cat, `import type { Dog } from "./dog.gen.js"`)
+	require.Contains(t, cat, "export class Cat extends BaseClient {")
+	require.Contains(t, cat, `return newObject<Dog>("Dog", ctx)`)
+	require.Contains(t, cat, `registerClass("Cat", Cat)`)
+	require.NotContains(t, cat, "export class Dog")
+
+	pet := read("chunks/pet.gen.ts")
+	require.Contains(t, pet, `import type { Cat } from "./cat.gen.js"`)
+	require.Contains(t, pet, `import type { Dog } from "./dog.gen.js"`)
+	require.Contains(t, pet, `return newObject<Cat>("Cat", this._ctx)`)
+	require.Contains(t, pet, `registerClass("Pet", Pet)`)
+	for _, chunk := range []string{cat, pet, read("chunks/dog.gen.ts")} {
+		require.NotRegexp(t, `import \{[^}]*\} from "\./`, chunk)
+	}
+
+	t.Run("file suffix", func(t *testing.T) {
+		g := &TypeScriptGenerator{Config: generator.Config{
+			ClientOnly:          true,
+			TypeScriptCodeSplit: true,
+			GeneratedFileSuffix: ".generated",
+		}}
+		generated, err := g.GenerateClient(context.Background(), load(), "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, "client.generated.ts")
+		require.NoError(t, err)
+		require.Contains(t, string(dt), `  Cat: () => import("./chunks/cat.generated.js"),`)
+		dt, err = fs.ReadFile(generated.Overlay, "chunks/cat.generated.ts")
+		require.NoError(t, err)
+		require.Contains(t, string(dt), `from "../client.generated.js"`)
+		require.Contains(t, string(dt), `import type { Dog } from "./dog.generated.js"`)
+	})
+}
+
+func TestGenerateFileSuffix(t *testing.T) {
+	dt, err := os.ReadFile("testdata/keywords.json")
+	require.NoError(t, err)
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	t.Run("client", func(t *testing.T) {
+		g := &TypeScriptGenerator{Config: generator.Config{
+			ClientOnly:          true,
+			GenerateMocks:       true,
+			GeneratedFileSuffix: ".generated",
+		}}
+		generated, err := g.GenerateClient(context.Background(), resp.Schema, "")
+		require.NoError(t, err)
+		_, err = fs.Stat(generated.Overlay, "client.generated.ts")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, "client.generated.mock.ts")
+		require.NoError(t, err)
+		require.Contains(t, string(dt), `import type * as api from "./client.generated.js"`)
+	})
+
+	t.Run("module", func(t *testing.T) {
+		g := &TypeScriptGenerator{Config: generator.Config{
+			ModuleName:          "test",
+			ModuleSourcePath:    "mod",
+			OutputDir:           t.TempDir(),
+			GeneratedFileSuffix: ".generated",
+		}}
+		generated, err := g.GenerateModule(context.Background(), resp.Schema, "")
+		require.NoError(t, err)
+		_, err = fs.Stat(generated.Overlay, "mod/sdk/src/api/"+ClientGenFile)
+		require.NoError(t, err)
+	})
+}
+
+func TestGeneratePing(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:    "version",
+						TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}},
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	generate := func(cfg generator.Config, name string) string {
+		cfg.ClientOnly = true
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), schema, "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, name)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	src := generate(generator.Config{GeneratePing: true}, ClientGenFile)
+	require.Contains(t, src, "ping = async (): Promise<void> => {")
+	require.Contains(t, src, `await this._ctx.select("__typename").execute()`)
+
+	require.Contains(t, generate(generator.Config{GeneratePing: true, TypeScriptDeclarationOnly: true}, DeclarationGenFile), "ping(): Promise<void>")
+
+	require.NotContains(t, generate(generator.Config{}, ClientGenFile), "ping")
+}
+
+func TestGenerateOperationNames(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:    "version",
+						TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}},
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	generate := func(cfg generator.Config) string {
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), schema, "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, ClientGenFile)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	src := generate(generator.Config{ClientOnly: true, GenerateOperationNames: true})
+	require.Contains(t, src, `import { AsyncLocalStorage } from "node:async_hooks"`)
+	require.Contains(t, src, `await executeOperation("QueryVersion", ctx)`)
+	require.Contains(t, src, "document = `query ${name} ${String(document).trim()}`")
+	require.Contains(t, src, "nameOperations(client)")
+	require.Contains(t, src, "nameOperations(dag)")
+
+	require.NotContains(t, generate(generator.Config{ClientOnly: true}), "executeOperation")
+}
+
+func TestGenerateRawQuery(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:    "version",
+						TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}},
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	generate := func(cfg generator.Config, name string) string {
+		cfg.ClientOnly = true
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), schema, "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, name)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	src := generate(generator.Config{GenerateRawQuery: true}, ClientGenFile)
+	require.Contains(t, src, "raw = async <T = unknown>(")
+	require.Contains(t, src, "return this._ctx.getGQLClient().request<T>(query, vars)")
+
+	require.Contains(t, generate(generator.Config{GenerateRawQuery: true, TypeScriptDeclarationOnly: true}, DeclarationGenFile), "raw<T = unknown>(query: string, vars?: Record<string, unknown>): Promise<T>")
+
+	require.NotContains(t, generate(generator.Config{}, ClientGenFile), "raw =")
+}
+
+func TestGenerateModuleScaffoldTests(t *testing.T) {
+	dt, err := os.ReadFile("testdata/keywords.json")
+	require.NoError(t, err)
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	generate := func(dir string, scaffoldTests bool) fs.FS {
+		g := &TypeScriptGenerator{Config: generator.Config{
+			ModuleName:       "my-module",
+			ModuleSourcePath: "mod",
+			OutputDir:        dir,
+			IsInit:           true,
+			ScaffoldTests:    scaffoldTests,
+		}}
+		generated, err := g.GenerateModule(context.Background(), resp.Schema, "")
+		require.NoError(t, err)
+		return generated.Overlay
+	}
+
+	t.Run("scaffold", func(t *testing.T) {
+		dt, err := fs.ReadFile(generate(t.TempDir(), true), "mod/"+StarterTestFile)
+		require.NoError(t, err)
+		require.Contains(t, string(dt), `import { MyModule } from "./index"`)
+		require.Contains(t, string(dt), `new MyModule().containerEcho("hello")`)
+	})
+
+	t.Run("existing test", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "mod", "src"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "mod", StarterTestFile), []byte("// my tests\n"), 0o600))
+		_, err := fs.Stat(generate(dir, true), "mod/"+StarterTestFile)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("no scaffold", func(t *testing.T) {
+		_, err := fs.Stat(generate(t.TempDir(), false), "mod/"+StarterTestFile)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestModuleLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		source string
+		want   generator.Layout
+	}{
+		{
+			name:   "root",
+			source: ".",
+			want:   generator.Layout{SourceDir: ".", GeneratedDir: "sdk/src/api", Entrypoint: "src/index.ts"},
+		},
+		{
+			name:   "subdirectory",
+			source: "./ci/dagger/",
+			want:   generator.Layout{SourceDir: "ci/dagger", GeneratedDir: "ci/dagger/sdk/src/api", Entrypoint: "ci/dagger/src/index.ts"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := generator.Config{Lang: generator.SDKLangTypeScript, ModuleName: "my-module", ModuleSourcePath: tc.source}
+			layout, err := (&TypeScriptGenerator{}).ModuleLayout(cfg)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, layout)
+
+			layout, err = generator.ResolveModuleLayout(cfg)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, layout)
+		})
+	}
+
+	t.Run("generated client", func(t *testing.T) {
+		dt, err := os.ReadFile("testdata/keywords.json")
+		require.NoError(t, err)
+		var resp introspection.Response
+		require.NoError(t, json.Unmarshal(dt, &resp))
+		generator.SetSchemaParents(resp.Schema)
+
+		g := &TypeScriptGenerator{Config: generator.Config{ModuleName: "my-module", ModuleSourcePath: "ci/dagger", OutputDir: t.TempDir()}}
+		generated, err := g.GenerateModule(context.Background(), resp.Schema, "")
+		require.NoError(t, err)
+		_, err = fs.Stat(generated.Overlay, "ci/dagger/sdk/src/api/"+ClientGenFile)
+		require.NoError(t, err)
+	})
+}
+
+func TestGenerateSchemaVersion(t *testing.T) {
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:    "version",
+						TypeRef: &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}},
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	generate := func(cfg generator.Config) fs.FS {
+		cfg.ClientOnly = true
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), schema, "v0.1.0")
+		require.NoError(t, err)
+		return generated.Overlay
+	}
+
+	overlay := generate(generator.Config{})
+	dt, err := fs.ReadFile(overlay, ClientGenFile)
+	require.NoError(t, err)
+	src := string(dt)
+	require.Contains(t, src, `export const schemaVersion = "v0.1.0"`+"\n")
+	require.Contains(t, src, "export function version(): string {\n  return schemaVersion\n}")
+
+	// it's deterministic, so that the overlay doesn't rewrite it
+	equal, paths, err := generator.OverlaysEqual(overlay, generate(generator.Config{}))
+	require.NoError(t, err)
+	require.True(t, equal, paths)
+
+	dt, err = fs.ReadFile(generate(generator.Config{TypeScriptDeclarationOnly: true}), DeclarationGenFile)
+	require.NoError(t, err)
+	require.Contains(t, string(dt), `export declare const schemaVersion: "v0.1.0"`+"\n")
+	require.Contains(t, string(dt), "export declare function version(): string\n")
+}
+
+func TestGenerateEmbedSchema(t *testing.T) {
+	dt, err := os.ReadFile("testdata/keywords.json")
+	require.NoError(t, err)
+	var resp introspection.Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	generator.SetSchemaParents(resp.Schema)
+
+	generate := func(cfg generator.Config) fs.FS {
+		cfg.ClientOnly = true
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), resp.Schema, "v0.1.0")
+		require.NoError(t, err)
+		return generated.Overlay
+	}
+
+	dt, err = fs.ReadFile(generate(generator.Config{EmbedSchema: true}), SchemaGenFile)
+	require.NoError(t, err)
+	src := string(dt)
+	require.Contains(t, src, `export const schemaVersion = "v0.1.0"`+"\n")
+
+	// the embedded json is the introspection of the schema
+	_, lit, ok := strings.Cut(src, "export const introspectionJSON = ")
+	require.True(t, ok)
+	var embedded string
+	require.NoError(t, json.Unmarshal([]byte(lit), &embedded))
+	var embeddedResp introspection.Response
+	require.NoError(t, json.Unmarshal([]byte(embedded), &embeddedResp))
+	require.Equal(t, "v0.1.0", embeddedResp.SchemaVersion)
+	require.Len(t, embeddedResp.Schema.Types, len(resp.Schema.Types))
+
+	_, err = fs.Stat(generate(generator.Config{}), SchemaGenFile)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestGenerateAnnotateNullability(t *testing.T) {
+	str := &introspection.TypeRef{Kind: introspection.TypeKindScalar, Name: "String"}
+	schema := &introspection.Schema{
+		Types: introspection.Types{
+			{Kind: introspection.TypeKindScalar, Name: "String"},
+			{
+				Kind: introspection.TypeKindObject,
+				Name: "Query",
+				Fields: []*introspection.Field{
+					{
+						Name:        "version",
+						Description: "The version of the engine.",
+						TypeRef:     &introspection.TypeRef{Kind: introspection.TypeKindNonNull, OfType: str},
+					},
+					{
+						Name:    "label",
+						TypeRef: str,
+					},
+				},
+			},
+		},
+	}
+	generator.SetSchemaParents(schema)
+
+	generate := func(cfg generator.Config) string {
+		cfg.ClientOnly = true
+		g := &TypeScriptGenerator{Config: cfg}
+		generated, err := g.GenerateClient(context.Background(), schema, "")
+		require.NoError(t, err)
+		dt, err := fs.ReadFile(generated.Overlay, ClientGenFile)
+		require.NoError(t, err)
+		return string(dt)
+	}
+
+	src := generate(generator.Config{AnnotateNullability: true})
+	require.Contains(t, src, "  /**\n"+
+		"   * The version of the engine.\n"+
+		"   * (non-null)\n"+
+		"   */\n"+
+		"  version = async")
+	require.Contains(t, src, "  /**\n"+
+		"   * (nullable)\n"+
+		"   */\n"+
+		"  label = async")
+
+	src = generate(generator.Config{})
+	require.NotContains(t, src, "(non-null)")
+	require.NotContains(t, src, "(nullable)")
+}
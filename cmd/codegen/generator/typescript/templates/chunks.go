@@ -0,0 +1,49 @@
+package templates
+
+import (
+	"path"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+// ChunksDir is the directory of the chunks of the client, next to the client,
+// with Config.TypeScriptCodeSplit.
+const ChunksDir = "chunks"
+
+// IsChunk returns whether the class of a type is generated in a chunk of its
+// own with Config.TypeScriptCodeSplit: every object but the query, which stays
+// in the client with the types of the schema.
+func IsChunk(t *introspection.Type) bool {
+	return len(t.Fields) > 0 && !strings.HasPrefix(t.Name, "_") && t.Name != generator.QueryStructName
+}
+
+// ChunkFileName returns the path of the chunk of a type, relative to the
+// client, e.g. `chunks/git-repository.gen.ts` for GitRepository.
+func ChunkFileName(cfg generator.Config, name string) string {
+	return path.Join(ChunksDir, GeneratedFileName(cfg, strcase.ToKebab(name)+".gen.ts"))
+}
+
+func (funcs typescriptTemplateFuncs) codeSplit() bool {
+	return funcs.cfg.TypeScriptCodeSplit
+}
+
+// chunkImport returns the import path of the chunk of a type from the client,
+// e.g. `./chunks/container.gen.js`.
+func (funcs typescriptTemplateFuncs) chunkImport(name string) string {
+	return "./" + strings.TrimSuffix(ChunkFileName(funcs.cfg, name), ".ts") + ".js"
+}
+
+// newObject returns the expression creating an object of a class from a
+// context. With Config.TypeScriptCodeSplit, the classes of the chunks aren't
+// imported by the client, so they are created from their registered class.
+// Example: `new Container(ctx)` -> `newObject<Container>("Container", ctx)`
+func (funcs typescriptTemplateFuncs) newObject(name string, ctx string) string {
+	if !funcs.cfg.TypeScriptCodeSplit || name == generator.QueryStructClientName {
+		return "new " + name + "(" + ctx + ")"
+	}
+	return "newObject<" + name + `>("` + name + `", ` + ctx + ")"
+}
@@ -68,6 +68,11 @@ func (f *FormatTypeFunc) FormatKindObject(representation string, refName string,
 	return representation
 }
 
+func (f *FormatTypeFunc) FormatKindInterface(representation string, refName string, input bool) string {
+	representation += f.scope + f.formatNameFunc(refName)
+	return representation
+}
+
 func (f *FormatTypeFunc) FormatKindInputObject(representation string, refName string, input bool) string {
 	representation += f.scope + f.formatNameFunc(refName)
 	return representation
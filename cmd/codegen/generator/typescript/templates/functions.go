@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"encoding/json"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -36,6 +37,7 @@ func (funcs typescriptTemplateFuncs) FuncMap() template.FuncMap {
 		"CommentToLines":            funcs.commentToLines,
 		"FormatDeprecation":         funcs.formatDeprecation,
 		"FormatExperimental":        funcs.formatExperimental,
+		"FormatNullability":         funcs.formatNullability,
 		"FormatReturnType":          commonFunc.FormatReturnType,
 		"FormatInputType":           commonFunc.FormatInputType,
 		"FormatOutputType":          commonFunc.FormatOutputType,
@@ -44,6 +46,7 @@ func (funcs typescriptTemplateFuncs) FuncMap() template.FuncMap {
 		"QueryToClient":             funcs.queryToClient,
 		"GetOptionalArgs":           funcs.getOptionalArgs,
 		"GetRequiredArgs":           funcs.getRequiredArgs,
+		"GetValidatedArgs":          funcs.getValidatedArgs,
 		"HasPrefix":                 strings.HasPrefix,
 		"PascalCase":                funcs.pascalCase,
 		"IsArgOptional":             funcs.isArgOptional,
@@ -67,12 +70,57 @@ func (funcs typescriptTemplateFuncs) FuncMap() template.FuncMap {
 		"ModuleRelPath":             funcs.moduleRelPath,
 		"FormatProtected":           funcs.formatProtected,
 		"IsClientOnly":              funcs.isClientOnly,
+		"SchemaVersion":             funcs.getSchemaVersion,
 		"Dependencies":              funcs.Dependencies,
 		"HasLocalDependencies":      funcs.HasLocalDependencies,
+		"ServeDependencies":         funcs.ServeDependencies,
+		"GenerateRequestHooks":      funcs.generateRequestHooks,
+		"GenerateOperationNames":    funcs.generateOperationNames,
+		"OperationName":             funcs.operationName,
+		"GeneratePing":              funcs.generatePing,
+		"GenerateRawQuery":          funcs.generateRawQuery,
 		"IsBundle":                  funcs.isBundle,
+		"IsDeclarationOnly":         funcs.isDeclarationOnly,
+		"ClientGenImport":           funcs.clientGenImport,
+		"IntrospectionJSON":         funcs.introspectionJSON,
+		"CodeSplit":                 funcs.codeSplit,
+		"IsChunk":                   IsChunk,
+		"ChunkImport":               funcs.chunkImport,
+		"NewObject":                 funcs.newObject,
 	}
 }
 
+// GeneratedFileName returns the name of a generated file with the
+// GeneratedFileSuffix of the config, see generator.Config.GeneratedFileName.
+// The files of a module keep their default names, which the SDK library of the
+// module imports.
+func GeneratedFileName(cfg generator.Config, name string) string {
+	if cfg.ModuleName != "" {
+		return name
+	}
+	return cfg.GeneratedFileName(name)
+}
+
+// clientGenImport returns the import path of the generated client from the
+// files next to it, e.g. `./client.gen.js`.
+func (funcs typescriptTemplateFuncs) clientGenImport() string {
+	return "./" + strings.TrimSuffix(GeneratedFileName(funcs.cfg, "client.gen.ts"), ".ts") + ".js"
+}
+
+// introspectionJSON returns the introspection of the schema as a TypeScript
+// string literal.
+func (funcs typescriptTemplateFuncs) introspectionJSON(schema *introspection.Schema, schemaVersion string) (string, error) {
+	dt, err := generator.IntrospectionJSON(schema, schemaVersion)
+	if err != nil {
+		return "", err
+	}
+	lit, err := json.Marshal(string(dt))
+	if err != nil {
+		return "", err
+	}
+	return string(lit), nil
+}
+
 // pascalCase change a type name into pascalCase
 func (funcs typescriptTemplateFuncs) pascalCase(name string) string {
 	return strcase.ToCamel(name)
@@ -98,7 +146,21 @@ func (funcs typescriptTemplateFuncs) commentToLines(s string) []string {
 		return []string{}
 	}
 
-	split := strings.Split(s, "\n")
+	// the links are listed as @see tags, the markdown links being replaced by
+	// their text
+	links := generator.DocLinks(s)
+	s, _ = generator.ReplaceMarkdownLinks(s, func(link generator.DocLink) string {
+		return link.Text
+	})
+
+	split := strings.Split(generator.WrapText(s, funcs.cfg.DocCommentWrap-len(" * ")), "\n")
+	for _, link := range links {
+		if link.Text == "" {
+			split = append(split, "@see "+link.URL)
+		} else {
+			split = append(split, "@see {@link "+link.URL+" "+link.Text+"}")
+		}
+	}
 	return split
 }
 
@@ -112,6 +174,12 @@ func (funcs typescriptTemplateFuncs) formatExperimental(_ string) []string {
 	return funcs.formatHelper("experimental", "")
 }
 
+// formatNullability returns the nullability annotation of a field, with
+// Config.AnnotateNullability.
+func (funcs typescriptTemplateFuncs) formatNullability(ref *introspection.TypeRef) string {
+	return generator.NullabilityAnnotation(funcs.cfg, ref)
+}
+
 func (funcs typescriptTemplateFuncs) formatHelper(name string, s string) []string {
 	r := regexp.MustCompile("`[a-zA-Z0-9_]+`")
 	matches := r.FindAllString(s, -1)
@@ -285,6 +353,22 @@ func (funcs typescriptTemplateFuncs) getRequiredArgs(values introspection.InputV
 	return required
 }
 
+// getValidatedArgs returns the required arguments that must not be empty,
+// when argument validation is enabled.
+func (funcs typescriptTemplateFuncs) getValidatedArgs(values introspection.InputValues) introspection.InputValues {
+	if !funcs.cfg.GenerateArgValidation {
+		return nil
+	}
+
+	var validated introspection.InputValues
+	for _, v := range funcs.getRequiredArgs(values) {
+		if generator.IsStringArg(v) {
+			validated = append(validated, v)
+		}
+	}
+	return validated
+}
+
 func (funcs typescriptTemplateFuncs) getOptionalArgs(values introspection.InputValues) introspection.InputValues {
 	_, optional := funcs.splitRequiredOptionalArgs(values)
 	return optional
@@ -333,6 +417,12 @@ func (funcs typescriptTemplateFuncs) formatProtected(s string) string {
 	return strings.TrimSuffix(s, "_")
 }
 
+// getSchemaVersion returns the version of the schema the client is generated
+// from.
+func (funcs typescriptTemplateFuncs) getSchemaVersion() string {
+	return funcs.schemaVersion
+}
+
 func (funcs typescriptTemplateFuncs) isClientOnly() bool {
 	return funcs.cfg.ClientOnly
 }
@@ -351,6 +441,45 @@ func (funcs typescriptTemplateFuncs) HasLocalDependencies() bool {
 	return false
 }
 
+func (funcs typescriptTemplateFuncs) ServeDependencies() bool {
+	return !funcs.cfg.SkipServeDependencies
+}
+
+func (funcs typescriptTemplateFuncs) generateRequestHooks() bool {
+	return funcs.cfg.GenerateRequestHooks
+}
+
+// generateOperationNames returns true if the client should name the
+// operations of the requests of its methods, which only the connection
+// helpers of a client set up
+func (funcs typescriptTemplateFuncs) generateOperationNames() bool {
+	return funcs.cfg.GenerateOperationNames && funcs.cfg.ClientOnly
+}
+
+// operationName returns the name of the GraphQL operation of the request of a
+// field, with Config.GenerateOperationNames, or an empty string otherwise.
+func (funcs typescriptTemplateFuncs) operationName(f introspection.Field) string {
+	if !funcs.generateOperationNames() {
+		return ""
+	}
+	return generator.OperationName(f)
+}
+
+func (funcs typescriptTemplateFuncs) generatePing() bool {
+	return funcs.cfg.GeneratePing
+}
+
+func (funcs typescriptTemplateFuncs) generateRawQuery() bool {
+	return funcs.cfg.GenerateRawQuery
+}
+
 func (funcs typescriptTemplateFuncs) isBundle() bool {
 	return funcs.cfg.Bundle
 }
+
+// isDeclarationOnly returns true if only the declarations of the client are
+// generated, with Config.TypeScriptDeclarationOnly, so that the helpers are
+// declared without their implementation.
+func (funcs typescriptTemplateFuncs) isDeclarationOnly() bool {
+	return funcs.cfg.TypeScriptDeclarationOnly
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/typescript/templates"
+)
+
+func TestDeclarations(t *testing.T) {
+	tmpl := templates.New("", generator.Config{ClientOnly: true, TypeScriptDeclarationOnly: true})
+
+	objects := objectsInit(t, objectsJSON)
+	objects.Types = append(objects.Types, objectInit(t, `
+    {
+      "kind": "ENUM",
+      "name": "NetworkProtocol",
+      "description": "Transport layer network protocol associated to a port.",
+      "enumValues": [
+        {"name": "UDP"},
+        {"name": "TCP"}
+      ]
+    }
+    `))
+
+	var b bytes.Buffer
+	err := tmpl.ExecuteTemplate(&b, "declarations", objects)
+
+	want := updateAndGetFixtures(t, "testdata/declarations_test_want.d.ts", b.String())
+	require.NoError(t, err)
+	require.Equal(t, want, b.String())
+
+	// declarations have no implementation
+	require.NotContains(t, b.String(), "=> {")
+	require.NotContains(t, b.String(), "this.")
+	require.NotContains(t, b.String(), "await")
+	require.NotContains(t, b.String(), ") {")
+	require.Contains(t, b.String(), "export declare function isNetworkProtocol(value: unknown): value is NetworkProtocol\n")
+	require.Contains(t, b.String(), "export declare function parseNetworkProtocol(value: string): NetworkProtocol\n")
+}
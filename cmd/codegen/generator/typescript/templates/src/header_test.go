@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/typescript/templates"
+)
+
+func TestHeaderServeDependencies(t *testing.T) {
+	t.Run("serve by default", func(t *testing.T) {
+		tmpl := templates.New("", generator.Config{ClientOnly: true})
+
+		var b bytes.Buffer
+		require.NoError(t, tmpl.ExecuteTemplate(&b, "header", nil))
+		require.Contains(t, b.String(), "async function serveModuleDependencies(client: Client): Promise<void> {")
+		require.Contains(t, b.String(), "await serveModuleDependencies(client)")
+	})
+
+	t.Run("no serve", func(t *testing.T) {
+		tmpl := templates.New("", generator.Config{ClientOnly: true, SkipServeDependencies: true})
+
+		var b bytes.Buffer
+		require.NoError(t, tmpl.ExecuteTemplate(&b, "header", nil))
+		require.Contains(t, b.String(), "export async function connect(")
+		require.NotContains(t, b.String(), "serveModuleDependencies")
+	})
+}
+
+func TestHeaderRequestHooks(t *testing.T) {
+	t.Run("hooks", func(t *testing.T) {
+		tmpl := templates.New("", generator.Config{ClientOnly: true, GenerateRequestHooks: true})
+
+		var b bytes.Buffer
+		require.NoError(t, tmpl.ExecuteTemplate(&b, "header", nil))
+		require.Contains(t, b.String(), `import { EventEmitter } from "node:events"`)
+		require.Contains(t, b.String(), "export const requests = new EventEmitter()")
+		require.Contains(t, b.String(), "hookRequests(client)")
+		require.Contains(t, b.String(), "hookRequests(dag)")
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		tmpl := templates.New("", generator.Config{ClientOnly: true})
+
+		var b bytes.Buffer
+		require.NoError(t, tmpl.ExecuteTemplate(&b, "header", nil))
+		require.NotContains(t, b.String(), "EventEmitter")
+		require.NotContains(t, b.String(), "hookRequests")
+	})
+}
@@ -0,0 +1,21 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock(t *testing.T) {
+	tmpl := templateHelper(t)
+
+	objects := objectsInit(t, objectsJSON)
+
+	var b bytes.Buffer
+	err := tmpl.ExecuteTemplate(&b, "mock", objects)
+
+	want := updateAndGetFixtures(t, "testdata/mock_test_want.ts", b.String())
+	require.NoError(t, err)
+	require.Equal(t, want, b.String())
+}
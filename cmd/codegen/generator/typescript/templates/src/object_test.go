@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/typescript/templates"
 	"github.com/dagger/dagger/cmd/codegen/introspection"
 )
 
@@ -26,6 +27,38 @@ func TestObject(t *testing.T) {
 	require.Equal(t, want, b.String())
 }
 
+func TestObjectDocCommentWrap(t *testing.T) {
+	tmpl := templates.New("", generator.Config{DocCommentWrap: 40})
+
+	object := objectInit(t, containerExecArgsJSON)
+	object.Description = "An OCI-compatible container, also known as a Docker container, see `docker run --help`."
+
+	var b bytes.Buffer
+	require.NoError(t, tmpl.ExecuteTemplate(&b, "object", object))
+
+	require.Contains(t, b.String(), `/**
+ * An OCI-compatible container, also
+ * known as a Docker container, see
+ * `+"`docker run --help`"+`.
+ */`)
+}
+
+func TestObjectDocCommentLinks(t *testing.T) {
+	tmpl := templateHelper(t)
+
+	object := objectInit(t, containerExecArgsJSON)
+	object.Description = "An OCI-compatible container, see [the containers guide](https://docs.dagger.io/api/containers) and https://opencontainers.org."
+
+	var b bytes.Buffer
+	require.NoError(t, tmpl.ExecuteTemplate(&b, "object", object))
+
+	require.Contains(t, b.String(), `/**
+ * An OCI-compatible container, see the containers guide and https://opencontainers.org.
+ * @see {@link https://docs.dagger.io/api/containers the containers guide}
+ * @see https://opencontainers.org
+ */`)
+}
+
 func objectInit(t *testing.T, jsonString string) *introspection.Type {
 	t.Helper()
 	var object introspection.Type
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/generator/typescript/templates"
 )
 
 func TestObjects(t *testing.T) {
@@ -13,6 +16,7 @@ func TestObjects(t *testing.T) {
 		wantFilePath string
 	}{
 		"CacheVolume + Host": {objectsJSON, "testdata/objects_test_want.ts"},
+		"Pet interface":      {interfaceObjectsJSON, "testdata/objects_test_interface_want.ts"},
 	}
 
 	for name, c := range cases {
@@ -33,6 +37,22 @@ func TestObjects(t *testing.T) {
 	}
 }
 
+func TestObjectsArgValidation(t *testing.T) {
+	tmpl := templates.New("", generator.Config{GenerateArgValidation: true})
+
+	objects := objectsInit(t, objectsJSON)
+
+	var b bytes.Buffer
+	require.NoError(t, tmpl.ExecuteTemplate(&b, "objects", objects))
+
+	require.Contains(t, b.String(), `  directory = (path: string, opts?: HostDirectoryOpts): Directory => {
+    if (path === "") {
+      throw new Error(`+"`"+`Host.directory: required argument "path" is empty`+"`"+`)
+    }
+`)
+	require.Contains(t, b.String(), `throw new Error(`+"`"+`Host.envVariable: required argument "name" is empty`+"`"+`)`)
+}
+
 var objectsJSON = `
 [
         {
@@ -222,3 +242,45 @@ var objectsJSON = `
         }
 ]
 `
+
+var interfaceObjectsJSON = `
+[
+  {
+    "kind": "INTERFACE",
+    "name": "Pet",
+    "description": "A pet.",
+    "fields": [
+      {
+        "name": "name",
+        "description": "The name of the pet.",
+        "args": [],
+        "type": {
+          "kind": "NON_NULL",
+          "ofType": { "kind": "SCALAR", "name": "String" }
+        }
+      }
+    ],
+    "possibleTypes": [
+      { "kind": "OBJECT", "name": "Cat" },
+      { "kind": "OBJECT", "name": "Dog" }
+    ]
+  },
+  {
+    "kind": "OBJECT",
+    "name": "Dog",
+    "description": "A dog.",
+    "fields": [
+      {
+        "name": "name",
+        "description": "The name of the pet.",
+        "args": [],
+        "type": {
+          "kind": "NON_NULL",
+          "ofType": { "kind": "SCALAR", "name": "String" }
+        }
+      }
+    ],
+    "interfaces": [{ "kind": "INTERFACE", "name": "Pet" }]
+  }
+]
+`
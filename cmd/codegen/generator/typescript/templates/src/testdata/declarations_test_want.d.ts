@@ -0,0 +1,115 @@
+/**
+ * This file was auto-generated by `client-gen`.
+ * Do not make direct changes to the file.
+ */
+import { Context, ConnectOpts, CallbackFct } from "@dagger.io/dagger"
+
+export declare function connection(
+  fct: () => Promise<void>,
+  cfg?: ConnectOpts,
+): Promise<void>
+
+export declare function connect(
+  fct: CallbackFct,
+  cfg?: ConnectOpts,
+): Promise<void>
+
+/**
+ * Declare a number as float in the Dagger API.
+ */
+export type float = number
+
+declare class BaseClient {
+  /**
+   * @hidden
+   */
+  protected _ctx: Context
+
+  constructor(_ctx?: Context)
+}
+
+export type HostDirectoryOpts = {
+  exclude?: string[]
+  include?: string[]
+}
+
+export type HostWorkdirOpts = {
+  exclude?: string[]
+  include?: string[]
+}
+
+/**
+ * Transport layer network protocol associated to a port.
+ */
+export enum NetworkProtocol {
+  Tcp = "TCP",
+  Udp = "UDP",
+}
+
+/**
+ * Returns true if the value is a NetworkProtocol.
+ */
+export declare function isNetworkProtocol(value: unknown): value is NetworkProtocol
+
+/**
+ * Parses a NetworkProtocol from its value, throwing an Error for invalid values.
+ */
+export declare function parseNetworkProtocol(value: string): NetworkProtocol
+/**
+ * A directory whose contents persist across runs
+ */
+export declare class CacheVolume extends BaseClient {
+  private readonly _id?
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: CacheVolumeID,
+  )
+  id: () => Promise<CacheVolumeID>
+}
+
+/**
+ * Information about the host execution environment
+ */
+export declare class Host extends BaseClient {
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+  )
+
+  /**
+   * Access a directory on the host
+   */
+  directory: (path: string, opts?: HostDirectoryOpts) => Directory
+
+  /**
+   * Lookup the value of an environment variable. Null if the variable is not available.
+   */
+  envVariable: (name: string) => HostVariable
+
+  /**
+   * The current working directory on the host
+   */
+  workdir: (opts?: HostWorkdirOpts) => Directory
+}
+
+
+
+export declare const dag: Client
+
+/**
+ * The version of the schema the client was generated from.
+ */
+export declare const schemaVersion: ""
+
+/**
+ * Returns the version of the schema the client was generated from,
+ * schemaVersion.
+ */
+export declare function version(): string
@@ -0,0 +1,59 @@
+/**
+ * This file was auto-generated by `client-gen`.
+ * Do not make direct changes to the file.
+ */
+import type * as api from "./client.gen.js"
+
+/**
+ * Create a mock implementing T from the given methods.
+ *
+ * Calling a method that is not implemented throws an error.
+ */
+function mock<T>(name: string, impl: Partial<T>): T {
+  return new Proxy(impl, {
+    get(target, prop, receiver) {
+      if (prop in target) {
+        return Reflect.get(target, prop, receiver)
+      }
+
+      // Avoid being mistaken for a thenable when awaited.
+      if (prop === "then" || typeof prop === "symbol") {
+        return undefined
+      }
+
+      return () => {
+        throw new Error(`${name}.${String(prop)} is not implemented`)
+      }
+    },
+  }) as T
+}
+
+/**
+ * Methods of a mocked CacheVolume.
+ */
+export type CacheVolumeMock = {
+  id?: api.CacheVolume["id"]
+}
+
+/**
+ * Create a mock of CacheVolume implemented by the given methods.
+ */
+export function mockCacheVolume(impl: CacheVolumeMock = {}): api.CacheVolume {
+  return mock<api.CacheVolume>("CacheVolume", impl)
+}
+
+/**
+ * Methods of a mocked Host.
+ */
+export type HostMock = {
+  directory?: api.Host["directory"]
+  envVariable?: api.Host["envVariable"]
+  workdir?: api.Host["workdir"]
+}
+
+/**
+ * Create a mock of Host implemented by the given methods.
+ */
+export function mockHost(impl: HostMock = {}): api.Host {
+  return mock<api.Host>("Host", impl)
+}
@@ -0,0 +1,104 @@
+
+/**
+ * A pet.
+ */
+export class Pet extends BaseClient {
+  private readonly _name?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+   constructor(
+    ctx?: Context,
+     _name?: string,
+   ) {
+     super(ctx)
+
+     this._name = _name
+   }
+
+  /**
+   * The name of the pet.
+   */
+  name = async (): Promise<string> => {
+    if (this._name) {
+      return this._name
+    }
+
+    const ctx = this._ctx.select(
+      "name",
+    )
+
+    const response: Awaited<string> = await ctx.execute()
+
+    
+    return response
+  }
+
+  /**
+   * Downcast this Pet into a Cat.
+   *
+   * The concrete type is resolved using the `__typename` of the underlying
+   * object, undefined is returned if it is not a Cat.
+   */
+  asCat = async (): Promise<Cat | undefined> => {
+    const typename: string = await this._ctx.select("__typename").execute()
+    if (typename !== "Cat") {
+      return undefined
+    }
+
+    return new Cat(this._ctx)
+  }
+
+  /**
+   * Downcast this Pet into a Dog.
+   *
+   * The concrete type is resolved using the `__typename` of the underlying
+   * object, undefined is returned if it is not a Dog.
+   */
+  asDog = async (): Promise<Dog | undefined> => {
+    const typename: string = await this._ctx.select("__typename").execute()
+    if (typename !== "Dog") {
+      return undefined
+    }
+
+    return new Dog(this._ctx)
+  }
+}
+
+/**
+ * A dog.
+ */
+export class Dog extends BaseClient {
+  private readonly _name?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+   constructor(
+    ctx?: Context,
+     _name?: string,
+   ) {
+     super(ctx)
+
+     this._name = _name
+   }
+
+  /**
+   * The name of the pet.
+   */
+  name = async (): Promise<string> => {
+    if (this._name) {
+      return this._name
+    }
+
+    const ctx = this._ctx.select(
+      "name",
+    )
+
+    const response: Awaited<string> = await ctx.execute()
+
+    
+    return response
+  }
+}
@@ -0,0 +1,25 @@
+
+/**
+ * Transport layer network protocol associated to a port.
+ */
+export enum NetworkProtocol {
+  Tcp = "TCP",
+  Udp = "UDP",
+}
+
+/**
+ * Returns true if the value is a NetworkProtocol.
+ */
+export function isNetworkProtocol(value: unknown): value is NetworkProtocol {
+  return Object.values(NetworkProtocol).includes(value as NetworkProtocol)
+}
+
+/**
+ * Parses a NetworkProtocol from its value, throwing an Error for invalid values.
+ */
+export function parseNetworkProtocol(value: string): NetworkProtocol {
+  if (!isNetworkProtocol(value)) {
+    throw new Error(`invalid NetworkProtocol value "${value}"`)
+  }
+  return value
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+/**
+ * This file was auto-generated by `client-gen`.
+ * Do not make direct changes to the file.
+ */
+
+export type HostDirectoryOpts = {
+  exclude?: string[]
+  include?: string[]
+}
+
+export type HostWorkdirOpts = {
+  exclude?: string[]
+  include?: string[]
+}
+
+/**
+ * A directory whose contents persist across runs
+ */
+export type CacheVolume = {
+  id?: CacheVolumeID
+}
+
+/**
+ * Information about the host execution environment
+ */
+export type Host = {
+}
+
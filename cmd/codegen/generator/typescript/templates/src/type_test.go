@@ -55,6 +55,34 @@ func TestType(t *testing.T) {
 		require.Equal(t, want, b.String())
 	})
 
+	t.Run("enum", func(t *testing.T) {
+		wantFile := "testdata/type_test_enum_want.ts"
+
+		var enumTypeJSON = `
+    {
+      "kind": "ENUM",
+      "name": "NetworkProtocol",
+      "description": "Transport layer network protocol associated to a port.",
+      "enumValues": [
+        {"name": "UDP"},
+        {"name": "TCP"}
+      ]
+    }
+    `
+
+		tmpl := templateHelper(t)
+
+		object := objectInit(t, enumTypeJSON)
+
+		var b bytes.Buffer
+		err := tmpl.ExecuteTemplate(&b, "type", object)
+
+		want := updateAndGetFixtures(t, wantFile, b.String())
+
+		require.NoError(t, err)
+		require.Equal(t, want, b.String())
+	})
+
 	t.Run("input", func(t *testing.T) {
 		var expectedInputType = `
 export type BuildArg = {
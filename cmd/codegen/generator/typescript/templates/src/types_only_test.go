@@ -0,0 +1,21 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypesOnly(t *testing.T) {
+	tmpl := templateHelper(t)
+
+	objects := objectsInit(t, objectsJSON)
+
+	var b bytes.Buffer
+	err := tmpl.ExecuteTemplate(&b, "types_only", objects)
+
+	want := updateAndGetFixtures(t, "testdata/types_only_test_want.ts", b.String())
+	require.NoError(t, err)
+	require.Equal(t, want, b.String())
+}
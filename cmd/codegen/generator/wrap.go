@@ -0,0 +1,73 @@
+package generator
+
+import "strings"
+
+// WrapText word-wraps each line of s so that it fits in width columns.
+//
+// Existing line breaks are kept. Code spans (delimited by backticks) are never
+// split, and words longer than width, such as URLs, are left on their own
+// line rather than broken. A width of 0 or less disables wrapping.
+func WrapText(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapLine(line, width)...)
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+func wrapLine(line string, width int) []string {
+	if len(line) <= width {
+		return []string{line}
+	}
+
+	// keep the indentation of the line, e.g. for markdown lists
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+	var lines []string
+	var current strings.Builder
+	current.WriteString(indent)
+	for _, word := range wrapWords(line) {
+		if current.Len() > len(indent) && current.Len()+1+len(word) > width {
+			lines = append(lines, current.String())
+			current.Reset()
+			current.WriteString(indent)
+		}
+		if current.Len() > len(indent) {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+	}
+	return append(lines, current.String())
+}
+
+// wrapWords splits a line into the units that can be wrapped, keeping code
+// spans containing spaces as a single unit.
+func wrapWords(line string) []string {
+	var words []string
+	var span []string
+	for _, field := range strings.Fields(line) {
+		if span != nil {
+			span = append(span, field)
+			if strings.Count(field, "`")%2 == 1 {
+				words = append(words, strings.Join(span, " "))
+				span = nil
+			}
+			continue
+		}
+		if strings.Count(field, "`")%2 == 1 {
+			span = []string{field}
+			continue
+		}
+		words = append(words, field)
+	}
+	if span != nil {
+		// unterminated code span, keep it as is
+		words = append(words, strings.Join(span, " "))
+	}
+	return words
+}
@@ -99,14 +99,15 @@ const (
 )
 
 type Type struct {
-	Kind        TypeKind     `json:"kind"`
-	Name        string       `json:"name"`
-	Description string       `json:"description,omitempty"`
-	Fields      []*Field     `json:"fields,omitempty"`
-	InputFields []InputValue `json:"inputFields,omitempty"`
-	EnumValues  []EnumValue  `json:"enumValues,omitempty"`
-	Interfaces  []*Type      `json:"interfaces"`
-	Directives  Directives   `json:"directives"`
+	Kind          TypeKind     `json:"kind"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description,omitempty"`
+	Fields        []*Field     `json:"fields,omitempty"`
+	InputFields   []InputValue `json:"inputFields,omitempty"`
+	EnumValues    []EnumValue  `json:"enumValues,omitempty"`
+	Interfaces    []*Type      `json:"interfaces"`
+	PossibleTypes []*Type      `json:"possibleTypes,omitempty"`
+	Directives    Directives   `json:"directives"`
 }
 
 // Remove all occurrences of a type from the schema, including
@@ -227,6 +228,14 @@ func (r TypeRef) IsObject() bool {
 	return false
 }
 
+func (r TypeRef) IsInterface() bool {
+	ref := r
+	if r.Kind == TypeKindNonNull {
+		ref = *ref.OfType
+	}
+	return ref.Kind == TypeKindInterface
+}
+
 func (r TypeRef) IsList() bool {
 	ref := r
 	if r.Kind == TypeKindNonNull {
@@ -265,11 +274,13 @@ func (i InputValues) HasOptionals() bool {
 }
 
 type InputValue struct {
-	Name         string     `json:"name"`
-	Description  string     `json:"description"`
-	DefaultValue *string    `json:"defaultValue"`
-	TypeRef      *TypeRef   `json:"type"`
-	Directives   Directives `json:"directives"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	DefaultValue      *string    `json:"defaultValue"`
+	TypeRef           *TypeRef   `json:"type"`
+	IsDeprecated      bool       `json:"isDeprecated,omitempty"`
+	DeprecationReason string     `json:"deprecationReason,omitempty"`
+	Directives        Directives `json:"directives"`
 }
 
 func (v InputValue) IsOptional() bool {
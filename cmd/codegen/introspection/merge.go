@@ -0,0 +1,158 @@
+package introspection
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MergeSchemas combines the given schemas into a single one.
+//
+// Types sharing the same name, whether they come from different schemas or
+// are declared multiple times in the same schema (e.g. when a type is
+// extended by another source of a stitched schema), are merged into a single
+// definition containing the union of their fields, input fields, enum values,
+// interfaces and possible types.
+// An error is returned if the same field is declared with conflicting
+// definitions.
+func MergeSchemas(schemas ...*Schema) (*Schema, error) {
+	merged := &Schema{}
+
+	byName := map[string]*Type{}
+	directives := map[string]struct{}{}
+	for _, schema := range schemas {
+		if schema == nil {
+			continue
+		}
+
+		if merged.QueryType.Name == "" {
+			merged.QueryType = schema.QueryType
+		}
+		if merged.MutationType == nil {
+			merged.MutationType = schema.MutationType
+		}
+		if merged.SubscriptionType == nil {
+			merged.SubscriptionType = schema.SubscriptionType
+		}
+
+		for _, t := range schema.Types {
+			existing, ok := byName[t.Name]
+			if !ok {
+				cp := *t
+				cp.Fields = slices.Clone(t.Fields)
+				cp.InputFields = slices.Clone(t.InputFields)
+				cp.EnumValues = slices.Clone(t.EnumValues)
+				cp.Interfaces = slices.Clone(t.Interfaces)
+				cp.PossibleTypes = slices.Clone(t.PossibleTypes)
+				byName[t.Name] = &cp
+				merged.Types = append(merged.Types, &cp)
+				continue
+			}
+			if err := existing.merge(t); err != nil {
+				return nil, fmt.Errorf("merge type %s: %w", t.Name, err)
+			}
+		}
+
+		for _, d := range schema.Directives {
+			if _, ok := directives[d.Name]; ok {
+				continue
+			}
+			directives[d.Name] = struct{}{}
+			merged.Directives = append(merged.Directives, d)
+		}
+	}
+
+	return merged, nil
+}
+
+func (t *Type) merge(other *Type) error {
+	if t.Kind != other.Kind {
+		return fmt.Errorf("conflicting kinds %s and %s", t.Kind, other.Kind)
+	}
+	if t.Description == "" {
+		t.Description = other.Description
+	}
+
+	for _, f := range other.Fields {
+		idx := slices.IndexFunc(t.Fields, func(existing *Field) bool {
+			return existing.Name == f.Name
+		})
+		if idx == -1 {
+			t.Fields = append(t.Fields, f)
+			continue
+		}
+		if !t.Fields[idx].TypeRef.Equal(f.TypeRef) || !t.Fields[idx].Args.Equal(f.Args) {
+			return fmt.Errorf("conflicting definitions for field %s", f.Name)
+		}
+	}
+
+	for _, f := range other.InputFields {
+		idx := slices.IndexFunc(t.InputFields, func(existing InputValue) bool {
+			return existing.Name == f.Name
+		})
+		if idx == -1 {
+			t.InputFields = append(t.InputFields, f)
+			continue
+		}
+		if !t.InputFields[idx].Equal(f) {
+			return fmt.Errorf("conflicting definitions for input field %s", f.Name)
+		}
+	}
+
+	for _, v := range other.EnumValues {
+		if !slices.ContainsFunc(t.EnumValues, func(existing EnumValue) bool {
+			return existing.Name == v.Name
+		}) {
+			t.EnumValues = append(t.EnumValues, v)
+		}
+	}
+
+	t.Interfaces = appendMissingTypes(t.Interfaces, other.Interfaces)
+	t.PossibleTypes = appendMissingTypes(t.PossibleTypes, other.PossibleTypes)
+
+	return nil
+}
+
+func appendMissingTypes(types []*Type, others []*Type) []*Type {
+	for _, o := range others {
+		if !slices.ContainsFunc(types, func(existing *Type) bool {
+			return existing.Name == o.Name
+		}) {
+			types = append(types, o)
+		}
+	}
+	return types
+}
+
+// Equal returns true if both type references point to the same type.
+func (r *TypeRef) Equal(other *TypeRef) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Kind == other.Kind && r.Name == other.Name && r.OfType.Equal(other.OfType)
+}
+
+// Equal returns true if both input values have the same name, type and
+// default value.
+func (v InputValue) Equal(other InputValue) bool {
+	if v.Name != other.Name || !v.TypeRef.Equal(other.TypeRef) {
+		return false
+	}
+	if v.DefaultValue == nil || other.DefaultValue == nil {
+		return v.DefaultValue == other.DefaultValue
+	}
+	return *v.DefaultValue == *other.DefaultValue
+}
+
+// Equal returns true if both lists declare the same input values, in any
+// order.
+func (i InputValues) Equal(other InputValues) bool {
+	if len(i) != len(other) {
+		return false
+	}
+	for _, v := range i {
+		if !slices.ContainsFunc(other, v.Equal) {
+			return false
+		}
+	}
+	return true
+}
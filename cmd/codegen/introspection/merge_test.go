@@ -0,0 +1,82 @@
+package introspection
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T, path string) *Schema {
+	t.Helper()
+	dt, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(dt, &resp))
+	return resp.Schema
+}
+
+func fieldNames(t *Type) []string {
+	names := make([]string, 0, len(t.Fields))
+	for _, f := range t.Fields {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestMergeSchemasExtendedType(t *testing.T) {
+	schema := loadFixture(t, "testdata/extended.json")
+
+	merged, err := MergeSchemas(schema)
+	require.NoError(t, err)
+	require.Len(t, merged.Types, 2)
+	require.Equal(t, "Query", merged.QueryType.Name)
+
+	user := merged.Types.Get("User")
+	require.NotNil(t, user)
+	require.Equal(t, "A user, defined by the accounts service.", user.Description)
+	require.Equal(t, []string{"id", "name", "reviews"}, fieldNames(user))
+}
+
+func TestMergeSchemasMultipleSources(t *testing.T) {
+	accounts := &Schema{
+		Types: Types{
+			{Kind: TypeKindEnum, Name: "Role", EnumValues: []EnumValue{{Name: "ADMIN"}}},
+		},
+	}
+	accounts.QueryType.Name = "Query"
+	billing := &Schema{
+		Types: Types{
+			{Kind: TypeKindEnum, Name: "Role", EnumValues: []EnumValue{{Name: "ADMIN"}, {Name: "BILLING"}}},
+		},
+	}
+
+	merged, err := MergeSchemas(accounts, billing)
+	require.NoError(t, err)
+	require.Equal(t, "Query", merged.QueryType.Name)
+
+	role := merged.Types.Get("Role")
+	require.Len(t, role.EnumValues, 2)
+
+	// inputs are left untouched
+	require.Len(t, accounts.Types.Get("Role").EnumValues, 1)
+}
+
+func TestMergeSchemasConflict(t *testing.T) {
+	str := &TypeRef{Kind: TypeKindScalar, Name: "String"}
+	integer := &TypeRef{Kind: TypeKindScalar, Name: "Int"}
+
+	_, err := MergeSchemas(
+		&Schema{Types: Types{{Kind: TypeKindObject, Name: "User", Fields: []*Field{{Name: "age", TypeRef: str}}}}},
+		&Schema{Types: Types{{Kind: TypeKindObject, Name: "User", Fields: []*Field{{Name: "age", TypeRef: integer}}}}},
+	)
+	require.ErrorContains(t, err, "conflicting definitions for field age")
+
+	_, err = MergeSchemas(
+		&Schema{Types: Types{{Kind: TypeKindObject, Name: "User"}}},
+		&Schema{Types: Types{{Kind: TypeKindInputObject, Name: "User"}}},
+	)
+	require.ErrorContains(t, err, "conflicting kinds")
+}
@@ -28,6 +28,9 @@ func (v *Visitor) Run() []*Type {
 		{
 			Kind: TypeKindInputObject,
 		},
+		{
+			Kind: TypeKindInterface,
+		},
 		{
 			Kind: TypeKindObject,
 		},
@@ -74,6 +77,14 @@ func (v *Visitor) visit(kind TypeKind, ignore map[string]any) []*Type {
 		sort.Slice(t.InputFields, func(i, j int) bool {
 			return t.InputFields[i].Name < t.InputFields[j].Name
 		})
+
+		sort.Slice(t.Interfaces, func(i, j int) bool {
+			return t.Interfaces[i].Name < t.Interfaces[j].Name
+		})
+
+		sort.Slice(t.PossibleTypes, func(i, j int) bool {
+			return t.PossibleTypes[i].Name < t.PossibleTypes[j].Name
+		})
 	}
 
 	return types
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -20,12 +22,18 @@ var (
 	outputDir             string
 	lang                  string
 	introspectionJSONPath string
+	schemaSnapshotDir     string
+
+	compatIntrospectionJSONPaths []string
 
 	modulePath string
 	moduleName string
 
-	outputSchema string
-	merge        bool
+	outputSchema     string
+	outputJSONSchema bool
+	outputSnapshot   string
+	merge            bool
+	goModSearchDepth int
 
 	clientOnly bool
 
@@ -33,10 +41,106 @@ var (
 
 	bundle bool
 
+	serveDependencies bool
+
+	resolveTransitiveDependencies bool
+	dependencyConcurrency         int
+
+	reuseConnection            bool
+	lazyConnect                bool
+	typedIDs                   bool
+	generateIDLoaders          bool
+	generatePluggableTransport bool
+	generateDryRunTransport    bool
+	generateRequestHooks       bool
+	generateMetrics            bool
+	generateOperationNames     bool
+	generateContextLabels      bool
+	generateErrorCodes         bool
+	errorCodes                 []string
+	errorCodeEnum              string
+	generateClientRetry        bool
+	clientRetryMaxAttempts     int
+	clientRetryBackoff         time.Duration
+	clientRetryExcludedFields  []string
+	defaultOperationTimeout    time.Duration
+	maxQueryDepth              int
+	includeQueryInErrors       bool
+	generateConnectionPool     bool
+	connectionPoolSize         int
+	generateFieldCache         bool
+	cacheableFields            []string
+	generateSession            bool
+	generatePing               bool
+	generateRawQuery           bool
+	generateIntrospectMethod   bool
+	namespaceByModule          bool
+	generateBatching           bool
+	scaffoldTests              bool
+	generateTracing            bool
+
+	goJSONPackage   string
+	goOptionalStyle string
+	skipGoFormat    bool
+	goTarget        string
+	goPlatformSplit bool
+
+	generateMocks bool
+
+	typesOnly           bool
+	standalone          bool
+	splitByType         bool
+	changedTypes        []string
+	generatedFileSuffix string
+
+	typeScriptDeclarationOnly bool
+	typeScriptCodeSplit       bool
+
+	generateArgValidation bool
+	guardRequiredArgs     bool
+
+	generateInputConstructors bool
+	generateValidationTags    bool
+	validationTagKey          string
+	separatePreview           bool
+	previewDirective          string
+	previewFieldPrefix        string
+
+	generateSelectors        bool
+	generatePathAccessors    bool
+	generateVariableStructs  bool
+	generateContextlessShims bool
+
+	generatePaginationHelpers bool
+	typedCursors              bool
+	generateLogStreams        bool
+
+	userRegionStart string
+	userRegionEnd   string
+
+	backup bool
+
+	docCommentWrap      int
+	annotateNullability bool
+
+	importRewrites map[string]string
+
+	hiddenTypePrefixes         []string
+	excludeDeprecated          bool
+	strictSchema               bool
+	embedSchema                bool
+	generateResponseValidation bool
+
+	manifestPath       string
+	manifestProvenance bool
+
 	moduleSourceID string
 
-	//go:embed modsourcedeps.graphql
-	loadModuleSourceDepsQuery string
+	watchInterval time.Duration
+
+	configPath string
+	// defaultConfig is the config of the flags left to their default
+	defaultConfig generator.Config
 )
 
 var rootCmd = &cobra.Command{
@@ -55,38 +159,123 @@ var introspectCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.Flags().StringVar(&lang, "lang", "go", "language to generate")
+	rootCmd.Flags().StringVar(&lang, "lang", "go", fmt.Sprintf("language to generate %s", generator.SupportedLangs()))
 	rootCmd.Flags().StringVarP(&outputDir, "output", "o", ".", "output directory")
 	rootCmd.Flags().StringVar(&introspectionJSONPath, "introspection-json-path", "", "optional path to file containing pre-computed graphql introspection JSON")
+	rootCmd.Flags().StringVar(&schemaSnapshotDir, "schema-snapshot-dir", "", "optional directory containing a schema snapshot written by the introspect command")
+	rootCmd.Flags().StringSliceVar(&compatIntrospectionJSONPaths, "compat-introspection-json-path", nil, "path to a file containing the introspection JSON of another schema the generated code must also work with")
 
 	rootCmd.Flags().StringVar(&modulePath, "module-source-path", "", "path to source subpath of the module")
 	rootCmd.Flags().StringVar(&moduleName, "module-name", "", "name of module to generate code for")
 	rootCmd.Flags().BoolVar(&merge, "merge", false, "merge module deps with project's existing go.mod in a parent directory")
+	rootCmd.Flags().IntVar(&goModSearchDepth, "go-mod-search-depth", 0, "number of parent directories searched for a go.mod with --merge (default up to the output directory)")
 	rootCmd.Flags().BoolVar(&isInit, "is-init", false, "whether this command is initializing a new module")
 	rootCmd.Flags().BoolVar(&clientOnly, "client-only", false, "generate only client code")
 	rootCmd.Flags().BoolVar(&bundle, "bundle", false, "generate the client in bundle mode")
+	rootCmd.Flags().BoolVar(&serveDependencies, "serve-dependencies", true, "serve the module dependencies when the generated client connects")
+	rootCmd.Flags().BoolVar(&resolveTransitiveDependencies, "resolve-transitive-dependencies", false, "also serve the dependencies of the module dependencies when the generated client connects")
+	rootCmd.Flags().IntVar(&dependencyConcurrency, "dependency-concurrency", 0, fmt.Sprintf("number of dependencies resolved at the same time with --resolve-transitive-dependencies (default %d)", generator.DefaultResolveConcurrency))
+	rootCmd.Flags().BoolVar(&reuseConnection, "reuse-connection", false, "generate a constructor of the client reusing an existing connection (go only)")
+	rootCmd.Flags().BoolVar(&lazyConnect, "lazy-connect", false, "generate a client connecting to the engine on its first request (go only)")
+	rootCmd.Flags().BoolVar(&typedIDs, "typed-ids", false, "generate the ids of the objects declared with the built-in ID scalar with a scalar per object (go only)")
+	rootCmd.Flags().BoolVar(&generateIDLoaders, "generate-id-loaders", false, "generate a function loading each object with a loader from its id (go only)")
+	rootCmd.Flags().BoolVar(&generatePluggableTransport, "generate-pluggable-transport", false, "generate a Transport interface and a constructor of the client taking one, e.g. a fake in tests (go only)")
+	rootCmd.Flags().BoolVar(&generateDryRunTransport, "generate-dry-run-transport", false, "generate a dry run of the client, enabled at runtime, recording its queries instead of sending them (go only)")
+	rootCmd.Flags().BoolVar(&generateRequestHooks, "generate-request-hooks", false, "generate a hook called before each request of the client")
+	rootCmd.Flags().BoolVar(&generateMetrics, "generate-metrics", false, "generate counters of the requests of the client methods and their errors (go only)")
+	rootCmd.Flags().BoolVar(&generateOperationNames, "generate-operation-names", false, "name the GraphQL operations of the requests of the client methods after their fields")
+	rootCmd.Flags().BoolVar(&generateContextLabels, "generate-context-labels", false, "attach the labels of the context of the requests of the client to them as baggage (go only)")
+	rootCmd.Flags().BoolVar(&generateErrorCodes, "generate-error-codes", false, "generate errors named after the known codes of the engine errors (go only)")
+	rootCmd.Flags().StringSliceVar(&errorCodes, "error-code", nil, "known code of the engine errors of --generate-error-codes")
+	rootCmd.Flags().StringVar(&errorCodeEnum, "error-code-enum", "", "enum of the schema listing the known codes of the engine errors of --generate-error-codes")
+	rootCmd.Flags().BoolVar(&generateClientRetry, "generate-client-retry", false, "retry the requests of the client failing with a transient error (go only)")
+	rootCmd.Flags().IntVar(&clientRetryMaxAttempts, "client-retry-max-attempts", 0, "default maximum number of attempts of a request of the client, with --generate-client-retry (default 3)")
+	rootCmd.Flags().DurationVar(&clientRetryBackoff, "client-retry-backoff", 0, "default wait before the first retry of a request of the client, with --generate-client-retry (default 100ms)")
+	rootCmd.Flags().StringSliceVar(&clientRetryExcludedFields, "client-retry-excluded-field", nil, "name of a field with side effects whose queries aren't retried, with --generate-client-retry (default the ones of the engine, e.g. publish)")
+	rootCmd.Flags().DurationVar(&defaultOperationTimeout, "default-operation-timeout", 0, "default timeout of each request of the client whose context has no deadline, 0 for none (go only)")
+	rootCmd.Flags().IntVar(&maxQueryDepth, "max-query-depth", 0, "maximum depth of the selections of the queries of the client, 0 for no limit (go only)")
+	rootCmd.Flags().BoolVar(&includeQueryInErrors, "include-query-in-errors", false, "include the query and the variables of the failed requests of the client in their errors, which may leak secrets (go only)")
+	rootCmd.Flags().BoolVar(&generateConnectionPool, "generate-connection-pool", false, "generate constructors of the client taking the options of its connection pool (go only)")
+	rootCmd.Flags().IntVar(&connectionPoolSize, "connection-pool-size", 0, "default maximum number of concurrent requests of the client, with --generate-connection-pool (default no limit)")
+	rootCmd.Flags().BoolVar(&generateFieldCache, "generate-field-cache", false, "fetch the cacheable fields once per query in the client (go only)")
+	rootCmd.Flags().StringSliceVar(&cacheableFields, "cacheable-field", nil, "pattern of the Type.field names of the fields to cache with --generate-field-cache, e.g. *.id")
+	rootCmd.Flags().BoolVar(&generateSession, "generate-session", false, "generate a session wrapping the client to apply common options to its calls (go only)")
+	rootCmd.Flags().BoolVar(&generatePing, "generate-ping", false, "generate a ping method on the client checking that the engine is reachable")
+	rootCmd.Flags().BoolVar(&generateRawQuery, "generate-raw-query", false, "generate a raw method on the client sending a GraphQL query as is, with an unvalidated response")
+	rootCmd.Flags().BoolVar(&generateIntrospectMethod, "generate-introspect-method", false, "generate an introspect method on the client returning the live schema (go only)")
+	rootCmd.Flags().BoolVar(&namespaceByModule, "namespace-by-module", false, "group the functions of each module dependency under a method of the client named after the module (go only)")
+	rootCmd.Flags().BoolVar(&generateBatching, "generate-batching", false, "generate a batch of calls of the client sent as a single request (go only)")
+	rootCmd.Flags().BoolVar(&scaffoldTests, "scaffold-tests", false, "add a starter test file to a module being initialized (go and typescript only)")
+	rootCmd.Flags().BoolVar(&generateTracing, "generate-tracing", false, "start an OpenTelemetry span in the client methods making a request (go only)")
+	rootCmd.Flags().StringVar(&goJSONPackage, "go-json-package", "", "import path of the JSON package used by the generated code instead of encoding/json (go only)")
+	rootCmd.Flags().StringVar(&goOptionalStyle, "go-optional-style", "", "how the values of the nullable output fields are rendered: Value (default), Pointer or Optional[T] (go only)")
+	rootCmd.Flags().BoolVar(&skipGoFormat, "skip-go-format", false, "write the generated code as rendered, without formatting it and organizing its imports (go only)")
+	rootCmd.Flags().StringVar(&goTarget, "go-target", "", "platform the generated client must compile for besides the native ones: wasm or tinygo (go only)")
+	rootCmd.Flags().BoolVar(&goPlatformSplit, "go-platform-split", false, "generate the platform-specific helpers of the client in files restricted to their platform by build constraints (go only)")
+	rootCmd.Flags().BoolVar(&generateMocks, "generate-mocks", false, "generate mocks of the client for testing")
+	rootCmd.Flags().BoolVar(&typesOnly, "types-only", false, "generate only the types of the client, without the query builder")
+	rootCmd.Flags().BoolVar(&standalone, "standalone", false, "generate only the types of the client, depending on the standard library only (go only)")
+	rootCmd.Flags().BoolVar(&splitByType, "split-by-type", false, "generate each object type in its own file (go only)")
+	rootCmd.Flags().StringSliceVar(&changedTypes, "changed-type", nil, "only generate the files of this type and of the types depending on it, with --split-by-type")
+	rootCmd.Flags().StringVar(&generatedFileSuffix, "generated-file-suffix", "", "suffix of the names of the generated files before their extension, instead of .gen (go and typescript only)")
+	rootCmd.Flags().BoolVar(&typeScriptDeclarationOnly, "typescript-declaration-only", false, "generate only the declarations (.d.ts) of the typescript client")
+	rootCmd.Flags().BoolVar(&typeScriptCodeSplit, "typescript-code-split", false, "write each object of the typescript client in a chunk the client imports lazily")
+	rootCmd.Flags().BoolVar(&generateArgValidation, "generate-arg-validation", false, "generate client-side validation of required arguments")
+	rootCmd.Flags().BoolVar(&guardRequiredArgs, "guard-required-args", false, "reject the zero values of the required string, enum, custom scalar and list arguments at call time (go only)")
+	rootCmd.Flags().BoolVar(&generateInputConstructors, "generate-input-constructors", false, "generate constructors of the input types taking their required fields (go only)")
+	rootCmd.Flags().BoolVar(&generateValidationTags, "generate-validation-tags", false, "tag the fields of the input types with their constraints for a validator library (go only)")
+	rootCmd.Flags().StringVar(&validationTagKey, "validation-tag-key", "", "key of the tags of --generate-validation-tags (default validate)")
+	rootCmd.Flags().BoolVar(&separatePreview, "separate-preview", false, "move the preview fields of each object to a separate type returned by its Preview method (go only)")
+	rootCmd.Flags().StringVar(&previewDirective, "preview-directive", "", "directive flagging the preview fields of --separate-preview (default experimental)")
+	rootCmd.Flags().StringVar(&previewFieldPrefix, "preview-field-prefix", "", "prefix of the names of the preview fields of --separate-preview")
+	rootCmd.Flags().BoolVar(&generateSelectors, "generate-selectors", false, "generate helpers fetching only some fields of the objects (go only)")
+	rootCmd.Flags().BoolVar(&generatePathAccessors, "generate-path-accessors", false, "generate typed paths to the nested fields of the objects (go only)")
+	rootCmd.Flags().BoolVar(&generateVariableStructs, "generate-variable-structs", false, "generate structs of the arguments of the fields, reusable across calls (go only)")
+	rootCmd.Flags().BoolVar(&generateContextlessShims, "generate-contextless-shims", false, "generate deprecated methods calling the methods taking a context with context.Background() (go only)")
+	rootCmd.Flags().BoolVar(&generatePaginationHelpers, "generate-pagination-helpers", false, "generate helpers paging through connection fields (go only)")
+	rootCmd.Flags().BoolVar(&typedCursors, "typed-cursors", false, "generate the cursors of each connection as a distinct type (go only)")
+	rootCmd.Flags().BoolVar(&generateLogStreams, "generate-log-streams", false, "generate a typed consumer sending the entries of each log stream to a channel (go only)")
+	rootCmd.Flags().IntVar(&docCommentWrap, "doc-comment-wrap", 0, "column at which to wrap generated doc comments (0 disables wrapping)")
+	rootCmd.Flags().BoolVar(&annotateNullability, "annotate-nullability", false, "annotate the doc comments of the object fields with their nullability")
+	rootCmd.Flags().StringVar(&userRegionStart, "user-region-start", "", "marker of the start of a user region preserved on regeneration, e.g. dagger:user-region:start (requires --user-region-end)")
+	rootCmd.Flags().StringVar(&userRegionEnd, "user-region-end", "", "marker of the end of a user region preserved on regeneration, e.g. dagger:user-region:end (requires --user-region-start)")
+	rootCmd.Flags().BoolVar(&backup, "backup", false, "back up the existing files overwritten by the generation with a .bak suffix")
+	rootCmd.Flags().StringToStringVar(&importRewrites, "import-rewrite", nil, "rewrite an import path of the generated code (e.g. dagger.io/dagger=example.com/mirror/dagger)")
+	rootCmd.Flags().StringSliceVar(&hiddenTypePrefixes, "hidden-type-prefix", nil, "hide the types whose name starts with this prefix from the generated code")
+	rootCmd.Flags().BoolVar(&excludeDeprecated, "exclude-deprecated", false, "exclude the deprecated fields, arguments, input fields and enum values from the generated code")
+	rootCmd.Flags().BoolVar(&strictSchema, "strict-schema", false, "fail if the schema violates the hygiene rules, e.g. a public field without description")
+	rootCmd.Flags().BoolVar(&embedSchema, "embed-schema", false, "embed the introspection of the schema and its version in the generated code")
+	rootCmd.Flags().BoolVar(&generateResponseValidation, "generate-response-validation", false, "check the data of the responses of the client against the embedded schema (go only)")
+	rootCmd.Flags().StringVar(&manifestPath, "manifest", "", "path of the manifest of the generated files, relative to the output directory")
+	rootCmd.Flags().BoolVar(&manifestProvenance, "manifest-provenance", false, "record the provenance of the generated files in the manifest")
 	rootCmd.Flags().StringVar(&moduleSourceID, "module-source-id", "", "id of the module source to generate code for")
+	rootCmd.Flags().DurationVar(&watchInterval, "watch", 0, "watch the schema at this interval and regenerate when it changes (0 disables watching)")
+	rootCmd.Flags().StringVar(&configPath, "config", "", "path to a JSON or YAML file of the config, overridden by the flags set on the command line")
 
 	introspectCmd.Flags().StringVarP(&outputSchema, "output", "o", "", "save introspection result to file")
+	introspectCmd.Flags().BoolVar(&outputJSONSchema, "json-schema", false, "output a JSON Schema of the types of the schema instead of the introspection result")
+	introspectCmd.Flags().StringVar(&outputSnapshot, "snapshot-dir", "", "save a schema snapshot to the directory instead of the introspection result, to generate code from it with --schema-snapshot-dir")
 	rootCmd.AddCommand(introspectCmd)
+
+	defaultConfig = flagConfig()
 }
 
 func ClientGen(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	ctx = telemetry.InitEmbedded(ctx, nil)
 
-	cfg := generator.Config{
-		Lang:       generator.SDKLang(lang),
-		OutputDir:  outputDir,
-		Merge:      merge,
-		IsInit:     isInit,
-		ClientOnly: clientOnly,
-		Bundle:     bundle,
+	cfg := flagConfig()
+	if configPath != "" {
+		var err error
+		cfg, err = fileConfig(configPath, cfg)
+		if err != nil {
+			return err
+		}
 	}
 
-	// If a module source ID is provided or no introspection JSON is provided, we will query
+	// If a module source ID is provided or no introspection JSON or schema snapshot is provided, we will query
 	// the engine so we can create a connection here.
-	if moduleSourceID != "" || introspectionJSONPath == "" {
+	if cfg.ModuleSourceID != "" || (introspectionJSONPath == "" && cfg.IntrospectionJSON == "" && cfg.SchemaSnapshotDir == "") {
 		dag, err := dagger.Connect(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to connect to engine: %w", err)
@@ -125,34 +314,171 @@ func ClientGen(cmd *cobra.Command, args []string) error {
 		cfg.IntrospectionJSON = string(introspectionJSON)
 	}
 
-	if moduleSourceID != "" {
-		var res struct {
-			Source struct {
-				Dependencies []generator.ModuleSourceDependencies
-			}
+	for _, path := range compatIntrospectionJSONPaths {
+		compatJSON, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read compat introspection json: %w", err)
+		}
+		var resp introspection.Response
+		if err := json.Unmarshal(compatJSON, &resp); err != nil {
+			return fmt.Errorf("unmarshal compat introspection json %s: %w", path, err)
 		}
+		cfg.CompatSchemas = append(cfg.CompatSchemas, resp.Schema)
+	}
 
-		err := cfg.Dag.Do(ctx,
-			&dagger.Request{
-				Query:  loadModuleSourceDepsQuery,
-				OpName: "ModuleSourceDependencies",
-				Variables: map[string]any{
-					"source": dagger.ModuleSourceID(moduleSourceID),
-				},
-			},
-			&dagger.Response{
-				Data: &res,
-			})
+	if cfg.ModuleSourceID != "" {
+		deps, err := generator.LoadModuleDependencies(ctx, cfg)
 		if err != nil {
-			return fmt.Errorf("failed to load module source dependencies: %w", err)
+			return err
 		}
-
-		cfg.ModuleDependencies = res.Source.Dependencies
+		cfg.ModuleDependencies = deps
 	}
 
+	if watchInterval > 0 {
+		return Watch(ctx, cfg, watchInterval)
+	}
 	return Generate(ctx, cfg)
 }
 
+// flagConfig returns the config of the flags.
+func flagConfig() generator.Config {
+	return generator.Config{
+		Lang:       generator.SDKLang(lang),
+		OutputDir:  outputDir,
+		Merge:      merge,
+		IsInit:     isInit,
+		ClientOnly: clientOnly,
+		Bundle:     bundle,
+
+		GoModSearchDepth:           goModSearchDepth,
+		SkipServeDependencies:      !serveDependencies,
+		ReuseConnection:            reuseConnection,
+		LazyConnect:                lazyConnect,
+		TypedIDs:                   typedIDs,
+		GenerateIDLoaders:          generateIDLoaders,
+		GeneratePluggableTransport: generatePluggableTransport,
+		GenerateDryRunTransport:    generateDryRunTransport,
+		GenerateRequestHooks:       generateRequestHooks,
+		GenerateMetrics:            generateMetrics,
+		GenerateOperationNames:     generateOperationNames,
+		GenerateContextLabels:      generateContextLabels,
+		GenerateErrorCodes:         generateErrorCodes,
+		ErrorCodes:                 errorCodes,
+		ErrorCodeEnum:              errorCodeEnum,
+		GenerateClientRetry:        generateClientRetry,
+		ClientRetryMaxAttempts:     clientRetryMaxAttempts,
+		ClientRetryBackoff:         clientRetryBackoff,
+		ClientRetryExcludedFields:  clientRetryExcludedFields,
+		DefaultOperationTimeout:    defaultOperationTimeout,
+		MaxQueryDepth:              maxQueryDepth,
+		IncludeQueryInErrors:       includeQueryInErrors,
+		GenerateConnectionPool:     generateConnectionPool,
+		ConnectionPoolSize:         connectionPoolSize,
+		GenerateFieldCache:         generateFieldCache,
+		CacheableFields:            cacheableFields,
+		GenerateSession:            generateSession,
+		GeneratePing:               generatePing,
+		GenerateRawQuery:           generateRawQuery,
+		GenerateIntrospectMethod:   generateIntrospectMethod,
+		NamespaceByModule:          namespaceByModule,
+		GenerateBatching:           generateBatching,
+		ScaffoldTests:              scaffoldTests,
+		GenerateTracing:            generateTracing,
+		GoJSONPackage:              goJSONPackage,
+		GoOptionalStyle:            goOptionalStyle,
+		SkipGoFormat:               skipGoFormat,
+		GoTarget:                   goTarget,
+		GoPlatformSplit:            goPlatformSplit,
+		GenerateMocks:              generateMocks,
+		TypesOnly:                  typesOnly,
+		Standalone:                 standalone,
+		SplitByType:                splitByType,
+		ChangedTypes:               changedTypes,
+		GeneratedFileSuffix:        generatedFileSuffix,
+
+		ResolveTransitiveDependencies: resolveTransitiveDependencies,
+		DependencyConcurrency:         dependencyConcurrency,
+
+		TypeScriptDeclarationOnly: typeScriptDeclarationOnly,
+		TypeScriptCodeSplit:       typeScriptCodeSplit,
+
+		GenerateInputConstructors:  generateInputConstructors,
+		GenerateValidationTags:     generateValidationTags,
+		ValidationTagKey:           validationTagKey,
+		SeparatePreview:            separatePreview,
+		PreviewDirective:           previewDirective,
+		PreviewFieldPrefix:         previewFieldPrefix,
+		GenerateSelectors:          generateSelectors,
+		GeneratePathAccessors:      generatePathAccessors,
+		GenerateVariableStructs:    generateVariableStructs,
+		GenerateContextlessShims:   generateContextlessShims,
+		GenerateArgValidation:      generateArgValidation,
+		GuardRequiredArgs:          guardRequiredArgs,
+		GeneratePaginationHelpers:  generatePaginationHelpers,
+		TypedCursors:               typedCursors,
+		GenerateLogStreams:         generateLogStreams,
+		DocCommentWrap:             docCommentWrap,
+		AnnotateNullability:        annotateNullability,
+		HiddenTypePrefixes:         hiddenTypePrefixes,
+		ExcludeDeprecated:          excludeDeprecated,
+		StrictSchema:               strictSchema,
+		EmbedSchema:                embedSchema,
+		GenerateResponseValidation: generateResponseValidation,
+		ManifestPath:               manifestPath,
+		ManifestProvenance:         manifestProvenance,
+		ImportRewrites:             importRewrites,
+		UserRegionMarkers: generator.UserRegionMarkers{
+			Start: userRegionStart,
+			End:   userRegionEnd,
+		},
+		Backup:            backup,
+		ModuleSourceID:    moduleSourceID,
+		SchemaSnapshotDir: schemaSnapshotDir,
+	}
+}
+
+// fileConfig reads the config file at path over the defaults of the flags,
+// and overrides it with the flags set to another value than their default.
+func fileConfig(path string, flags generator.Config) (generator.Config, error) {
+	cfg, err := generator.ReadConfig(path, defaultConfig)
+	if err != nil {
+		return generator.Config{}, err
+	}
+
+	defaults, err := configFields(defaultConfig)
+	if err != nil {
+		return generator.Config{}, err
+	}
+	set, err := configFields(flags)
+	if err != nil {
+		return generator.Config{}, err
+	}
+	overrides := map[string]json.RawMessage{}
+	for name, value := range set {
+		if !bytes.Equal(value, defaults[name]) {
+			overrides[name] = value
+		}
+	}
+	dt, err := json.Marshal(overrides)
+	if err != nil {
+		return generator.Config{}, err
+	}
+	if err := json.Unmarshal(dt, &cfg); err != nil {
+		return generator.Config{}, fmt.Errorf("override config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// configFields returns the JSON encoding of each field of cfg.
+func configFields(cfg generator.Config) (map[string]json.RawMessage, error) {
+	dt, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(dt, &fields)
+}
+
 func Introspect(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	dag, err := dagger.Connect(ctx)
@@ -160,6 +486,10 @@ func Introspect(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if outputSnapshot != "" {
+		return generator.WriteSchemaSnapshot(ctx, dag, outputSnapshot)
+	}
+
 	var data any
 	err = dag.Do(ctx, &dagger.Request{
 		Query: introspection.Query,
@@ -174,6 +504,16 @@ func Introspect(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("marshal introspection json: %w", err)
 		}
+		if outputJSONSchema {
+			var resp introspection.Response
+			if err := json.Unmarshal(jsonData, &resp); err != nil {
+				return fmt.Errorf("unmarshal introspection json: %w", err)
+			}
+			jsonData, err = generator.EmitJSONSchema(resp.Schema)
+			if err != nil {
+				return fmt.Errorf("emit json schema: %w", err)
+			}
+		}
 		if outputSchema != "" {
 			return os.WriteFile(outputSchema, jsonData, 0o644) //nolint: gosec
 		}
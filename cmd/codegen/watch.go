@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+type (
+	loadSchemaFunc     func(ctx context.Context, cfg generator.Config) (*introspection.Schema, string, error)
+	generateSchemaFunc func(ctx context.Context, logsW io.Writer, schema *introspection.Schema, schemaVersion string, cfg generator.Config) error
+)
+
+// Watch generates the code, then introspects the schema every interval and
+// regenerates it whenever the schema changes, until ctx is done.
+// A change is only regenerated once the schema is the same for two
+// consecutive polls, so rapid changes (e.g. while the engine reloads a
+// module) trigger a single regeneration. The failures of the introspection
+// and of the regenerations are logged, and the watch goes on.
+func Watch(ctx context.Context, cfg generator.Config, interval time.Duration) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return watch(ctx, os.Stdout, cfg, interval, generator.LoadSchema, generateSchema)
+}
+
+func watch(
+	ctx context.Context,
+	logsW io.Writer,
+	cfg generator.Config,
+	interval time.Duration,
+	load loadSchemaFunc,
+	generate generateSchemaFunc,
+) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid watch interval %s", interval)
+	}
+
+	schema, schemaVersion, err := load(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	current, err := schemaHash(schema, schemaVersion)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(logsW, "generating from schema", current[:12])
+	if err := generate(ctx, logsW, schema, schemaVersion, cfg); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending string
+	for {
+		select {
+		case <-ctx.Done():
+			return nilIfCanceled(ctx.Err())
+		case <-ticker.C:
+		}
+
+		schema, schemaVersion, err := load(ctx, cfg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nilIfCanceled(ctx.Err())
+			}
+			// keep watching, e.g. while the engine restarts
+			fmt.Fprintln(logsW, "introspection failed:", err)
+			continue
+		}
+		hash, err := schemaHash(schema, schemaVersion)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case hash == current:
+			pending = ""
+			fmt.Fprintln(logsW, "schema unchanged [skipped]")
+			continue
+		case hash != pending:
+			pending = hash
+			fmt.Fprintln(logsW, "schema changed, waiting for it to settle [skipped]")
+			continue
+		}
+
+		pending = ""
+		current = hash
+		fmt.Fprintln(logsW, "schema changed, regenerating from schema", hash[:12])
+		if err := generate(ctx, logsW, schema, schemaVersion, cfg); err != nil {
+			if ctx.Err() != nil {
+				return nilIfCanceled(ctx.Err())
+			}
+			// keep watching, the next change may fix it
+			fmt.Fprintln(logsW, "regeneration failed:", err)
+		}
+	}
+}
+
+// schemaHash returns a hash identifying the given schema and version.
+func schemaHash(schema *introspection.Schema, schemaVersion string) (string, error) {
+	dt, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("hash schema: %w", err)
+	}
+	dt, err = generator.NormalizeIntrospection(dt)
+	if err != nil {
+		return "", fmt.Errorf("hash schema: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(schemaVersion))
+	h.Write([]byte{0})
+	h.Write(dt)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// nilIfCanceled treats the cancellation of the watch as a normal exit.
+func nilIfCanceled(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
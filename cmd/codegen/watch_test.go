@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/cmd/codegen/generator"
+	"github.com/dagger/dagger/cmd/codegen/introspection"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	schemas := []string{"A", "A", "B", "C", "C"}
+	var polls int
+	load := func(ctx context.Context, cfg generator.Config) (*introspection.Schema, string, error) {
+		name := schemas[min(polls, len(schemas)-1)]
+		polls++
+		return &introspection.Schema{Types: introspection.Types{{Name: name}}}, "v0.0.0", nil
+	}
+
+	var generated []string
+	generate := func(ctx context.Context, logsW io.Writer, schema *introspection.Schema, schemaVersion string, cfg generator.Config) error {
+		generated = append(generated, schema.Types[0].Name)
+		if len(generated) == 2 {
+			cancel()
+		}
+		return nil
+	}
+
+	var logs bytes.Buffer
+	err := watch(ctx, &logs, generator.Config{}, time.Millisecond, load, generate)
+	require.NoError(t, err)
+
+	// B is skipped as the schema changed again before settling
+	require.Equal(t, []string{"A", "C"}, generated)
+	require.Contains(t, logs.String(), "schema unchanged [skipped]")
+	require.Contains(t, logs.String(), "schema changed, waiting for it to settle [skipped]")
+	require.Contains(t, logs.String(), "schema changed, regenerating")
+}
+
+func TestWatchLoadFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the engine restarts after the initial generation
+	schemas := []string{"A", "", "", "B", "B"}
+	var polls int
+	load := func(ctx context.Context, cfg generator.Config) (*introspection.Schema, string, error) {
+		name := schemas[min(polls, len(schemas)-1)]
+		polls++
+		if name == "" {
+			return nil, "", errors.New("connection refused")
+		}
+		return &introspection.Schema{Types: introspection.Types{{Name: name}}}, "v0.0.0", nil
+	}
+
+	var generated []string
+	generate := func(ctx context.Context, logsW io.Writer, schema *introspection.Schema, schemaVersion string, cfg generator.Config) error {
+		generated = append(generated, schema.Types[0].Name)
+		if len(generated) == 2 {
+			cancel()
+		}
+		return nil
+	}
+
+	var logs bytes.Buffer
+	err := watch(ctx, &logs, generator.Config{}, time.Millisecond, load, generate)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"A", "B"}, generated)
+	require.Contains(t, logs.String(), "introspection failed: connection refused")
+}
+
+func TestWatchInvalidInterval(t *testing.T) {
+	err := watch(context.Background(), io.Discard, generator.Config{}, 0, nil, nil)
+	require.ErrorContains(t, err, "invalid watch interval")
+}
@@ -10128,6 +10128,24 @@ const (
 	CacheSharingModeShared CacheSharingMode = "SHARED"
 )
 
+// ParseCacheSharingMode returns the CacheSharingMode of the given value, or an error if it isn't one of its values.
+func ParseCacheSharingMode(s string) (CacheSharingMode, error) {
+	switch v := CacheSharingMode(s); v {
+	case
+		CacheSharingModeLocked,
+		CacheSharingModePrivate,
+		CacheSharingModeShared:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid CacheSharingMode value %q", s)
+	}
+}
+
+// String returns the value of the CacheSharingMode.
+func (v CacheSharingMode) String() string {
+	return string(v)
+}
+
 // Compression algorithm to use for image layers.
 type ImageLayerCompression string
 
@@ -10143,6 +10161,25 @@ const (
 	ImageLayerCompressionZstd ImageLayerCompression = "Zstd"
 )
 
+// ParseImageLayerCompression returns the ImageLayerCompression of the given value, or an error if it isn't one of its values.
+func ParseImageLayerCompression(s string) (ImageLayerCompression, error) {
+	switch v := ImageLayerCompression(s); v {
+	case
+		ImageLayerCompressionEstarGz,
+		ImageLayerCompressionGzip,
+		ImageLayerCompressionUncompressed,
+		ImageLayerCompressionZstd:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid ImageLayerCompression value %q", s)
+	}
+}
+
+// String returns the value of the ImageLayerCompression.
+func (v ImageLayerCompression) String() string {
+	return string(v)
+}
+
 // Mediatypes to use in published or exported image metadata.
 type ImageMediaTypes string
 
@@ -10154,6 +10191,23 @@ const (
 	ImageMediaTypesOcimediaTypes ImageMediaTypes = "OCIMediaTypes"
 )
 
+// ParseImageMediaTypes returns the ImageMediaTypes of the given value, or an error if it isn't one of its values.
+func ParseImageMediaTypes(s string) (ImageMediaTypes, error) {
+	switch v := ImageMediaTypes(s); v {
+	case
+		ImageMediaTypesDockerMediaTypes,
+		ImageMediaTypesOcimediaTypes:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid ImageMediaTypes value %q", s)
+	}
+}
+
+// String returns the value of the ImageMediaTypes.
+func (v ImageMediaTypes) String() string {
+	return string(v)
+}
+
 // The kind of module source.
 type ModuleSourceKind string
 
@@ -10167,6 +10221,24 @@ const (
 	ModuleSourceKindLocalSource ModuleSourceKind = "LOCAL_SOURCE"
 )
 
+// ParseModuleSourceKind returns the ModuleSourceKind of the given value, or an error if it isn't one of its values.
+func ParseModuleSourceKind(s string) (ModuleSourceKind, error) {
+	switch v := ModuleSourceKind(s); v {
+	case
+		ModuleSourceKindDirSource,
+		ModuleSourceKindGitSource,
+		ModuleSourceKindLocalSource:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid ModuleSourceKind value %q", s)
+	}
+}
+
+// String returns the value of the ModuleSourceKind.
+func (v ModuleSourceKind) String() string {
+	return string(v)
+}
+
 // Transport layer network protocol associated to a port.
 type NetworkProtocol string
 
@@ -10178,6 +10250,23 @@ const (
 	NetworkProtocolUdp NetworkProtocol = "UDP"
 )
 
+// ParseNetworkProtocol returns the NetworkProtocol of the given value, or an error if it isn't one of its values.
+func ParseNetworkProtocol(s string) (NetworkProtocol, error) {
+	switch v := NetworkProtocol(s); v {
+	case
+		NetworkProtocolTcp,
+		NetworkProtocolUdp:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid NetworkProtocol value %q", s)
+	}
+}
+
+// String returns the value of the NetworkProtocol.
+func (v NetworkProtocol) String() string {
+	return string(v)
+}
+
 // Expected return type of an execution
 type ReturnType string
 
@@ -10194,6 +10283,24 @@ const (
 	ReturnTypeSuccess ReturnType = "SUCCESS"
 )
 
+// ParseReturnType returns the ReturnType of the given value, or an error if it isn't one of its values.
+func ParseReturnType(s string) (ReturnType, error) {
+	switch v := ReturnType(s); v {
+	case
+		ReturnTypeAny,
+		ReturnTypeFailure,
+		ReturnTypeSuccess:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid ReturnType value %q", s)
+	}
+}
+
+// String returns the value of the ReturnType.
+func (v ReturnType) String() string {
+	return string(v)
+}
+
 // Distinguishes the different kinds of TypeDefs.
 type TypeDefKind string
 
@@ -10243,3 +10350,29 @@ const (
 	// This is used for functions that have no return value. The outer TypeDef specifying this Kind is always Optional, as the Void is never actually represented.
 	TypeDefKindVoidKind TypeDefKind = "VOID_KIND"
 )
+
+// ParseTypeDefKind returns the TypeDefKind of the given value, or an error if it isn't one of its values.
+func ParseTypeDefKind(s string) (TypeDefKind, error) {
+	switch v := TypeDefKind(s); v {
+	case
+		TypeDefKindBooleanKind,
+		TypeDefKindEnumKind,
+		TypeDefKindFloatKind,
+		TypeDefKindInputKind,
+		TypeDefKindIntegerKind,
+		TypeDefKindInterfaceKind,
+		TypeDefKindListKind,
+		TypeDefKindObjectKind,
+		TypeDefKindScalarKind,
+		TypeDefKindStringKind,
+		TypeDefKindVoidKind:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid TypeDefKind value %q", s)
+	}
+}
+
+// String returns the value of the TypeDefKind.
+func (v TypeDefKind) String() string {
+	return string(v)
+}
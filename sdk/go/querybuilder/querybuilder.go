@@ -26,6 +26,7 @@ type Selection struct {
 	prev *Selection
 
 	client graphql.Client
+	err    error
 }
 
 func (s *Selection) path() []*Selection {
@@ -96,6 +97,13 @@ func (s *Selection) marshalArguments(ctx context.Context) error {
 }
 
 func (s *Selection) Build(ctx context.Context) (string, error) {
+	path := s.path()
+	for _, sel := range path {
+		if sel.err != nil {
+			return "", sel.err
+		}
+	}
+
 	if err := s.marshalArguments(ctx); err != nil {
 		return "", err
 	}
@@ -103,8 +111,6 @@ func (s *Selection) Build(ctx context.Context) (string, error) {
 	var b strings.Builder
 	b.WriteString("query")
 
-	path := s.path()
-
 	for _, sel := range path {
 		if sel.prev != nil && sel.prev.multiple {
 			return "", fmt.Errorf("sibling selections not end of chain")
@@ -166,6 +172,15 @@ func (s *Selection) unpack(data any) error {
 	return nil
 }
 
+// WithError returns a selection failing to build with err, as do the
+// selections chained from it, e.g. to report an invalid argument of a lazy
+// field at the first request rather than when selecting it.
+func (s *Selection) WithError(err error) *Selection {
+	sel := *s
+	sel.err = err
+	return &sel
+}
+
 func (s *Selection) Client(c graphql.Client) *Selection {
 	sel := *s
 	sel.client = c
@@ -3,6 +3,7 @@ package querybuilder
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -40,6 +41,19 @@ func TestArgsCollision(t *testing.T) {
 	require.Equal(t, `query{a(arg:"one"){b(arg:"two")}}`, q)
 }
 
+func TestWithError(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	root := Query().Select("a")
+
+	sel := root.Select("b").WithError(errInvalid)
+	_, err := sel.Select("c").Build(context.Background())
+	require.ErrorIs(t, err, errInvalid)
+
+	q, err := root.Select("b").Build(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, `query{a{b}}`, q)
+}
+
 func TestNullableArgs(t *testing.T) {
 	str := "value"
 
@@ -53,6 +53,23 @@ export enum CacheSharingMode {
    */
   Shared = "SHARED",
 }
+
+/**
+ * Returns true if the value is a CacheSharingMode.
+ */
+export function isCacheSharingMode(value: unknown): value is CacheSharingMode {
+  return Object.values(CacheSharingMode).includes(value as CacheSharingMode)
+}
+
+/**
+ * Parses a CacheSharingMode from its value, throwing an Error for invalid values.
+ */
+export function parseCacheSharingMode(value: string): CacheSharingMode {
+  if (!isCacheSharingMode(value)) {
+    throw new Error(`invalid CacheSharingMode value "${value}"`)
+  }
+  return value
+}
 /**
  * The `CacheVolumeID` scalar type represents an identifier for an object of type CacheVolume.
  */
@@ -1058,6 +1075,29 @@ export enum ImageLayerCompression {
   Uncompressed = "Uncompressed",
   Zstd = "Zstd",
 }
+
+/**
+ * Returns true if the value is a ImageLayerCompression.
+ */
+export function isImageLayerCompression(
+  value: unknown,
+): value is ImageLayerCompression {
+  return Object.values(ImageLayerCompression).includes(
+    value as ImageLayerCompression,
+  )
+}
+
+/**
+ * Parses a ImageLayerCompression from its value, throwing an Error for invalid values.
+ */
+export function parseImageLayerCompression(
+  value: string,
+): ImageLayerCompression {
+  if (!isImageLayerCompression(value)) {
+    throw new Error(`invalid ImageLayerCompression value "${value}"`)
+  }
+  return value
+}
 /**
  * Mediatypes to use in published or exported image metadata.
  */
@@ -1065,6 +1105,23 @@ export enum ImageMediaTypes {
   Dockermediatypes = "DockerMediaTypes",
   Ocimediatypes = "OCIMediaTypes",
 }
+
+/**
+ * Returns true if the value is a ImageMediaTypes.
+ */
+export function isImageMediaTypes(value: unknown): value is ImageMediaTypes {
+  return Object.values(ImageMediaTypes).includes(value as ImageMediaTypes)
+}
+
+/**
+ * Parses a ImageMediaTypes from its value, throwing an Error for invalid values.
+ */
+export function parseImageMediaTypes(value: string): ImageMediaTypes {
+  if (!isImageMediaTypes(value)) {
+    throw new Error(`invalid ImageMediaTypes value "${value}"`)
+  }
+  return value
+}
 /**
  * The `InputTypeDefID` scalar type represents an identifier for an object of type InputTypeDef.
  */
@@ -1130,6 +1187,23 @@ export enum ModuleSourceKind {
   GitSource = "GIT_SOURCE",
   LocalSource = "LOCAL_SOURCE",
 }
+
+/**
+ * Returns true if the value is a ModuleSourceKind.
+ */
+export function isModuleSourceKind(value: unknown): value is ModuleSourceKind {
+  return Object.values(ModuleSourceKind).includes(value as ModuleSourceKind)
+}
+
+/**
+ * Parses a ModuleSourceKind from its value, throwing an Error for invalid values.
+ */
+export function parseModuleSourceKind(value: string): ModuleSourceKind {
+  if (!isModuleSourceKind(value)) {
+    throw new Error(`invalid ModuleSourceKind value "${value}"`)
+  }
+  return value
+}
 /**
  * Transport layer network protocol associated to a port.
  */
@@ -1137,6 +1211,23 @@ export enum NetworkProtocol {
   Tcp = "TCP",
   Udp = "UDP",
 }
+
+/**
+ * Returns true if the value is a NetworkProtocol.
+ */
+export function isNetworkProtocol(value: unknown): value is NetworkProtocol {
+  return Object.values(NetworkProtocol).includes(value as NetworkProtocol)
+}
+
+/**
+ * Parses a NetworkProtocol from its value, throwing an Error for invalid values.
+ */
+export function parseNetworkProtocol(value: string): NetworkProtocol {
+  if (!isNetworkProtocol(value)) {
+    throw new Error(`invalid NetworkProtocol value "${value}"`)
+  }
+  return value
+}
 /**
  * The `ObjectTypeDefID` scalar type represents an identifier for an object of type ObjectTypeDef.
  */
@@ -1332,6 +1423,23 @@ export enum ReturnType {
    */
   Success = "SUCCESS",
 }
+
+/**
+ * Returns true if the value is a ReturnType.
+ */
+export function isReturnType(value: unknown): value is ReturnType {
+  return Object.values(ReturnType).includes(value as ReturnType)
+}
+
+/**
+ * Parses a ReturnType from its value, throwing an Error for invalid values.
+ */
+export function parseReturnType(value: string): ReturnType {
+  if (!isReturnType(value)) {
+    throw new Error(`invalid ReturnType value "${value}"`)
+  }
+  return value
+}
 /**
  * The `SDKConfigID` scalar type represents an identifier for an object of type SDKConfig.
  */
@@ -1524,6 +1632,23 @@ export enum TypeDefKind {
    */
   VoidKind = "VOID_KIND",
 }
+
+/**
+ * Returns true if the value is a TypeDefKind.
+ */
+export function isTypeDefKind(value: unknown): value is TypeDefKind {
+  return Object.values(TypeDefKind).includes(value as TypeDefKind)
+}
+
+/**
+ * Parses a TypeDefKind from its value, throwing an Error for invalid values.
+ */
+export function parseTypeDefKind(value: string): TypeDefKind {
+  if (!isTypeDefKind(value)) {
+    throw new Error(`invalid TypeDefKind value "${value}"`)
+  }
+  return value
+}
 /**
  * The absence of a value.
  *
@@ -7873,6 +7998,7 @@ export class Client extends BaseClient {
   /**
    * Returns a file containing an http remote url content.
    * @param url HTTP url to get the content from (e.g., "https://docs.dagger.io").
+   * @see https://docs.dagger.io
    * @param opts.name File name to use for the file. Defaults to the last part of the URL.
    * @param opts.permissions Permissions to set on the file.
    * @param opts.authHeader Secret used to populate the Authorization HTTP header